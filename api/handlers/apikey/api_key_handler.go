@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/apikey"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type APIKeyHandler interface {
+	CreateKey(c *gin.Context)
+	ListKeys(c *gin.Context)
+	RevokeKey(c *gin.Context)
+}
+
+type apiKeyHandler struct {
+	apiKeyService services.APIKeyService
+	logger        *logrus.Logger
+}
+
+func NewAPIKeyHandler(apiKeyService services.APIKeyService, logger *logrus.Logger) APIKeyHandler {
+	return &apiKeyHandler{
+		apiKeyService: apiKeyService,
+		logger:        logger,
+	}
+}
+
+// CreateKey godoc
+// @Summary      Crear API key
+// @Description  Crea una API key personal; la llave completa solo se muestra en esta respuesta
+// @Tags         keys
+// @Accept       json
+// @Produce      json
+// @Param        request body models.CreateAPIKeyRequest true "Datos de la API key"
+// @Success      201 {object} models.CreateAPIKeyResponse
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/keys [post]
+func (h *apiKeyHandler) CreateKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("[Layer: api_key_handler] [Method: CreateKey] Datos inválidos: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre es requerido y el scope debe ser read o read_write"})
+		return
+	}
+	username, _ := c.Get("username")
+	key, raw, err := h.apiKeyService.CreateKey(c.Request.Context(), username.(string), req.Name, req.Scope, req.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNameRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre es requerido"})
+		case errors.Is(err, services.ErrInvalidScope):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El scope debe ser read o read_write"})
+		case errors.Is(err, services.ErrInvalidExpiry):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La fecha de expiración debe ser futura"})
+		default:
+			h.logger.Error("[Layer: api_key_handler] [Method: CreateKey] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear la API key"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, models.CreateAPIKeyResponse{
+		APIKeyResponse: toAPIKeyResponse(key),
+		Key:            raw,
+	})
+}
+
+// ListKeys godoc
+// @Summary      Listar API keys
+// @Description  Lista las API keys activas del usuario autenticado (sin la llave completa)
+// @Tags         keys
+// @Produce      json
+// @Success      200 {array} models.APIKeyResponse
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/keys [get]
+func (h *apiKeyHandler) ListKeys(c *gin.Context) {
+	username, _ := c.Get("username")
+	keys, err := h.apiKeyService.ListKeys(c.Request.Context(), username.(string))
+	if err != nil {
+		h.logger.Error("[Layer: api_key_handler] [Method: ListKeys] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener API keys"})
+		return
+	}
+	resp := make([]models.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, toAPIKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeKey godoc
+// @Summary      Revocar API key
+// @Description  Revoca una API key del usuario autenticado
+// @Tags         keys
+// @Produce      json
+// @Param        id path int true "ID de la API key"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/keys/{id} [delete]
+func (h *apiKeyHandler) RevokeKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: api_key_handler] [Method: RevokeKey] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	username, _ := c.Get("username")
+	if err := h.apiKeyService.RevokeKey(c.Request.Context(), id, username.(string)); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			h.logger.Warn("[Layer: api_key_handler] [Method: RevokeKey] No encontrada: ", err)
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key no encontrada"})
+			return
+		}
+		h.logger.Error("[Layer: api_key_handler] [Method: RevokeKey] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo revocar la API key"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revocada exitosamente"})
+}
+
+func toAPIKeyResponse(key *models.APIKey) models.APIKeyResponse {
+	return models.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scope:      key.Scope,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+	}
+}
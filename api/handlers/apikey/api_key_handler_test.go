@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/apikey"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPIKeyHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	key := &models.APIKey{Name: "ci", Prefix: "gk_12345678", Scope: models.APIKeyScopeRead}
+	key.ID = 1
+
+	testScenarios := []struct {
+		testName       string
+		method         string
+		path           string
+		body           string
+		mockSetup      func(*mockAPIKeyService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName:       "Crear sin nombre",
+			method:         http.MethodPost,
+			path:           "/keys",
+			body:           `{"scope":"read"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"El nombre es requerido y el scope debe ser read o read_write"`,
+		},
+		{
+			testName:       "Crear con scope inválido",
+			method:         http.MethodPost,
+			path:           "/keys",
+			body:           `{"name":"ci","scope":"admin"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"El nombre es requerido y el scope debe ser read o read_write"`,
+		},
+		{
+			testName: "Crear con expiración pasada",
+			method:   http.MethodPost,
+			path:     "/keys",
+			body:     `{"name":"ci","expires_at":"2000-01-01T00:00:00Z"}`,
+			mockSetup: func(m *mockAPIKeyService) {
+				m.On("CreateKey", mock.Anything, "user1", "ci", "", mock.Anything).
+					Return((*models.APIKey)(nil), "", services.ErrInvalidExpiry)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"La fecha de expiración debe ser futura"`,
+		},
+		{
+			testName: "Crear muestra la llave una vez",
+			method:   http.MethodPost,
+			path:     "/keys",
+			body:     `{"name":"ci"}`,
+			mockSetup: func(m *mockAPIKeyService) {
+				m.On("CreateKey", mock.Anything, "user1", "ci", "", mock.Anything).Return(key, "gk_12345678abcdef", nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"key":"gk_12345678abcdef"`,
+		},
+		{
+			testName: "Listar no incluye la llave",
+			method:   http.MethodGet,
+			path:     "/keys",
+			mockSetup: func(m *mockAPIKeyService) {
+				m.On("ListKeys", mock.Anything, "user1").Return([]*models.APIKey{key}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"prefix":"gk_12345678","scope":"read"`,
+		},
+		{
+			testName: "Error al listar",
+			method:   http.MethodGet,
+			path:     "/keys",
+			mockSetup: func(m *mockAPIKeyService) {
+				m.On("ListKeys", mock.Anything, "user1").Return(([]*models.APIKey)(nil), errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `"error":"Error al obtener API keys"`,
+		},
+		{
+			testName:       "Revocar con ID inválido",
+			method:         http.MethodDelete,
+			path:           "/keys/abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"ID inválido"`,
+		},
+		{
+			testName: "Revocar llave ajena",
+			method:   http.MethodDelete,
+			path:     "/keys/2",
+			mockSetup: func(m *mockAPIKeyService) {
+				m.On("RevokeKey", mock.Anything, 2, "user1").Return(services.ErrAPIKeyNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"API key no encontrada"`,
+		},
+		{
+			testName: "Revocar llave",
+			method:   http.MethodDelete,
+			path:     "/keys/1",
+			mockSetup: func(m *mockAPIKeyService) {
+				m.On("RevokeKey", mock.Anything, 1, "user1").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"message":"API key revocada exitosamente"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockAPIKeyService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			logger := logrus.New()
+			handler := NewAPIKeyHandler(mockService, logger)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.POST("/keys", handler.CreateKey)
+			router.GET("/keys", handler.ListKeys)
+			router.DELETE("/keys/:id", handler.RevokeKey)
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
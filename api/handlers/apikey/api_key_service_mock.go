@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *mockAPIKeyService) CreateKey(ctx context.Context, username, name, scope string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	args := m.Called(ctx, username, name, scope, expiresAt)
+	return args.Get(0).(*models.APIKey), args.String(1), args.Error(2)
+}
+func (m *mockAPIKeyService) ListKeys(ctx context.Context, username string) ([]*models.APIKey, error) {
+	args := m.Called(ctx, username)
+	return args.Get(0).([]*models.APIKey), args.Error(1)
+}
+func (m *mockAPIKeyService) RevokeKey(ctx context.Context, id int, username string) error {
+	args := m.Called(ctx, id, username)
+	return args.Error(0)
+}
+func (m *mockAPIKeyService) ValidateKey(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	args := m.Called(ctx, rawKey)
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
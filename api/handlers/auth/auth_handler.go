@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
 	"prueba_tecnica_go_guarapo/api/models"
 	services "prueba_tecnica_go_guarapo/api/services/auth"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -11,7 +14,21 @@ import (
 )
 
 type AuthHandler interface {
+	Register(c *gin.Context)
 	Login(c *gin.Context)
+	LoginTwoFactor(c *gin.Context)
+	SetupTwoFactor(c *gin.Context)
+	VerifyTwoFactor(c *gin.Context)
+	Refresh(c *gin.Context)
+	Logout(c *gin.Context)
+	LogoutAll(c *gin.Context)
+	ListSessions(c *gin.Context)
+	RevokeSession(c *gin.Context)
+	ListUsers(c *gin.Context)
+	DisableUser(c *gin.Context)
+	EnableUser(c *gin.Context)
+	UnlockUser(c *gin.Context)
+	SetUserRole(c *gin.Context)
 }
 
 type authHandler struct {
@@ -26,21 +43,65 @@ func NewAuthHandler(authService services.AuthService, logger *logrus.Logger) Aut
 	}
 }
 
+// Register godoc
+// @Summary      Registro de usuario
+// @Description  Crea una cuenta de usuario con contraseña
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.RegisterRequest true "Datos de registro"
+// @Success      201 {object} models.UserResponse
+// @Failure      400 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /api/register [post]
+func (h *authHandler) Register(c *gin.Context) {
+	var req models.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("[Layer: auth_handler] [Method: Register] Invalid JSON: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username y password son requeridos"})
+		return
+	}
+
+	user, err := h.authService.Register(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUsernameRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Username no puede estar vacío"})
+		case errors.Is(err, services.ErrPasswordTooShort):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La contraseña debe tener al menos 8 caracteres"})
+		case errors.Is(err, services.ErrUsernameTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "El username ya está registrado"})
+		default:
+			h.logger.Error("[Layer: auth_handler] [Method: Register] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo registrar el usuario"})
+		}
+		return
+	}
+	h.logger.Infof("[Layer: auth_handler] [Method: Register] Usuario '%s' registrado", user.Username)
+	c.JSON(http.StatusCreated, toUserResponse(user))
+}
+
 // Login godoc
 // @Summary      Login de usuario
-// @Description  Autentica un usuario y retorna un token
+// @Description  Verifica usuario y contraseña y retorna un token. Si la cuenta tiene 2FA retorna un challenge_token que se canjea en /api/login/2fa
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        request body models.LoginRequest true "Credenciales de login"
 // @Success      200 {object} models.LoginResponse
+// @Success      202 {object} models.TwoFactorChallengeResponse
 // @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Failure      500 {object} map[string]string
 // @Router       /api/login [post]
 func (h *authHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Warn("[Layer: auth_handler] [Method: Login] Invalid JSON: ", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username es requerido"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username y password son requeridos"})
 		return
 	}
 
@@ -50,9 +111,462 @@ func (h *authHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token := h.authService.Login(c.Request.Context(), req.Username)
+	result, err := h.authService.Login(c.Request.Context(), req.Username, req.Password, clientInfo(c))
+	if err != nil {
+		if h.tooManyAttempts(c, err) {
+			h.logger.Warnf("[Layer: auth_handler] [Method: Login] Login bloqueado temporalmente para '%s' desde %s", req.Username, c.ClientIP())
+			return
+		}
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			h.logger.Warnf("[Layer: auth_handler] [Method: Login] Credenciales inválidas para '%s'", req.Username)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Credenciales inválidas"})
+			return
+		}
+		if errors.Is(err, services.ErrUserDisabled) {
+			h.logger.Warnf("[Layer: auth_handler] [Method: Login] Cuenta deshabilitada '%s'", req.Username)
+			c.JSON(http.StatusForbidden, gin.H{"error": "La cuenta está deshabilitada"})
+			return
+		}
+		h.logger.Error("[Layer: auth_handler] [Method: Login] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo iniciar sesión"})
+		return
+	}
+	if result.ChallengeToken != "" {
+		h.logger.Infof("[Layer: auth_handler] [Method: Login] Usuario '%s' debe completar el segundo factor", req.Username)
+		c.JSON(http.StatusAccepted, models.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    result.ChallengeToken,
+			ExpiresIn:         int64(result.ChallengeExpiresIn.Seconds()),
+		})
+		return
+	}
 	h.logger.Infof("[Layer: auth_handler] [Method: Login] Usuario '%s' autenticado", req.Username)
-	response := models.LoginResponse{Token: token}
+	c.JSON(http.StatusOK, toLoginResponse(result.TokenPair))
+}
+
+// LoginTwoFactor godoc
+// @Summary      Completar login con 2FA
+// @Description  Canjea el challenge_token de /api/login junto a un código TOTP o un código de recuperación
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.TwoFactorLoginRequest true "Desafío y código"
+// @Success      200 {object} models.LoginResponse
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /api/login/2fa [post]
+func (h *authHandler) LoginTwoFactor(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("[Layer: auth_handler] [Method: LoginTwoFactor] Invalid JSON: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "challenge_token y code son requeridos"})
+		return
+	}
+
+	pair, err := h.authService.CompleteTwoFactorLogin(c.Request.Context(), req.ChallengeToken, req.Code, clientInfo(c))
+	if err != nil {
+		if h.tooManyAttempts(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrTOTPInvalidCode):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Código inválido"})
+		case errors.Is(err, services.ErrChallengeExpired):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "El desafío expiró, inicia sesión de nuevo"})
+		case errors.Is(err, services.ErrChallengeInvalid):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Desafío inválido, inicia sesión de nuevo"})
+		case errors.Is(err, services.ErrUserDisabled):
+			c.JSON(http.StatusForbidden, gin.H{"error": "La cuenta está deshabilitada"})
+		default:
+			h.logger.Error("[Layer: auth_handler] [Method: LoginTwoFactor] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo iniciar sesión"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, toLoginResponse(pair))
+}
+
+// SetupTwoFactor godoc
+// @Summary      Iniciar enrolamiento 2FA
+// @Description  Genera un secreto TOTP y su URI otpauth:// para registrarlo en la app de autenticación. No se exige hasta confirmarlo en /api/2fa/verify
+// @Tags         2fa
+// @Produce      json
+// @Success      200 {object} models.TwoFactorSetupResponse
+// @Failure      401 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/2fa/setup [post]
+func (h *authHandler) SetupTwoFactor(c *gin.Context) {
+	username, _ := c.Get("username")
+	setup, err := h.authService.SetupTOTP(c.Request.Context(), username.(string))
+	if err != nil {
+		if errors.Is(err, services.ErrTOTPAlreadyEnabled) {
+			c.JSON(http.StatusConflict, gin.H{"error": "La verificación en dos pasos ya está activa"})
+			return
+		}
+		h.logger.Error("[Layer: auth_handler] [Method: SetupTwoFactor] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo iniciar la verificación en dos pasos"})
+		return
+	}
+	c.JSON(http.StatusOK, models.TwoFactorSetupResponse{Secret: setup.Secret, OTPAuthURI: setup.URI})
+}
+
+// VerifyTwoFactor godoc
+// @Summary      Confirmar enrolamiento 2FA
+// @Description  Activa la verificación en dos pasos con un código válido y retorna los códigos de recuperación (solo se muestran una vez)
+// @Tags         2fa
+// @Accept       json
+// @Produce      json
+// @Param        request body models.TwoFactorVerifyRequest true "Código TOTP"
+// @Success      200 {object} models.TwoFactorVerifyResponse
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/2fa/verify [post]
+func (h *authHandler) VerifyTwoFactor(c *gin.Context) {
+	var req models.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("[Layer: auth_handler] [Method: VerifyTwoFactor] Invalid JSON: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El código es requerido"})
+		return
+	}
+	username, _ := c.Get("username")
+	codes, err := h.authService.EnableTOTP(c.Request.Context(), username.(string), req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTOTPInvalidCode):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Código inválido"})
+		case errors.Is(err, services.ErrTOTPSetupRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Primero inicia el enrolamiento en /api/2fa/setup"})
+		case errors.Is(err, services.ErrTOTPAlreadyEnabled):
+			c.JSON(http.StatusConflict, gin.H{"error": "La verificación en dos pasos ya está activa"})
+		default:
+			h.logger.Error("[Layer: auth_handler] [Method: VerifyTwoFactor] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo activar la verificación en dos pasos"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, models.TwoFactorVerifyResponse{RecoveryCodes: codes})
+}
+
+// Refresh godoc
+// @Summary      Renovar tokens
+// @Description  Intercambia un refresh token por un nuevo par de tokens. El refresh token usado queda invalidado; reutilizarlo revoca toda la sesión
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.RefreshRequest true "Refresh token"
+// @Success      200 {object} models.LoginResponse
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /api/token/refresh [post]
+func (h *authHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("[Layer: auth_handler] [Method: Refresh] Invalid JSON: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token es requerido"})
+		return
+	}
+
+	pair, err := h.authService.Refresh(c.Request.Context(), req.RefreshToken, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRefreshTokenExpired):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token expirado"})
+		case errors.Is(err, services.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reutilizado, la sesión fue revocada"})
+		case errors.Is(err, services.ErrRefreshTokenInvalid):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token inválido"})
+		case errors.Is(err, services.ErrUserDisabled):
+			c.JSON(http.StatusForbidden, gin.H{"error": "La cuenta está deshabilitada"})
+		default:
+			h.logger.Error("[Layer: auth_handler] [Method: Refresh] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo renovar el token"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, toLoginResponse(pair))
+}
+
+// Logout godoc
+// @Summary      Cerrar sesión
+// @Description  Revoca el token actual y la sesión a la que pertenece
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/logout [post]
+func (h *authHandler) Logout(c *gin.Context) {
+	claims, _ := c.Get("claims")
+	if err := h.authService.Logout(c.Request.Context(), claims.(*services.TokenClaims)); err != nil {
+		h.logger.Error("[Layer: auth_handler] [Method: Logout] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo cerrar la sesión"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sesión cerrada exitosamente"})
+}
+
+// LogoutAll godoc
+// @Summary      Cerrar todas las sesiones
+// @Description  Revoca todas las sesiones activas del usuario autenticado
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/logout/all [post]
+func (h *authHandler) LogoutAll(c *gin.Context) {
+	username, _ := c.Get("username")
+	if err := h.authService.LogoutAll(c.Request.Context(), username.(string)); err != nil {
+		h.logger.Error("[Layer: auth_handler] [Method: LogoutAll] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron cerrar las sesiones"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Todas las sesiones fueron cerradas"})
+}
+
+// ListSessions godoc
+// @Summary      Listar sesiones
+// @Description  Lista las sesiones activas del usuario autenticado
+// @Tags         auth
+// @Produce      json
+// @Success      200 {array} models.SessionResponse
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/sessions [get]
+func (h *authHandler) ListSessions(c *gin.Context) {
+	username, _ := c.Get("username")
+	claims, _ := c.Get("claims")
+	sessions, err := h.authService.ListSessions(c.Request.Context(), username.(string))
+	if err != nil {
+		h.logger.Error("[Layer: auth_handler] [Method: ListSessions] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener sesiones"})
+		return
+	}
+	currentID := claims.(*services.TokenClaims).SessionID
+	resp := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, models.SessionResponse{
+			ID:         session.ID,
+			ClientIP:   session.ClientIP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentID,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeSession godoc
+// @Summary      Cerrar una sesión
+// @Description  Revoca una sesión específica del usuario autenticado
+// @Tags         auth
+// @Produce      json
+// @Param        id path string true "ID de la sesión"
+// @Success      200 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/sessions/{id} [delete]
+func (h *authHandler) RevokeSession(c *gin.Context) {
+	username, _ := c.Get("username")
+	err := h.authService.RevokeSession(c.Request.Context(), username.(string), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			h.logger.Warn("[Layer: auth_handler] [Method: RevokeSession] No encontrada: ", err)
+			c.JSON(http.StatusNotFound, gin.H{"error": "Sesión no encontrada"})
+			return
+		}
+		h.logger.Error("[Layer: auth_handler] [Method: RevokeSession] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo cerrar la sesión"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sesión cerrada exitosamente"})
+}
+
+// ListUsers godoc
+// @Summary      Listar usuarios (admin)
+// @Description  Lista todos los usuarios registrados
+// @Tags         admin
+// @Produce      json
+// @Success      200 {array} models.UserResponse
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/admin/users [get]
+func (h *authHandler) ListUsers(c *gin.Context) {
+	users, err := h.authService.ListUsers(c.Request.Context())
+	if err != nil {
+		h.logger.Error("[Layer: auth_handler] [Method: ListUsers] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener usuarios"})
+		return
+	}
+	resp := make([]models.UserResponse, 0, len(users))
+	for _, user := range users {
+		resp = append(resp, toUserResponse(user))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DisableUser godoc
+// @Summary      Deshabilitar usuario (admin)
+// @Description  Deshabilita la cuenta y cierra todas sus sesiones
+// @Tags         admin
+// @Produce      json
+// @Param        username path string true "Username"
+// @Success      200 {object} models.UserResponse
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/admin/users/{username}/disable [post]
+func (h *authHandler) DisableUser(c *gin.Context) {
+	h.setUserDisabled(c, true)
+}
+
+// EnableUser godoc
+// @Summary      Habilitar usuario (admin)
+// @Description  Vuelve a habilitar una cuenta deshabilitada
+// @Tags         admin
+// @Produce      json
+// @Param        username path string true "Username"
+// @Success      200 {object} models.UserResponse
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/admin/users/{username}/enable [post]
+func (h *authHandler) EnableUser(c *gin.Context) {
+	h.setUserDisabled(c, false)
+}
+
+// UnlockUser godoc
+// @Summary      Desbloquear usuario (admin)
+// @Description  Limpia los intentos fallidos de login y el bloqueo temporal de la cuenta
+// @Tags         admin
+// @Produce      json
+// @Param        username path string true "Username"
+// @Success      200 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/admin/users/{username}/unlock [post]
+func (h *authHandler) UnlockUser(c *gin.Context) {
+	username := c.Param("username")
+	if err := h.authService.UnlockUser(c.Request.Context(), username); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+			return
+		}
+		h.logger.Error("[Layer: auth_handler] [Method: UnlockUser] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo desbloquear el usuario"})
+		return
+	}
+	h.logger.Infof("[Layer: auth_handler] [Method: UnlockUser] Usuario '%s' desbloqueado", username)
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario desbloqueado"})
+}
+
+func (h *authHandler) setUserDisabled(c *gin.Context, disabled bool) {
+	user, err := h.authService.SetUserDisabled(c.Request.Context(), c.Param("username"), disabled)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+			return
+		}
+		h.logger.Error("[Layer: auth_handler] [Method: setUserDisabled] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el usuario"})
+		return
+	}
+	c.JSON(http.StatusOK, toUserResponse(user))
+}
 
-	c.JSON(http.StatusOK, response)
+// SetUserRole godoc
+// @Summary      Cambiar rol (admin)
+// @Description  Asigna el rol user o admin; el usuario debe volver a iniciar sesión
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        username path string true "Username"
+// @Param        request body models.SetRoleRequest true "Nuevo rol"
+// @Success      200 {object} models.UserResponse
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/admin/users/{username}/role [put]
+func (h *authHandler) SetUserRole(c *gin.Context) {
+	var req models.SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("[Layer: auth_handler] [Method: SetUserRole] Invalid JSON: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El rol debe ser user o admin"})
+		return
+	}
+	user, err := h.authService.SetUserRole(c.Request.Context(), c.Param("username"), req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		case errors.Is(err, services.ErrInvalidRole):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El rol debe ser user o admin"})
+		default:
+			h.logger.Error("[Layer: auth_handler] [Method: SetUserRole] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el usuario"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+func toUserResponse(user *models.User) models.UserResponse {
+	return models.UserResponse{
+		ID:               user.ID,
+		Username:         user.Username,
+		Role:             user.Role,
+		Disabled:         user.Disabled,
+		TwoFactorEnabled: user.TOTPEnabled,
+	}
+}
+
+// tooManyAttempts responde 429 con Retry-After si el login está bloqueado temporalmente.
+func (h *authHandler) tooManyAttempts(c *gin.Context, err error) bool {
+	var lockErr *services.LockoutError
+	if !errors.As(err, &lockErr) {
+		return false
+	}
+	seconds := int64(math.Ceil(lockErr.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Demasiados intentos fallidos, intenta de nuevo más tarde"})
+	return true
+}
+
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+func toLoginResponse(pair *services.TokenPair) models.LoginResponse {
+	return models.LoginResponse{
+		Token:        pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		ExpiresIn:    int64(pair.ExpiresIn.Seconds()),
+	}
 }
@@ -3,10 +3,13 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/auth"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -18,34 +21,91 @@ func TestAuthHandler_Login(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	testScenarios := []struct {
-		testName       string
-		requestBody    interface{}
-		mockSetup      func(*mockAuthService)
-		expectedStatus int
-		expectedBody   string
+		testName           string
+		requestBody        interface{}
+		mockSetup          func(*mockAuthService)
+		expectedStatus     int
+		expectedBody       string
+		expectedRetryAfter string
 	}{
 		{
 			testName:    "Login exitoso",
-			requestBody: models.LoginRequest{Username: "user1"},
+			requestBody: models.LoginRequest{Username: "user1", Password: "password123"},
 			mockSetup: func(m *mockAuthService) {
-				m.On("Login", mock.Anything, "user1").Return("token123")
+				m.On("Login", mock.Anything, "user1", "password123", mock.Anything).
+					Return(&services.LoginResult{TokenPair: &services.TokenPair{AccessToken: "token123", RefreshToken: "refresh123", ExpiresIn: 15 * time.Minute}}, nil)
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"token":"token123"}`,
+			expectedBody:   `{"token":"token123","refresh_token":"refresh123","expires_in":900}`,
+		},
+		{
+			testName:    "Cuenta con 2FA devuelve desafío",
+			requestBody: models.LoginRequest{Username: "user1", Password: "password123"},
+			mockSetup: func(m *mockAuthService) {
+				m.On("Login", mock.Anything, "user1", "password123", mock.Anything).
+					Return(&services.LoginResult{ChallengeToken: "challenge123", ChallengeExpiresIn: 5 * time.Minute}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `{"two_factor_required":true,"challenge_token":"challenge123","expires_in":300}`,
+		},
+		{
+			testName:    "Credenciales inválidas",
+			requestBody: models.LoginRequest{Username: "user1", Password: "wrongpassword"},
+			mockSetup: func(m *mockAuthService) {
+				m.On("Login", mock.Anything, "user1", "wrongpassword", mock.Anything).Return((*services.LoginResult)(nil), services.ErrInvalidCredentials)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"Credenciales inválidas"}`,
+		},
+		{
+			testName:    "Cuenta deshabilitada",
+			requestBody: models.LoginRequest{Username: "user1", Password: "password123"},
+			mockSetup: func(m *mockAuthService) {
+				m.On("Login", mock.Anything, "user1", "password123", mock.Anything).Return((*services.LoginResult)(nil), services.ErrUserDisabled)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"error":"La cuenta está deshabilitada"}`,
+		},
+		{
+			testName:    "Demasiados intentos fallidos",
+			requestBody: models.LoginRequest{Username: "user1", Password: "password123"},
+			mockSetup: func(m *mockAuthService) {
+				m.On("Login", mock.Anything, "user1", "password123", mock.Anything).
+					Return((*services.LoginResult)(nil), &services.LockoutError{RetryAfter: 1500 * time.Millisecond})
+			},
+			expectedStatus:     http.StatusTooManyRequests,
+			expectedBody:       `{"error":"Demasiados intentos fallidos, intenta de nuevo más tarde"}`,
+			expectedRetryAfter: "2",
+		},
+		{
+			testName:    "Error interno",
+			requestBody: models.LoginRequest{Username: "user1", Password: "password123"},
+			mockSetup: func(m *mockAuthService) {
+				m.On("Login", mock.Anything, "user1", "password123", mock.Anything).Return((*services.LoginResult)(nil), errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"No se pudo iniciar sesión"}`,
 		},
 		{
 			testName:       "JSON inválido",
 			requestBody:    `{bad json}`,
 			mockSetup:      nil,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":"Username es requerido"}`,
+			expectedBody:   `{"error":"Username y password son requeridos"}`,
 		},
 		{
 			testName:       "Username vacío",
-			requestBody:    models.LoginRequest{Username: ""},
+			requestBody:    models.LoginRequest{Username: "", Password: "password123"},
 			mockSetup:      nil,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":"Username es requerido"}`,
+			expectedBody:   `{"error":"Username y password son requeridos"}`,
+		},
+		{
+			testName:       "Password vacío",
+			requestBody:    models.LoginRequest{Username: "user1", Password: ""},
+			mockSetup:      nil,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"Username y password son requeridos"}`,
 		},
 	}
 
@@ -79,6 +139,617 @@ func TestAuthHandler_Login(t *testing.T) {
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.Equal(t, tt.expectedRetryAfter, w.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestAuthHandler_Register(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		requestBody    interface{}
+		mockSetup      func(*mockAuthService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName:    "Registro exitoso",
+			requestBody: models.RegisterRequest{Username: "user1", Password: "password123"},
+			mockSetup: func(m *mockAuthService) {
+				user := &models.User{Username: "user1", Role: models.RoleUser}
+				user.ID = 1
+				m.On("Register", mock.Anything, "user1", "password123").Return(user, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id":1,"username":"user1","role":"user","disabled":false,"two_factor_enabled":false}`,
+		},
+		{
+			testName:       "JSON inválido",
+			requestBody:    `{bad json}`,
+			mockSetup:      nil,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"Username y password son requeridos"}`,
+		},
+		{
+			testName:    "Contraseña corta",
+			requestBody: models.RegisterRequest{Username: "user1", Password: "short"},
+			mockSetup: func(m *mockAuthService) {
+				m.On("Register", mock.Anything, "user1", "short").Return((*models.User)(nil), services.ErrPasswordTooShort)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"La contraseña debe tener al menos 8 caracteres"}`,
+		},
+		{
+			testName:    "Username ya registrado",
+			requestBody: models.RegisterRequest{Username: "user1", Password: "password123"},
+			mockSetup: func(m *mockAuthService) {
+				m.On("Register", mock.Anything, "user1", "password123").Return((*models.User)(nil), services.ErrUsernameTaken)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"error":"El username ya está registrado"}`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockAuthService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			logger := logrus.New()
+			handler := NewAuthHandler(mockService, logger)
+
+			router := gin.New()
+			router.POST("/register", handler.Register)
+
+			var reqBody []byte
+			var err error
+			switch v := tt.requestBody.(type) {
+			case string:
+				reqBody = []byte(v)
+			default:
+				reqBody, err = json.Marshal(v)
+				assert.NoError(t, err)
+			}
+
+			req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestAuthHandler_Refresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		requestBody    interface{}
+		mockSetup      func(*mockAuthService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName:    "Rotación exitosa",
+			requestBody: models.RefreshRequest{RefreshToken: "refresh123"},
+			mockSetup: func(m *mockAuthService) {
+				m.On("Refresh", mock.Anything, "refresh123", mock.Anything).
+					Return(&services.TokenPair{AccessToken: "token456", RefreshToken: "refresh456", ExpiresIn: 15 * time.Minute}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"token":"token456","refresh_token":"refresh456","expires_in":900}`,
+		},
+		{
+			testName:       "Sin refresh token",
+			requestBody:    `{}`,
+			mockSetup:      nil,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"refresh_token es requerido"}`,
+		},
+		{
+			testName:    "Refresh token reutilizado",
+			requestBody: models.RefreshRequest{RefreshToken: "old"},
+			mockSetup: func(m *mockAuthService) {
+				m.On("Refresh", mock.Anything, "old", mock.Anything).Return((*services.TokenPair)(nil), services.ErrRefreshTokenReused)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"Refresh token reutilizado, la sesión fue revocada"}`,
+		},
+		{
+			testName:    "Refresh token expirado",
+			requestBody: models.RefreshRequest{RefreshToken: "expired"},
+			mockSetup: func(m *mockAuthService) {
+				m.On("Refresh", mock.Anything, "expired", mock.Anything).Return((*services.TokenPair)(nil), services.ErrRefreshTokenExpired)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"Refresh token expirado"}`,
+		},
+		{
+			testName:    "Refresh token inválido",
+			requestBody: models.RefreshRequest{RefreshToken: "unknown"},
+			mockSetup: func(m *mockAuthService) {
+				m.On("Refresh", mock.Anything, "unknown", mock.Anything).Return((*services.TokenPair)(nil), services.ErrRefreshTokenInvalid)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"Refresh token inválido"}`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockAuthService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			logger := logrus.New()
+			handler := NewAuthHandler(mockService, logger)
+
+			router := gin.New()
+			router.POST("/token/refresh", handler.Refresh)
+
+			var reqBody []byte
+			var err error
+			switch v := tt.requestBody.(type) {
+			case string:
+				reqBody = []byte(v)
+			default:
+				reqBody, err = json.Marshal(v)
+				assert.NoError(t, err)
+			}
+
+			req, _ := http.NewRequest(http.MethodPost, "/token/refresh", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	claims := &services.TokenClaims{SessionID: "session1"}
+	claims.Subject = "user1"
+	claims.ID = "jti1"
+
+	testScenarios := []struct {
+		testName       string
+		path           string
+		mockSetup      func(*mockAuthService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Logout exitoso",
+			path:     "/logout",
+			mockSetup: func(m *mockAuthService) {
+				m.On("Logout", mock.Anything, claims).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"message":"Sesión cerrada exitosamente"`,
+		},
+		{
+			testName: "Error al revocar",
+			path:     "/logout",
+			mockSetup: func(m *mockAuthService) {
+				m.On("Logout", mock.Anything, claims).Return(errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `"error":"No se pudo cerrar la sesión"`,
+		},
+		{
+			testName: "Logout de todas las sesiones",
+			path:     "/logout/all",
+			mockSetup: func(m *mockAuthService) {
+				m.On("LogoutAll", mock.Anything, "user1").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"message":"Todas las sesiones fueron cerradas"`,
+		},
+		{
+			testName: "Error al revocar todas las sesiones",
+			path:     "/logout/all",
+			mockSetup: func(m *mockAuthService) {
+				m.On("LogoutAll", mock.Anything, "user1").Return(errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `"error":"No se pudieron cerrar las sesiones"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockAuthService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			logger := logrus.New()
+			handler := NewAuthHandler(mockService, logger)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+				c.Set("claims", claims)
+			})
+			router.POST("/logout", handler.Logout)
+			router.POST("/logout/all", handler.LogoutAll)
+
+			req, _ := http.NewRequest(http.MethodPost, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestAuthHandler_Sessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	claims := &services.TokenClaims{SessionID: "session1"}
+	claims.Subject = "user1"
+
+	testScenarios := []struct {
+		testName       string
+		method         string
+		path           string
+		mockSetup      func(*mockAuthService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Listar sesiones marca la actual",
+			method:   http.MethodGet,
+			path:     "/sessions",
+			mockSetup: func(m *mockAuthService) {
+				m.On("ListSessions", mock.Anything, "user1").
+					Return([]*models.Session{{ID: "session1", UserAgent: "laptop"}, {ID: "session2", UserAgent: "phone"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"current":true},{"id":"session2"`,
+		},
+		{
+			testName: "Error al listar sesiones",
+			method:   http.MethodGet,
+			path:     "/sessions",
+			mockSetup: func(m *mockAuthService) {
+				m.On("ListSessions", mock.Anything, "user1").Return(([]*models.Session)(nil), errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `"error":"Error al obtener sesiones"`,
+		},
+		{
+			testName: "Cerrar sesión",
+			method:   http.MethodDelete,
+			path:     "/sessions/session2",
+			mockSetup: func(m *mockAuthService) {
+				m.On("RevokeSession", mock.Anything, "user1", "session2").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"message":"Sesión cerrada exitosamente"`,
+		},
+		{
+			testName: "Sesión no encontrada",
+			method:   http.MethodDelete,
+			path:     "/sessions/unknown",
+			mockSetup: func(m *mockAuthService) {
+				m.On("RevokeSession", mock.Anything, "user1", "unknown").Return(services.ErrSessionNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"Sesión no encontrada"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockAuthService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			logger := logrus.New()
+			handler := NewAuthHandler(mockService, logger)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+				c.Set("claims", claims)
+			})
+			router.GET("/sessions", handler.ListSessions)
+			router.DELETE("/sessions/:id", handler.RevokeSession)
+
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestAuthHandler_AdminUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		method         string
+		path           string
+		requestBody    string
+		mockSetup      func(*mockAuthService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Listar usuarios",
+			method:   http.MethodGet,
+			path:     "/admin/users",
+			mockSetup: func(m *mockAuthService) {
+				m.On("ListUsers", mock.Anything).
+					Return([]*models.User{{Username: "admin", Role: models.RoleAdmin}, {Username: "user1", Role: models.RoleUser}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"username":"admin","role":"admin"`,
+		},
+		{
+			testName: "Deshabilitar usuario",
+			method:   http.MethodPost,
+			path:     "/admin/users/user1/disable",
+			mockSetup: func(m *mockAuthService) {
+				m.On("SetUserDisabled", mock.Anything, "user1", true).
+					Return(&models.User{Username: "user1", Role: models.RoleUser, Disabled: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"disabled":true`,
+		},
+		{
+			testName: "Habilitar usuario inexistente",
+			method:   http.MethodPost,
+			path:     "/admin/users/ghost/enable",
+			mockSetup: func(m *mockAuthService) {
+				m.On("SetUserDisabled", mock.Anything, "ghost", false).Return((*models.User)(nil), services.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"Usuario no encontrado"`,
+		},
+		{
+			testName: "Desbloquear usuario",
+			method:   http.MethodPost,
+			path:     "/admin/users/user1/unlock",
+			mockSetup: func(m *mockAuthService) {
+				m.On("UnlockUser", mock.Anything, "user1").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"message":"Usuario desbloqueado"`,
+		},
+		{
+			testName: "Desbloquear usuario inexistente",
+			method:   http.MethodPost,
+			path:     "/admin/users/ghost/unlock",
+			mockSetup: func(m *mockAuthService) {
+				m.On("UnlockUser", mock.Anything, "ghost").Return(services.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"Usuario no encontrado"`,
+		},
+		{
+			testName:    "Cambiar rol",
+			method:      http.MethodPut,
+			path:        "/admin/users/user1/role",
+			requestBody: `{"role":"admin"}`,
+			mockSetup: func(m *mockAuthService) {
+				m.On("SetUserRole", mock.Anything, "user1", "admin").
+					Return(&models.User{Username: "user1", Role: models.RoleAdmin}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"role":"admin"`,
+		},
+		{
+			testName:       "Rol inválido",
+			method:         http.MethodPut,
+			path:           "/admin/users/user1/role",
+			requestBody:    `{"role":"root"}`,
+			mockSetup:      nil,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"El rol debe ser user o admin"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockAuthService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			logger := logrus.New()
+			handler := NewAuthHandler(mockService, logger)
+
+			router := gin.New()
+			router.GET("/admin/users", handler.ListUsers)
+			router.POST("/admin/users/:username/disable", handler.DisableUser)
+			router.POST("/admin/users/:username/enable", handler.EnableUser)
+			router.POST("/admin/users/:username/unlock", handler.UnlockUser)
+			router.PUT("/admin/users/:username/role", handler.SetUserRole)
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestAuthHandler_TwoFactor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		path           string
+		requestBody    string
+		mockSetup      func(*mockAuthService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Iniciar enrolamiento",
+			path:     "/2fa/setup",
+			mockSetup: func(m *mockAuthService) {
+				m.On("SetupTOTP", mock.Anything, "user1").
+					Return(&services.TOTPSetup{Secret: "JBSWY3DPEHPK3PXP", URI: "otpauth://totp/x"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"secret":"JBSWY3DPEHPK3PXP","otpauth_uri":"otpauth://totp/x"}`,
+		},
+		{
+			testName: "Enrolamiento con 2FA ya activo",
+			path:     "/2fa/setup",
+			mockSetup: func(m *mockAuthService) {
+				m.On("SetupTOTP", mock.Anything, "user1").Return((*services.TOTPSetup)(nil), services.ErrTOTPAlreadyEnabled)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"error":"La verificación en dos pasos ya está activa"`,
+		},
+		{
+			testName:       "Verificar sin código",
+			path:           "/2fa/verify",
+			requestBody:    `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"El código es requerido"`,
+		},
+		{
+			testName:    "Verificar con código inválido",
+			path:        "/2fa/verify",
+			requestBody: `{"code":"000000"}`,
+			mockSetup: func(m *mockAuthService) {
+				m.On("EnableTOTP", mock.Anything, "user1", "000000").Return(([]string)(nil), services.ErrTOTPInvalidCode)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"Código inválido"`,
+		},
+		{
+			testName:    "Verificar sin enrolamiento",
+			path:        "/2fa/verify",
+			requestBody: `{"code":"123456"}`,
+			mockSetup: func(m *mockAuthService) {
+				m.On("EnableTOTP", mock.Anything, "user1", "123456").Return(([]string)(nil), services.ErrTOTPSetupRequired)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"Primero inicia el enrolamiento en /api/2fa/setup"`,
+		},
+		{
+			testName:    "Verificar devuelve códigos de recuperación",
+			path:        "/2fa/verify",
+			requestBody: `{"code":"123456"}`,
+			mockSetup: func(m *mockAuthService) {
+				m.On("EnableTOTP", mock.Anything, "user1", "123456").Return([]string{"AAAA-BBBB-CCCC-DDDD"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"recovery_codes":["AAAA-BBBB-CCCC-DDDD"]}`,
+		},
+		{
+			testName:       "Login 2FA sin datos",
+			path:           "/login/2fa",
+			requestBody:    `{"code":"123456"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"challenge_token y code son requeridos"`,
+		},
+		{
+			testName:    "Login 2FA con código inválido",
+			path:        "/login/2fa",
+			requestBody: `{"challenge_token":"challenge","code":"000000"}`,
+			mockSetup: func(m *mockAuthService) {
+				m.On("CompleteTwoFactorLogin", mock.Anything, "challenge", "000000", mock.Anything).Return((*services.TokenPair)(nil), services.ErrTOTPInvalidCode)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"Código inválido"`,
+		},
+		{
+			testName:    "Login 2FA con desafío expirado",
+			path:        "/login/2fa",
+			requestBody: `{"challenge_token":"challenge","code":"123456"}`,
+			mockSetup: func(m *mockAuthService) {
+				m.On("CompleteTwoFactorLogin", mock.Anything, "challenge", "123456", mock.Anything).Return((*services.TokenPair)(nil), services.ErrChallengeExpired)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"El desafío expiró, inicia sesión de nuevo"`,
+		},
+		{
+			testName:    "Login 2FA con desafío usado",
+			path:        "/login/2fa",
+			requestBody: `{"challenge_token":"challenge","code":"123456"}`,
+			mockSetup: func(m *mockAuthService) {
+				m.On("CompleteTwoFactorLogin", mock.Anything, "challenge", "123456", mock.Anything).Return((*services.TokenPair)(nil), services.ErrChallengeInvalid)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"Desafío inválido, inicia sesión de nuevo"`,
+		},
+		{
+			testName:    "Login 2FA bloqueado",
+			path:        "/login/2fa",
+			requestBody: `{"challenge_token":"challenge","code":"123456"}`,
+			mockSetup: func(m *mockAuthService) {
+				m.On("CompleteTwoFactorLogin", mock.Anything, "challenge", "123456", mock.Anything).
+					Return((*services.TokenPair)(nil), &services.LockoutError{RetryAfter: 15 * time.Minute})
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   `"error":"Demasiados intentos fallidos, intenta de nuevo más tarde"`,
+		},
+		{
+			testName:    "Login 2FA exitoso",
+			path:        "/login/2fa",
+			requestBody: `{"challenge_token":"challenge","code":"123456"}`,
+			mockSetup: func(m *mockAuthService) {
+				m.On("CompleteTwoFactorLogin", mock.Anything, "challenge", "123456", mock.Anything).
+					Return(&services.TokenPair{AccessToken: "token123", RefreshToken: "refresh123", ExpiresIn: 15 * time.Minute}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"token":"token123","refresh_token":"refresh123","expires_in":900}`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockAuthService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			logger := logrus.New()
+			handler := NewAuthHandler(mockService, logger)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.POST("/2fa/setup", handler.SetupTwoFactor)
+			router.POST("/2fa/verify", handler.VerifyTwoFactor)
+			router.POST("/login/2fa", handler.LoginTwoFactor)
+
+			req, _ := http.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
 		})
 	}
 }
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/auth"
+	oidcServices "prueba_tecnica_go_guarapo/api/services/oidc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// stateCookie ata el state al navegador que inició el login para evitar login CSRF.
+const stateCookie = "oidc_state"
+
+type OIDCHandler interface {
+	Login(c *gin.Context)
+	Link(c *gin.Context)
+	Callback(c *gin.Context)
+}
+
+type oidcHandler struct {
+	oidcService oidcServices.OIDCService
+	authService services.AuthService
+	logger      *logrus.Logger
+}
+
+func NewOIDCHandler(oidcService oidcServices.OIDCService, authService services.AuthService, logger *logrus.Logger) OIDCHandler {
+	return &oidcHandler{
+		oidcService: oidcService,
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// Login godoc
+// @Summary      Login con proveedor OIDC
+// @Description  Redirige al proveedor de identidad (authorization code + PKCE)
+// @Tags         auth
+// @Success      302
+// @Failure      502 {object} map[string]string
+// @Router       /api/auth/oidc/login [get]
+func (h *oidcHandler) Login(c *gin.Context) {
+	authURL, state, err := h.oidcService.AuthCodeURL(c.Request.Context())
+	if err != nil {
+		h.logger.Error("[Layer: oidc_handler] [Method: Login] Error: ", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "El proveedor de identidad no está disponible"})
+		return
+	}
+	h.setStateCookie(c, state)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Link godoc
+// @Summary      Enlazar proveedor OIDC
+// @Description  Inicia el flujo para enlazar una identidad del proveedor con el usuario autenticado. El navegador debe abrir authorization_url; el callback enlaza la identidad en lugar de iniciar sesión
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} models.OIDCLinkResponse
+// @Failure      401 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /api/auth/oidc/link [post]
+func (h *oidcHandler) Link(c *gin.Context) {
+	username := c.GetString("username")
+	authURL, state, err := h.oidcService.LinkCodeURL(c.Request.Context(), username)
+	if err != nil {
+		h.logger.Error("[Layer: oidc_handler] [Method: Link] Error: ", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "El proveedor de identidad no está disponible"})
+		return
+	}
+	h.setStateCookie(c, state)
+	h.logger.Infof("[Layer: oidc_handler] [Method: Link] Usuario '%s' inició el enlace con el proveedor", username)
+	c.JSON(http.StatusOK, models.OIDCLinkResponse{AuthorizationURL: authURL})
+}
+
+func (h *oidcHandler) setStateCookie(c *gin.Context, state string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(stateCookie, state, int(h.oidcService.StateTTL().Seconds()), "/api/auth/oidc", "", c.Request.TLS != nil, true)
+}
+
+// Callback godoc
+// @Summary      Callback del proveedor OIDC
+// @Description  Valida state, nonce e id_token y retorna los tokens propios de la API (o un challenge_token si la cuenta tiene 2FA). Si el flujo se inició con /api/auth/oidc/link, enlaza la identidad con ese usuario
+// @Tags         auth
+// @Produce      json
+// @Param        state query string true "State devuelto por el proveedor"
+// @Param        code  query string true "Authorization code"
+// @Success      200 {object} models.LoginResponse
+// @Success      202 {object} models.TwoFactorChallengeResponse
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /api/auth/oidc/callback [get]
+func (h *oidcHandler) Callback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		h.logger.Warnf("[Layer: oidc_handler] [Method: Callback] El proveedor rechazó el login: %s", providerErr)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "El proveedor de identidad rechazó el inicio de sesión"})
+		return
+	}
+	state := c.Query("state")
+	code := c.Query("code")
+	cookieState, _ := c.Cookie(stateCookie)
+	c.SetCookie(stateCookie, "", -1, "/api/auth/oidc", "", c.Request.TLS != nil, true)
+	if state == "" || code == "" || state != cookieState {
+		h.logger.Warn("[Layer: oidc_handler] [Method: Callback] State o code inválidos")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "State inválido o expirado"})
+		return
+	}
+
+	identity, err := h.oidcService.Exchange(c.Request.Context(), state, code)
+	if err != nil {
+		if errors.Is(err, oidcServices.ErrStateInvalid) {
+			h.logger.Warn("[Layer: oidc_handler] [Method: Callback] State expirado: ", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "State inválido o expirado"})
+			return
+		}
+		if errors.Is(err, oidcServices.ErrProviderOffline) {
+			h.logger.Error("[Layer: oidc_handler] [Method: Callback] Error: ", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "El proveedor de identidad no está disponible"})
+			return
+		}
+		h.logger.Warn("[Layer: oidc_handler] [Method: Callback] Identidad no verificada: ", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No se pudo verificar la identidad"})
+		return
+	}
+
+	external := services.ExternalIdentity{
+		Issuer:        identity.Issuer,
+		Subject:       identity.Subject,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+	}
+	if identity.LinkUsername != "" {
+		h.link(c, identity.LinkUsername, external)
+		return
+	}
+
+	result, err := h.authService.LoginExternal(c.Request.Context(), external, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrExternalEmailRequired):
+			h.logger.Warnf("[Layer: oidc_handler] [Method: Callback] Identidad '%s' sin email verificado", identity.Subject)
+			c.JSON(http.StatusForbidden, gin.H{"error": "El proveedor no entregó un email verificado"})
+		case errors.Is(err, services.ErrExternalAccountExists):
+			h.logger.Warnf("[Layer: oidc_handler] [Method: Callback] Identidad '%s' coincide con una cuenta sin enlazar", identity.Subject)
+			c.JSON(http.StatusConflict, gin.H{"error": "Ya existe una cuenta con ese email: inicia sesión con ella y enlaza el proveedor desde /api/auth/oidc/link"})
+		case errors.Is(err, services.ErrUserDisabled):
+			h.logger.Warnf("[Layer: oidc_handler] [Method: Callback] Cuenta deshabilitada para '%s'", identity.Subject)
+			c.JSON(http.StatusForbidden, gin.H{"error": "La cuenta está deshabilitada"})
+		default:
+			h.logger.Error("[Layer: oidc_handler] [Method: Callback] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo iniciar sesión"})
+		}
+		return
+	}
+	if result.ChallengeToken != "" {
+		h.logger.Infof("[Layer: oidc_handler] [Method: Callback] Identidad '%s' debe completar el segundo factor", identity.Subject)
+		c.JSON(http.StatusAccepted, models.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    result.ChallengeToken,
+			ExpiresIn:         int64(result.ChallengeExpiresIn.Seconds()),
+		})
+		return
+	}
+	h.logger.Infof("[Layer: oidc_handler] [Method: Callback] Identidad '%s' de '%s' autenticada", identity.Subject, identity.Issuer)
+	c.JSON(http.StatusOK, toLoginResponse(result.TokenPair))
+}
+
+// link termina el flujo iniciado con Link: el usuario ya se autenticó al pedirlo.
+func (h *oidcHandler) link(c *gin.Context, username string, identity services.ExternalIdentity) {
+	if err := h.authService.LinkExternalIdentity(c.Request.Context(), username, identity); err != nil {
+		switch {
+		case errors.Is(err, services.ErrExternalIdentityLinked):
+			h.logger.Warnf("[Layer: oidc_handler] [Method: Callback] Identidad '%s' ya enlazada con otro usuario", identity.Subject)
+			c.JSON(http.StatusConflict, gin.H{"error": "La identidad ya está enlazada con otra cuenta"})
+		case errors.Is(err, services.ErrUserNotFound):
+			h.logger.Warnf("[Layer: oidc_handler] [Method: Callback] Usuario '%s' no encontrado", username)
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		default:
+			h.logger.Error("[Layer: oidc_handler] [Method: Callback] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo enlazar la identidad"})
+		}
+		return
+	}
+	h.logger.Infof("[Layer: oidc_handler] [Method: Callback] Identidad '%s' de '%s' enlazada con '%s'", identity.Subject, identity.Issuer, username)
+	c.JSON(http.StatusOK, gin.H{"message": "Identidad enlazada exitosamente"})
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	services "prueba_tecnica_go_guarapo/api/services/auth"
+	oidcServices "prueba_tecnica_go_guarapo/api/services/oidc"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOIDCHandler_Login(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName         string
+		mockSetup        func(*mockOIDCService)
+		expectedStatus   int
+		expectedLocation string
+		expectedBody     string
+	}{
+		{
+			testName: "Redirige al proveedor",
+			mockSetup: func(m *mockOIDCService) {
+				m.On("AuthCodeURL", mock.Anything).Return("https://idp.example.com/authorize?state=abc", "abc", nil)
+			},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://idp.example.com/authorize?state=abc",
+		},
+		{
+			testName: "Proveedor no disponible",
+			mockSetup: func(m *mockOIDCService) {
+				m.On("AuthCodeURL", mock.Anything).Return("", "", oidcServices.ErrProviderOffline)
+			},
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `"error":"El proveedor de identidad no está disponible"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockOIDC := new(mockOIDCService)
+			tt.mockSetup(mockOIDC)
+			handler := NewOIDCHandler(mockOIDC, new(mockAuthService), logrus.New())
+
+			router := gin.New()
+			router.GET("/login", handler.Login)
+
+			req, _ := http.NewRequest(http.MethodGet, "/login", nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			if tt.expectedStatus == http.StatusFound {
+				assert.Contains(t, w.Header().Get("Set-Cookie"), "oidc_state=abc")
+				assert.Contains(t, w.Header().Get("Set-Cookie"), "HttpOnly")
+			}
+		})
+	}
+}
+
+func TestOIDCHandler_Link(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		mockSetup      func(*mockOIDCService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Devuelve la URL del proveedor",
+			mockSetup: func(m *mockOIDCService) {
+				m.On("LinkCodeURL", mock.Anything, "ana").Return("https://idp.example.com/authorize?state=abc", "abc", nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"authorization_url":"https://idp.example.com/authorize?state=abc"}`,
+		},
+		{
+			testName: "Proveedor no disponible",
+			mockSetup: func(m *mockOIDCService) {
+				m.On("LinkCodeURL", mock.Anything, "ana").Return("", "", oidcServices.ErrProviderOffline)
+			},
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `"error":"El proveedor de identidad no está disponible"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockOIDC := new(mockOIDCService)
+			tt.mockSetup(mockOIDC)
+			handler := NewOIDCHandler(mockOIDC, new(mockAuthService), logrus.New())
+
+			router := gin.New()
+			router.POST("/link", func(c *gin.Context) {
+				c.Set("username", "ana")
+				c.Next()
+			}, handler.Link)
+
+			req, _ := http.NewRequest(http.MethodPost, "/link", nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Header().Get("Set-Cookie"), "oidc_state=abc")
+			}
+			mockOIDC.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOIDCHandler_Callback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	identity := &oidcServices.Identity{Issuer: "https://idp", Subject: "sub-1", Email: "ana@example.com", EmailVerified: true}
+	external := services.ExternalIdentity{Issuer: "https://idp", Subject: "sub-1", Email: "ana@example.com", EmailVerified: true}
+	linking := &oidcServices.Identity{Issuer: "https://idp", Subject: "sub-1", Email: "ana@example.com", EmailVerified: true, LinkUsername: "ana"}
+	pair := &services.TokenPair{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 15 * time.Minute}
+
+	testScenarios := []struct {
+		testName       string
+		query          string
+		cookie         string
+		mockSetup      func(*mockOIDCService, *mockAuthService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName:       "Proveedor rechaza el login",
+			query:          "?error=access_denied&state=abc",
+			cookie:         "abc",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"El proveedor de identidad rechazó el inicio de sesión"`,
+		},
+		{
+			testName:       "State distinto al de la cookie",
+			query:          "?state=abc&code=xyz",
+			cookie:         "other",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"State inválido o expirado"`,
+		},
+		{
+			testName:       "Sin cookie de state",
+			query:          "?state=abc&code=xyz",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"State inválido o expirado"`,
+		},
+		{
+			testName: "State expirado en el servidor",
+			query:    "?state=abc&code=xyz",
+			cookie:   "abc",
+			mockSetup: func(o *mockOIDCService, a *mockAuthService) {
+				o.On("Exchange", mock.Anything, "abc", "xyz").Return((*oidcServices.Identity)(nil), oidcServices.ErrStateInvalid)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"State inválido o expirado"`,
+		},
+		{
+			testName: "Nonce inválido",
+			query:    "?state=abc&code=xyz",
+			cookie:   "abc",
+			mockSetup: func(o *mockOIDCService, a *mockAuthService) {
+				o.On("Exchange", mock.Anything, "abc", "xyz").Return((*oidcServices.Identity)(nil), oidcServices.ErrNonceMismatch)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"No se pudo verificar la identidad"`,
+		},
+		{
+			testName: "Sin email verificado",
+			query:    "?state=abc&code=xyz",
+			cookie:   "abc",
+			mockSetup: func(o *mockOIDCService, a *mockAuthService) {
+				o.On("Exchange", mock.Anything, "abc", "xyz").Return(identity, nil)
+				a.On("LoginExternal", mock.Anything, external, mock.Anything).Return((*services.LoginResult)(nil), services.ErrExternalEmailRequired)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `"error":"El proveedor no entregó un email verificado"`,
+		},
+		{
+			testName: "El email coincide con una cuenta sin enlazar",
+			query:    "?state=abc&code=xyz",
+			cookie:   "abc",
+			mockSetup: func(o *mockOIDCService, a *mockAuthService) {
+				o.On("Exchange", mock.Anything, "abc", "xyz").Return(identity, nil)
+				a.On("LoginExternal", mock.Anything, external, mock.Anything).Return((*services.LoginResult)(nil), services.ErrExternalAccountExists)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"error":"Ya existe una cuenta con ese email`,
+		},
+		{
+			testName: "Cuenta con 2FA",
+			query:    "?state=abc&code=xyz",
+			cookie:   "abc",
+			mockSetup: func(o *mockOIDCService, a *mockAuthService) {
+				o.On("Exchange", mock.Anything, "abc", "xyz").Return(identity, nil)
+				a.On("LoginExternal", mock.Anything, external, mock.Anything).Return(&services.LoginResult{ChallengeToken: "challenge", ChallengeExpiresIn: 5 * time.Minute}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `{"two_factor_required":true,"challenge_token":"challenge","expires_in":300}`,
+		},
+		{
+			testName: "Enlace con el usuario que lo pidió",
+			query:    "?state=abc&code=xyz",
+			cookie:   "abc",
+			mockSetup: func(o *mockOIDCService, a *mockAuthService) {
+				o.On("Exchange", mock.Anything, "abc", "xyz").Return(linking, nil)
+				a.On("LinkExternalIdentity", mock.Anything, "ana", external).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"message":"Identidad enlazada exitosamente"`,
+		},
+		{
+			testName: "Enlace con una identidad de otro usuario",
+			query:    "?state=abc&code=xyz",
+			cookie:   "abc",
+			mockSetup: func(o *mockOIDCService, a *mockAuthService) {
+				o.On("Exchange", mock.Anything, "abc", "xyz").Return(linking, nil)
+				a.On("LinkExternalIdentity", mock.Anything, "ana", external).Return(services.ErrExternalIdentityLinked)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"error":"La identidad ya está enlazada con otra cuenta"`,
+		},
+		{
+			testName: "Cuenta deshabilitada",
+			query:    "?state=abc&code=xyz",
+			cookie:   "abc",
+			mockSetup: func(o *mockOIDCService, a *mockAuthService) {
+				o.On("Exchange", mock.Anything, "abc", "xyz").Return(identity, nil)
+				a.On("LoginExternal", mock.Anything, external, mock.Anything).Return((*services.LoginResult)(nil), services.ErrUserDisabled)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `"error":"La cuenta está deshabilitada"`,
+		},
+		{
+			testName: "Error interno",
+			query:    "?state=abc&code=xyz",
+			cookie:   "abc",
+			mockSetup: func(o *mockOIDCService, a *mockAuthService) {
+				o.On("Exchange", mock.Anything, "abc", "xyz").Return(identity, nil)
+				a.On("LoginExternal", mock.Anything, external, mock.Anything).Return((*services.LoginResult)(nil), errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `"error":"No se pudo iniciar sesión"`,
+		},
+		{
+			testName: "Login exitoso",
+			query:    "?state=abc&code=xyz",
+			cookie:   "abc",
+			mockSetup: func(o *mockOIDCService, a *mockAuthService) {
+				o.On("Exchange", mock.Anything, "abc", "xyz").Return(identity, nil)
+				a.On("LoginExternal", mock.Anything, external, mock.Anything).Return(&services.LoginResult{TokenPair: pair}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"token":"access","refresh_token":"refresh","expires_in":900}`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockOIDC := new(mockOIDCService)
+			mockAuth := new(mockAuthService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockOIDC, mockAuth)
+			}
+			handler := NewOIDCHandler(mockOIDC, mockAuth, logrus.New())
+
+			router := gin.New()
+			router.GET("/callback", handler.Callback)
+
+			req, _ := http.NewRequest(http.MethodGet, "/callback"+tt.query, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "oidc_state", Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockOIDC.AssertExpectations(t)
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"context"
+	services "prueba_tecnica_go_guarapo/api/services/oidc"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockOIDCService struct {
+	mock.Mock
+}
+
+func (m *mockOIDCService) AuthCodeURL(ctx context.Context) (string, string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.String(1), args.Error(2)
+}
+func (m *mockOIDCService) LinkCodeURL(ctx context.Context, username string) (string, string, error) {
+	args := m.Called(ctx, username)
+	return args.String(0), args.String(1), args.Error(2)
+}
+func (m *mockOIDCService) Exchange(ctx context.Context, state, code string) (*services.Identity, error) {
+	args := m.Called(ctx, state, code)
+	return args.Get(0).(*services.Identity), args.Error(1)
+}
+func (m *mockOIDCService) StateTTL() time.Duration {
+	return 10 * time.Minute
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"prueba_tecnica_go_guarapo/api/models"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func taskETag(task *models.Task) string {
+	return fmt.Sprintf(`"%d"`, task.Version)
+}
+
+// ifMatchVersions lee If-Match. Sin header o con "*" devuelve nil (sin condición); si no, las versiones
+// de las entradas de la lista. If-Match usa comparación fuerte (RFC 7232), así que las etiquetas débiles
+// (W/"3") y las que no son un ETag de tarea nunca coinciden; ok es false si ninguna entrada puede coincidir.
+func ifMatchVersions(c *gin.Context) (versions []uint, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if len(candidate) < 3 || !strings.HasPrefix(candidate, `"`) || !strings.HasSuffix(candidate, `"`) {
+			continue
+		}
+		parsed, err := strconv.ParseUint(candidate[1:len(candidate)-1], 10, 64)
+		if err != nil || parsed == 0 {
+			continue
+		}
+		versions = append(versions, uint(parsed))
+	}
+	return versions, len(versions) > 0
+}
+
+// expectedVersion resuelve If-Match a la versión con la que se condiciona la escritura (0 sin condición).
+// Con varias versiones se lee la tarea y se usa la actual si está en la lista; si no se puede leer se
+// usa la primera y el servicio responde como con una sola.
+func (h *taskHandler) expectedVersion(c *gin.Context, id int, username string) (uint, bool) {
+	versions, ok := ifMatchVersions(c)
+	switch {
+	case !ok:
+		return 0, false
+	case len(versions) == 0:
+		return 0, true
+	case len(versions) == 1:
+		return versions[0], true
+	}
+	current, err := h.taskService.GetTaskByID(c.Request.Context(), id, username)
+	if err != nil {
+		return versions[0], true
+	}
+	if slices.Contains(versions, current.Version) {
+		return current.Version, true
+	}
+	return 0, false
+}
+
+// etagMatches compara If-None-Match con comparación débil: acepta listas, "*" y el prefijo W/.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func preconditionFailed(c *gin.Context) {
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "La tarea cambió desde la versión indicada en If-Match"})
+}
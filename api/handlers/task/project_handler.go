@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type ProjectHandler interface {
+	ListProjects(c *gin.Context)
+	GetProject(c *gin.Context)
+	CreateProject(c *gin.Context)
+	UpdateProject(c *gin.Context)
+	ArchiveProject(c *gin.Context)
+	UnarchiveProject(c *gin.Context)
+	DeleteProject(c *gin.Context)
+}
+
+type projectHandler struct {
+	projectService services.ProjectService
+	logger         *logrus.Logger
+}
+
+func NewProjectHandler(projectService services.ProjectService, logger *logrus.Logger) ProjectHandler {
+	return &projectHandler{
+		projectService: projectService,
+		logger:         logger,
+	}
+}
+
+// ListProjects godoc
+// @Summary      Listar proyectos
+// @Description  Lista los proyectos del usuario autenticado ordenados por nombre; los archivados solo con include_archived=true
+// @Tags         projects
+// @Produce      json
+// @Param        include_archived query bool false "Incluir proyectos archivados"
+// @Success      200 {array} models.ProjectResponse
+// @Failure      400 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/projects [get]
+func (h *projectHandler) ListProjects(c *gin.Context) {
+	includeArchived := false
+	if raw := c.Query("include_archived"); raw != "" {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.logger.Warnf("[Layer: project_handler] [Method: ListProjects] include_archived inválido: '%s'", raw)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "include_archived debe ser true o false"})
+			return
+		}
+		includeArchived = value
+	}
+	username, _ := c.Get("username")
+	projects, err := h.projectService.ListProjects(c.Request.Context(), username.(string), includeArchived)
+	if err != nil {
+		h.logger.Error("[Layer: project_handler] [Method: ListProjects] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener proyectos"})
+		return
+	}
+	resp := make([]models.ProjectResponse, 0, len(projects))
+	for _, project := range projects {
+		resp = append(resp, toProjectResponse(project))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetProject godoc
+// @Summary      Obtener proyecto
+// @Description  Obtiene un proyecto del usuario autenticado
+// @Tags         projects
+// @Produce      json
+// @Param        id path int true "ID del proyecto"
+// @Success      200 {object} models.ProjectResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/projects/{id} [get]
+func (h *projectHandler) GetProject(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: project_handler] [Method: GetProject] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	username, _ := c.Get("username")
+	project, err := h.projectService.GetProject(c.Request.Context(), id, username.(string))
+	if err != nil {
+		h.respondError(c, "GetProject", err)
+		return
+	}
+	c.JSON(http.StatusOK, toProjectResponse(project))
+}
+
+// CreateProject godoc
+// @Summary      Crear proyecto
+// @Description  Crea un proyecto para agrupar tareas
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        request body models.ProjectRequest true "Datos del proyecto"
+// @Success      201 {object} models.ProjectResponse
+// @Failure      400 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/projects [post]
+func (h *projectHandler) CreateProject(c *gin.Context) {
+	var req models.ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("[Layer: project_handler] [Method: CreateProject] Datos inválidos: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre es requerido"})
+		return
+	}
+	username, _ := c.Get("username")
+	project, err := h.projectService.CreateProject(c.Request.Context(), username.(string), services.ProjectFields{Name: req.Name, Color: req.Color})
+	if err != nil {
+		h.respondError(c, "CreateProject", err)
+		return
+	}
+	c.JSON(http.StatusCreated, toProjectResponse(project))
+}
+
+// UpdateProject godoc
+// @Summary      Actualizar proyecto
+// @Description  Cambia el nombre y el color de un proyecto
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID del proyecto"
+// @Param        request body models.ProjectRequest true "Datos del proyecto"
+// @Success      200 {object} models.ProjectResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/projects/{id} [put]
+func (h *projectHandler) UpdateProject(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: project_handler] [Method: UpdateProject] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var req models.ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("[Layer: project_handler] [Method: UpdateProject] Datos inválidos: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre es requerido"})
+		return
+	}
+	username, _ := c.Get("username")
+	project, err := h.projectService.UpdateProject(c.Request.Context(), id, username.(string), services.ProjectFields{Name: req.Name, Color: req.Color})
+	if err != nil {
+		h.respondError(c, "UpdateProject", err)
+		return
+	}
+	c.JSON(http.StatusOK, toProjectResponse(project))
+}
+
+// ArchiveProject godoc
+// @Summary      Archivar proyecto
+// @Description  Archiva un proyecto; ya no admite tareas nuevas. Con tasks=move sus tareas pasan a la bandeja de entrada y con tasks=delete se eliminan
+// @Tags         projects
+// @Produce      json
+// @Param        id path int true "ID del proyecto"
+// @Param        tasks query string false "keep, move o delete" default(keep)
+// @Success      200 {object} models.ProjectResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/projects/{id}/archive [post]
+func (h *projectHandler) ArchiveProject(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: project_handler] [Method: ArchiveProject] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	username, _ := c.Get("username")
+	project, err := h.projectService.ArchiveProject(auditContext(c), id, username.(string), c.Query("tasks"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCascade) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tasks debe ser keep, move o delete"})
+			return
+		}
+		h.respondError(c, "ArchiveProject", err)
+		return
+	}
+	c.JSON(http.StatusOK, toProjectResponse(project))
+}
+
+// UnarchiveProject godoc
+// @Summary      Desarchivar proyecto
+// @Description  Vuelve a activar un proyecto archivado
+// @Tags         projects
+// @Produce      json
+// @Param        id path int true "ID del proyecto"
+// @Success      200 {object} models.ProjectResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/projects/{id}/unarchive [post]
+func (h *projectHandler) UnarchiveProject(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: project_handler] [Method: UnarchiveProject] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	username, _ := c.Get("username")
+	project, err := h.projectService.UnarchiveProject(c.Request.Context(), id, username.(string))
+	if err != nil {
+		h.respondError(c, "UnarchiveProject", err)
+		return
+	}
+	c.JSON(http.StatusOK, toProjectResponse(project))
+}
+
+// DeleteProject godoc
+// @Summary      Eliminar proyecto
+// @Description  Elimina un proyecto. Con tasks=move (por defecto) sus tareas pasan a la bandeja de entrada; con tasks=delete se eliminan
+// @Tags         projects
+// @Produce      json
+// @Param        id path int true "ID del proyecto"
+// @Param        tasks query string false "move o delete" default(move)
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/projects/{id} [delete]
+func (h *projectHandler) DeleteProject(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: project_handler] [Method: DeleteProject] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	username, _ := c.Get("username")
+	if err := h.projectService.DeleteProject(auditContext(c), id, username.(string), c.Query("tasks")); err != nil {
+		if errors.Is(err, services.ErrInvalidCascade) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tasks debe ser move o delete"})
+			return
+		}
+		h.respondError(c, "DeleteProject", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Proyecto eliminado exitosamente"})
+}
+
+func (h *projectHandler) respondError(c *gin.Context, method string, err error) {
+	switch {
+	case errors.Is(err, services.ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proyecto no encontrado"})
+	case errors.Is(err, services.ErrInvalidProjectName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("El nombre debe tener entre 1 y %d caracteres", services.MaxProjectNameLength)})
+	case errors.Is(err, services.ErrInvalidColor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "color debe tener formato #RRGGBB"})
+	default:
+		h.logger.Errorf("[Layer: project_handler] [Method: %s] Error: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo procesar el proyecto"})
+	}
+}
+
+func toProjectResponse(project *models.Project) models.ProjectResponse {
+	return models.ProjectResponse{
+		ID:        project.ID,
+		Name:      project.Name,
+		Color:     project.Color,
+		Archived:  project.Archived,
+		CreatedAt: project.CreatedAt,
+		UpdatedAt: project.UpdatedAt,
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProjectHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		method         string
+		path           string
+		requestBody    string
+		mockSetup      func(*mockProjectService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Listar proyectos activos",
+			method:   http.MethodGet,
+			path:     "/projects",
+			mockSetup: func(m *mockProjectService) {
+				m.On("ListProjects", mock.Anything, "user1", false).Return([]*models.Project{{Name: "Trabajo", Color: "#1E88E5"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"color":"#1E88E5"`,
+		},
+		{
+			testName: "Listar incluyendo archivados",
+			method:   http.MethodGet,
+			path:     "/projects?include_archived=true",
+			mockSetup: func(m *mockProjectService) {
+				m.On("ListProjects", mock.Anything, "user1", true).Return([]*models.Project{{Name: "Viejo", Archived: true}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"archived":true`,
+		},
+		{
+			testName:       "include_archived inválido",
+			method:         http.MethodGet,
+			path:           "/projects?include_archived=quizas",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"include_archived debe ser true o false"`,
+		},
+		{
+			testName: "Error al listar",
+			method:   http.MethodGet,
+			path:     "/projects",
+			mockSetup: func(m *mockProjectService) {
+				m.On("ListProjects", mock.Anything, "user1", false).Return([]*models.Project(nil), errors.New("db"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `"error":"Error al obtener proyectos"`,
+		},
+		{
+			testName: "Obtener proyecto ajeno",
+			method:   http.MethodGet,
+			path:     "/projects/9",
+			mockSetup: func(m *mockProjectService) {
+				m.On("GetProject", mock.Anything, 9, "user1").Return((*models.Project)(nil), services.ErrProjectNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"Proyecto no encontrado"`,
+		},
+		{
+			testName:    "Crear proyecto",
+			method:      http.MethodPost,
+			path:        "/projects",
+			requestBody: `{"name":"Trabajo","color":"#1e88e5"}`,
+			mockSetup: func(m *mockProjectService) {
+				m.On("CreateProject", mock.Anything, "user1", services.ProjectFields{Name: "Trabajo", Color: "#1e88e5"}).
+					Return(&models.Project{Name: "Trabajo", Color: "#1E88E5"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"name":"Trabajo"`,
+		},
+		{
+			testName:       "Crear sin nombre",
+			method:         http.MethodPost,
+			path:           "/projects",
+			requestBody:    `{"color":"#1e88e5"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"El nombre es requerido"`,
+		},
+		{
+			testName:    "Color inválido",
+			method:      http.MethodPut,
+			path:        "/projects/1",
+			requestBody: `{"name":"Trabajo","color":"azul"}`,
+			mockSetup: func(m *mockProjectService) {
+				m.On("UpdateProject", mock.Anything, 1, "user1", services.ProjectFields{Name: "Trabajo", Color: "azul"}).
+					Return((*models.Project)(nil), services.ErrInvalidColor)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"color debe tener formato #RRGGBB"`,
+		},
+		{
+			testName: "Archivar moviendo tareas",
+			method:   http.MethodPost,
+			path:     "/projects/1/archive?tasks=move",
+			mockSetup: func(m *mockProjectService) {
+				m.On("ArchiveProject", mock.Anything, 1, "user1", "move").Return(&models.Project{Name: "Trabajo", Archived: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"archived":true`,
+		},
+		{
+			testName: "Archivar con cascada inválida",
+			method:   http.MethodPost,
+			path:     "/projects/1/archive?tasks=purge",
+			mockSetup: func(m *mockProjectService) {
+				m.On("ArchiveProject", mock.Anything, 1, "user1", "purge").Return((*models.Project)(nil), services.ErrInvalidCascade)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"tasks debe ser keep, move o delete"`,
+		},
+		{
+			testName: "Desarchivar",
+			method:   http.MethodPost,
+			path:     "/projects/1/unarchive",
+			mockSetup: func(m *mockProjectService) {
+				m.On("UnarchiveProject", mock.Anything, 1, "user1").Return(&models.Project{Name: "Trabajo"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"archived":false`,
+		},
+		{
+			testName: "Eliminar con sus tareas",
+			method:   http.MethodDelete,
+			path:     "/projects/1?tasks=delete",
+			mockSetup: func(m *mockProjectService) {
+				m.On("DeleteProject", mock.Anything, 1, "user1", "delete").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"message":"Proyecto eliminado exitosamente"`,
+		},
+		{
+			testName: "Eliminar conservando tareas no se permite",
+			method:   http.MethodDelete,
+			path:     "/projects/1?tasks=keep",
+			mockSetup: func(m *mockProjectService) {
+				m.On("DeleteProject", mock.Anything, 1, "user1", "keep").Return(services.ErrInvalidCascade)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"tasks debe ser move o delete"`,
+		},
+		{
+			testName:       "Eliminar con ID inválido",
+			method:         http.MethodDelete,
+			path:           "/projects/abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"ID inválido"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockProjectService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewProjectHandler(mockService, logrus.New())
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.GET("/projects", handler.ListProjects)
+			router.GET("/projects/:id", handler.GetProject)
+			router.POST("/projects", handler.CreateProject)
+			router.PUT("/projects/:id", handler.UpdateProject)
+			router.POST("/projects/:id/archive", handler.ArchiveProject)
+			router.POST("/projects/:id/unarchive", handler.UnarchiveProject)
+			router.DELETE("/projects/:id", handler.DeleteProject)
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockProjectService struct {
+	mock.Mock
+}
+
+func (m *mockProjectService) ListProjects(ctx context.Context, username string, includeArchived bool) ([]*models.Project, error) {
+	args := m.Called(ctx, username, includeArchived)
+	return args.Get(0).([]*models.Project), args.Error(1)
+}
+func (m *mockProjectService) GetProject(ctx context.Context, id int, username string) (*models.Project, error) {
+	args := m.Called(ctx, id, username)
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+func (m *mockProjectService) CreateProject(ctx context.Context, username string, fields services.ProjectFields) (*models.Project, error) {
+	args := m.Called(ctx, username, fields)
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+func (m *mockProjectService) UpdateProject(ctx context.Context, id int, username string, fields services.ProjectFields) (*models.Project, error) {
+	args := m.Called(ctx, id, username, fields)
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+func (m *mockProjectService) ArchiveProject(ctx context.Context, id int, username, cascade string) (*models.Project, error) {
+	args := m.Called(ctx, id, username, cascade)
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+func (m *mockProjectService) UnarchiveProject(ctx context.Context, id int, username string) (*models.Project, error) {
+	args := m.Called(ctx, id, username)
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+func (m *mockProjectService) DeleteProject(ctx context.Context, id int, username, cascade string) error {
+	args := m.Called(ctx, id, username, cascade)
+	return args.Error(0)
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type TagHandler interface {
+	ListTags(c *gin.Context)
+	GetTag(c *gin.Context)
+	CreateTag(c *gin.Context)
+	UpdateTag(c *gin.Context)
+	DeleteTag(c *gin.Context)
+}
+
+type tagHandler struct {
+	tagService services.TagService
+	logger     *logrus.Logger
+}
+
+func NewTagHandler(tagService services.TagService, logger *logrus.Logger) TagHandler {
+	return &tagHandler{
+		tagService: tagService,
+		logger:     logger,
+	}
+}
+
+// ListTags godoc
+// @Summary      Listar etiquetas
+// @Description  Lista las etiquetas del usuario autenticado ordenadas por nombre
+// @Tags         tags
+// @Produce      json
+// @Success      200 {array} models.TagResponse
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tags [get]
+func (h *tagHandler) ListTags(c *gin.Context) {
+	username, _ := c.Get("username")
+	tags, err := h.tagService.ListTags(c.Request.Context(), username.(string))
+	if err != nil {
+		h.logger.Error("[Layer: tag_handler] [Method: ListTags] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener etiquetas"})
+		return
+	}
+	resp := make([]models.TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		resp = append(resp, toTagResponse(tag))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetTag godoc
+// @Summary      Obtener etiqueta
+// @Description  Obtiene una etiqueta del usuario autenticado
+// @Tags         tags
+// @Produce      json
+// @Param        id path int true "ID de la etiqueta"
+// @Success      200 {object} models.TagResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tags/{id} [get]
+func (h *tagHandler) GetTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: tag_handler] [Method: GetTag] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	username, _ := c.Get("username")
+	tag, err := h.tagService.GetTag(c.Request.Context(), id, username.(string))
+	if err != nil {
+		h.respondError(c, "GetTag", err)
+		return
+	}
+	c.JSON(http.StatusOK, toTagResponse(tag))
+}
+
+// CreateTag godoc
+// @Summary      Crear etiqueta
+// @Description  Crea una etiqueta para el usuario autenticado; el nombre es único por usuario
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        request body models.TagRequest true "Nombre de la etiqueta"
+// @Success      201 {object} models.TagResponse
+// @Failure      400 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tags [post]
+func (h *tagHandler) CreateTag(c *gin.Context) {
+	var req models.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("[Layer: tag_handler] [Method: CreateTag] Datos inválidos: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre es requerido"})
+		return
+	}
+	username, _ := c.Get("username")
+	tag, err := h.tagService.CreateTag(c.Request.Context(), username.(string), req.Name)
+	if err != nil {
+		h.respondError(c, "CreateTag", err)
+		return
+	}
+	c.JSON(http.StatusCreated, toTagResponse(tag))
+}
+
+// UpdateTag godoc
+// @Summary      Renombrar etiqueta
+// @Description  Cambia el nombre de una etiqueta; las tareas que la tienen muestran el nuevo nombre
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID de la etiqueta"
+// @Param        request body models.TagRequest true "Nuevo nombre"
+// @Success      200 {object} models.TagResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tags/{id} [put]
+func (h *tagHandler) UpdateTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: tag_handler] [Method: UpdateTag] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var req models.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("[Layer: tag_handler] [Method: UpdateTag] Datos inválidos: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre es requerido"})
+		return
+	}
+	username, _ := c.Get("username")
+	tag, err := h.tagService.RenameTag(auditContext(c), id, username.(string), req.Name)
+	if err != nil {
+		h.respondError(c, "UpdateTag", err)
+		return
+	}
+	c.JSON(http.StatusOK, toTagResponse(tag))
+}
+
+// DeleteTag godoc
+// @Summary      Eliminar etiqueta
+// @Description  Elimina una etiqueta y la quita de todas las tareas que la tenían
+// @Tags         tags
+// @Produce      json
+// @Param        id path int true "ID de la etiqueta"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tags/{id} [delete]
+func (h *tagHandler) DeleteTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: tag_handler] [Method: DeleteTag] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	username, _ := c.Get("username")
+	if err := h.tagService.DeleteTag(auditContext(c), id, username.(string)); err != nil {
+		h.respondError(c, "DeleteTag", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Etiqueta eliminada exitosamente"})
+}
+
+func (h *tagHandler) respondError(c *gin.Context, method string, err error) {
+	switch {
+	case errors.Is(err, services.ErrTagNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Etiqueta no encontrada"})
+	case errors.Is(err, services.ErrTagExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya existe una etiqueta con ese nombre"})
+	case errors.Is(err, services.ErrInvalidTagName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("El nombre debe tener entre 1 y %d caracteres", services.MaxTagNameLength)})
+	default:
+		h.logger.Errorf("[Layer: tag_handler] [Method: %s] Error: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo procesar la etiqueta"})
+	}
+}
+
+func toTagResponse(tag *models.Tag) models.TagResponse {
+	return models.TagResponse{
+		ID:        tag.ID,
+		Name:      tag.Name,
+		CreatedAt: tag.CreatedAt,
+		UpdatedAt: tag.UpdatedAt,
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTagHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		method         string
+		path           string
+		requestBody    string
+		mockSetup      func(*mockTagService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Listar etiquetas",
+			method:   http.MethodGet,
+			path:     "/tags",
+			mockSetup: func(m *mockTagService) {
+				m.On("ListTags", mock.Anything, "user1").Return([]*models.Tag{{ID: 1, Name: "casa"}, {ID: 2, Name: "trabajo"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"name":"trabajo"`,
+		},
+		{
+			testName: "Error al listar",
+			method:   http.MethodGet,
+			path:     "/tags",
+			mockSetup: func(m *mockTagService) {
+				m.On("ListTags", mock.Anything, "user1").Return([]*models.Tag(nil), errors.New("db"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `"error":"Error al obtener etiquetas"`,
+		},
+		{
+			testName: "Obtener etiqueta ajena",
+			method:   http.MethodGet,
+			path:     "/tags/9",
+			mockSetup: func(m *mockTagService) {
+				m.On("GetTag", mock.Anything, 9, "user1").Return((*models.Tag)(nil), services.ErrTagNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"Etiqueta no encontrada"`,
+		},
+		{
+			testName:    "Crear etiqueta",
+			method:      http.MethodPost,
+			path:        "/tags",
+			requestBody: `{"name":"trabajo"}`,
+			mockSetup: func(m *mockTagService) {
+				m.On("CreateTag", mock.Anything, "user1", "trabajo").Return(&models.Tag{ID: 1, Name: "trabajo"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"name":"trabajo"`,
+		},
+		{
+			testName:       "Crear sin nombre",
+			method:         http.MethodPost,
+			path:           "/tags",
+			requestBody:    `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"El nombre es requerido"`,
+		},
+		{
+			testName:    "Nombre repetido",
+			method:      http.MethodPost,
+			path:        "/tags",
+			requestBody: `{"name":"trabajo"}`,
+			mockSetup: func(m *mockTagService) {
+				m.On("CreateTag", mock.Anything, "user1", "trabajo").Return((*models.Tag)(nil), services.ErrTagExists)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"error":"Ya existe una etiqueta con ese nombre"`,
+		},
+		{
+			testName:    "Nombre inválido",
+			method:      http.MethodPost,
+			path:        "/tags",
+			requestBody: `{"name":"   "}`,
+			mockSetup: func(m *mockTagService) {
+				m.On("CreateTag", mock.Anything, "user1", "   ").Return((*models.Tag)(nil), services.ErrInvalidTagName)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"El nombre debe tener entre 1 y 50 caracteres"`,
+		},
+		{
+			testName:    "Renombrar etiqueta",
+			method:      http.MethodPut,
+			path:        "/tags/1",
+			requestBody: `{"name":"oficina"}`,
+			mockSetup: func(m *mockTagService) {
+				m.On("RenameTag", mock.Anything, 1, "user1", "oficina").Return(&models.Tag{ID: 1, Name: "oficina"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"name":"oficina"`,
+		},
+		{
+			testName:       "Renombrar con ID inválido",
+			method:         http.MethodPut,
+			path:           "/tags/abc",
+			requestBody:    `{"name":"oficina"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"ID inválido"`,
+		},
+		{
+			testName: "Eliminar etiqueta",
+			method:   http.MethodDelete,
+			path:     "/tags/1",
+			mockSetup: func(m *mockTagService) {
+				m.On("DeleteTag", mock.Anything, 1, "user1").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"message":"Etiqueta eliminada exitosamente"`,
+		},
+		{
+			testName: "Eliminar etiqueta inexistente",
+			method:   http.MethodDelete,
+			path:     "/tags/9",
+			mockSetup: func(m *mockTagService) {
+				m.On("DeleteTag", mock.Anything, 9, "user1").Return(services.ErrTagNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"Etiqueta no encontrada"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockTagService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewTagHandler(mockService, logrus.New())
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.GET("/tags", handler.ListTags)
+			router.GET("/tags/:id", handler.GetTag)
+			router.POST("/tags", handler.CreateTag)
+			router.PUT("/tags/:id", handler.UpdateTag)
+			router.DELETE("/tags/:id", handler.DeleteTag)
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
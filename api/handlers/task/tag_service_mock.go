@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockTagService struct {
+	mock.Mock
+}
+
+func (m *mockTagService) ListTags(ctx context.Context, username string) ([]*models.Tag, error) {
+	args := m.Called(ctx, username)
+	return args.Get(0).([]*models.Tag), args.Error(1)
+}
+func (m *mockTagService) GetTag(ctx context.Context, id int, username string) (*models.Tag, error) {
+	args := m.Called(ctx, id, username)
+	return args.Get(0).(*models.Tag), args.Error(1)
+}
+func (m *mockTagService) CreateTag(ctx context.Context, username, name string) (*models.Tag, error) {
+	args := m.Called(ctx, username, name)
+	return args.Get(0).(*models.Tag), args.Error(1)
+}
+func (m *mockTagService) RenameTag(ctx context.Context, id int, username, name string) (*models.Tag, error) {
+	args := m.Called(ctx, id, username, name)
+	return args.Get(0).(*models.Tag), args.Error(1)
+}
+func (m *mockTagService) DeleteTag(ctx context.Context, id int, username string) error {
+	args := m.Called(ctx, id, username)
+	return args.Error(0)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBlockers godoc
+// @Summary      Listar bloqueos
+// @Description  Obtiene las tareas que bloquean a una tarea: no se puede completar mientras alguna siga pendiente
+// @Tags         tasks
+// @Produce      json
+// @Param        id path int true "ID de la tarea"
+// @Success      200 {array} models.TaskResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tasks/{id}/blockers [get]
+func (h *taskHandler) GetBlockers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: GetBlockers] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	username, _ := c.Get("username")
+	blockers, err := h.taskService.ListBlockers(c.Request.Context(), id, username.(string))
+	if err != nil {
+		h.blockerError(c, "GetBlockers", err)
+		return
+	}
+	resp := make([]models.TaskResponse, 0, len(blockers))
+	for _, t := range blockers {
+		resp = append(resp, toTaskResponse(t))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AddBlocker godoc
+// @Summary      Agregar bloqueo
+// @Description  Indica que la tarea no puede completarse hasta que blocker_id esté completada. Las dependencias no pueden formar ciclos. Sube la versión de la tarea bloqueada
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID de la tarea bloqueada"
+// @Param        request body models.BlockerRequest true "Tarea que bloquea"
+// @Success      201 {object} models.TaskResponse
+// @Header       201 {string} ETag "Nueva versión de la tarea bloqueada"
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tasks/{id}/blockers [post]
+func (h *taskHandler) AddBlocker(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: AddBlocker] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var req models.BlockerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: AddBlocker] Datos inválidos: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "blocker_id es obligatorio"})
+		return
+	}
+	username, _ := c.Get("username")
+	task, err := h.taskService.AddBlocker(c.Request.Context(), id, username.(string), req.BlockerID)
+	if err != nil {
+		h.blockerError(c, "AddBlocker", err)
+		return
+	}
+	c.Header("ETag", taskETag(task))
+	c.JSON(http.StatusCreated, toTaskResponse(task))
+}
+
+// RemoveBlocker godoc
+// @Summary      Quitar bloqueo
+// @Description  Elimina la dependencia entre la tarea y blocker_id
+// @Tags         tasks
+// @Produce      json
+// @Param        id path int true "ID de la tarea bloqueada"
+// @Param        blocker_id path int true "ID de la tarea que bloquea"
+// @Success      200 {object} models.TaskResponse
+// @Header       200 {string} ETag "Nueva versión de la tarea bloqueada"
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tasks/{id}/blockers/{blocker_id} [delete]
+func (h *taskHandler) RemoveBlocker(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: RemoveBlocker] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	blockerID, err := strconv.ParseUint(c.Param("blocker_id"), 10, 32)
+	if err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: RemoveBlocker] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	username, _ := c.Get("username")
+	task, err := h.taskService.RemoveBlocker(c.Request.Context(), id, username.(string), uint(blockerID))
+	if err != nil {
+		h.blockerError(c, "RemoveBlocker", err)
+		return
+	}
+	c.Header("ETag", taskETag(task))
+	c.JSON(http.StatusOK, toTaskResponse(task))
+}
+
+func (h *taskHandler) blockerError(c *gin.Context, method string, err error) {
+	switch {
+	case errors.Is(err, services.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
+	case errors.Is(err, services.ErrDependencyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "La tarea no está bloqueada por esa tarea"})
+	case errors.Is(err, services.ErrBlockerNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "blocker_id no corresponde a ninguna tarea del usuario"})
+	case errors.Is(err, services.ErrDependencyExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "La tarea ya está bloqueada por esa tarea"})
+	case errors.Is(err, services.ErrDependencyCycle):
+		c.JSON(http.StatusConflict, gin.H{"error": "La dependencia formaría un ciclo"})
+	default:
+		h.logger.Errorf("[Layer: task_handler] [Method: %s] Error: %v", method, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar los bloqueos"})
+	}
+}
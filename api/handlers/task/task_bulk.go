@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BulkTasks godoc
+// @Summary      Operaciones en lote
+// @Description  Ejecuta hasta 100 operaciones create, update, delete o complete en una sola transacción. En modo atomic (por defecto) si una falla no se aplica ninguna y la respuesta lleva el código de la que falló; en best_effort se aplican las que se puedan y cada resultado trae su propio código
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Param        request body models.BulkTaskRequest true "Operaciones"
+// @Success      200 {object} models.BulkTaskResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} models.BulkTaskResponse
+// @Failure      409 {object} models.BulkTaskResponse
+// @Failure      412 {object} models.BulkTaskResponse
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tasks/bulk [post]
+func (h *taskHandler) BulkTasks(c *gin.Context) {
+	var req models.BulkTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: BulkTasks] Datos inválidos: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "operations es obligatorio"})
+		return
+	}
+	ops := make([]services.BulkOperation, 0, len(req.Operations))
+	for _, op := range req.Operations {
+		operation := services.BulkOperation{Op: op.Op, ID: op.ID, ExpectedVersion: op.Version}
+		if op.Task != nil {
+			operation.Fields = services.TaskFields{
+				Title:       op.Task.Title,
+				Description: op.Task.Description,
+				Completed:   op.Task.Completed,
+				Priority:    op.Task.Priority,
+				DueAt:       op.Task.DueAt,
+				Recurrence:  op.Task.Recurrence,
+				ProjectID:   op.Task.ProjectID,
+				ParentID:    op.Task.ParentID,
+				Tags:        op.Task.Tags,
+			}
+		}
+		ops = append(ops, operation)
+	}
+
+	username, _ := c.Get("username")
+	results, err := h.taskService.BulkTasks(auditContext(c), username.(string), req.Mode, ops)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidBulkMode):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mode debe ser atomic o best_effort"})
+		case errors.Is(err, services.ErrInvalidBulkSize):
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("operations debe tener entre 1 y %d operaciones", services.MaxBulkOperations)})
+		default:
+			h.logger.Error("[Layer: task_handler] [Method: BulkTasks] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron aplicar las operaciones"})
+		}
+		return
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = services.BulkModeAtomic
+	}
+	resp := models.BulkTaskResponse{Mode: mode, Results: make([]models.BulkOperationResult, 0, len(results))}
+	status := http.StatusOK
+	for i, result := range results {
+		item := models.BulkOperationResult{Index: i, Op: req.Operations[i].Op, Status: http.StatusOK}
+		switch {
+		case result.Err != nil:
+			item.Status, item.Error = bulkOperationError(result.Err)
+			// en un lote atómico la respuesta toma el código de la operación que lo hizo fallar
+			if mode == services.BulkModeAtomic && !errors.Is(result.Err, services.ErrBulkAborted) {
+				status = item.Status
+			}
+		case item.Op == services.BulkOpCreate:
+			item.Status = http.StatusCreated
+		}
+		if result.Err == nil {
+			resp.Applied++
+			if result.Task != nil {
+				task := toTaskResponse(result.Task)
+				item.Task = &task
+			}
+		}
+		resp.Results = append(resp.Results, item)
+	}
+	c.JSON(status, resp)
+}
+
+func bulkOperationError(err error) (int, string) {
+	if message, ok := taskFieldError(err); ok {
+		return http.StatusBadRequest, message
+	}
+	switch {
+	case errors.Is(err, services.ErrBulkAborted):
+		return http.StatusFailedDependency, "No se aplicó porque otra operación del lote falló"
+	case errors.Is(err, services.ErrInvalidBulkOperation):
+		return http.StatusBadRequest, "op debe ser create, update, delete o complete"
+	case errors.Is(err, services.ErrTaskNotFound):
+		return http.StatusNotFound, "Tarea no encontrada"
+	case errors.Is(err, services.ErrVersionConflict):
+		return http.StatusPreconditionFailed, "La tarea cambió desde la versión indicada"
+	case errors.Is(err, services.ErrTaskBlocked):
+		return http.StatusConflict, "La tarea tiene bloqueos pendientes"
+	}
+	return http.StatusInternalServerError, "No se pudo aplicar la operación"
+}
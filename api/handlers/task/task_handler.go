@@ -1,13 +1,21 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"prueba_tecnica_go_guarapo/api/models"
 	services "prueba_tecnica_go_guarapo/api/services/task"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
 )
 
@@ -15,8 +23,26 @@ type TaskHandler interface {
 	GetTasks(c *gin.Context)
 	GetTask(c *gin.Context)
 	CreateTask(c *gin.Context)
+	GetSubtasks(c *gin.Context)
+	GetBlockers(c *gin.Context)
+	AddBlocker(c *gin.Context)
+	RemoveBlocker(c *gin.Context)
+	GetTrash(c *gin.Context)
+	SearchTasks(c *gin.Context)
+	RestoreTask(c *gin.Context)
+	GetTaskHistory(c *gin.Context)
+	RevertTask(c *gin.Context)
+	BulkTasks(c *gin.Context)
+	PreviewRecurrence(c *gin.Context)
+	GetProjectTasks(c *gin.Context)
+	CreateProjectTask(c *gin.Context)
 	UpdateTask(c *gin.Context)
+	PatchTask(c *gin.Context)
 	DeleteTask(c *gin.Context)
+	GetAllTasks(c *gin.Context)
+	GetUserTasks(c *gin.Context)
+	GetTaskAsAdmin(c *gin.Context)
+	DeleteTaskAsAdmin(c *gin.Context)
 }
 
 type taskHandler struct {
@@ -39,45 +65,104 @@ func NewTaskHandler(taskService services.TaskService, logger *logrus.Logger) Tas
 // @Produce      json
 // @Param        request body models.CreateTaskRequest true "Datos de la tarea"
 // @Success      201 {object} models.TaskResponse
+// @Header       201 {string} ETag "Versión de la tarea"
 // @Failure      400 {object} map[string]string
 // @Failure      500 {object} map[string]string
 // @Security     ApiKeyAuth
+// @Security     APIKeyHeader
 // @Router       /api/tasks [post]
 func (h *taskHandler) CreateTask(c *gin.Context) {
+	h.createTask(c, nil, "CreateTask")
+}
+
+// CreateProjectTask godoc
+// @Summary      Crear tarea en un proyecto
+// @Description  Crea una tarea dentro del proyecto indicado; el project_id del cuerpo se ignora
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID del proyecto"
+// @Param        request body models.CreateTaskRequest true "Datos de la tarea"
+// @Success      201 {object} models.TaskResponse
+// @Header       201 {string} ETag "Versión de la tarea"
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/projects/{id}/tasks [post]
+func (h *taskHandler) CreateProjectTask(c *gin.Context) {
+	projectID, ok := h.idParam(c, "CreateProjectTask")
+	if !ok {
+		return
+	}
+	h.createTask(c, &projectID, "CreateProjectTask")
+}
+
+// createTask crea la tarea; con projectID (ruta anidada) el proyecto sale de la URL y sus errores son 404/409.
+func (h *taskHandler) createTask(c *gin.Context, projectID *uint, method string) {
 	var req models.CreateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warn("[Layer: task_handler] [Method: CreateTask] Datos inválidos: ", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "El título no puede estar vacío"})
+		h.logger.Warnf("[Layer: task_handler] [Method: %s] Datos inválidos: %v", method, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": bindErrorMessage(err)})
 		return
 	}
+	fields := services.TaskFields{
+		Title:       req.Title,
+		Description: req.Description,
+		Priority:    req.Priority,
+		DueAt:       req.DueAt,
+		Recurrence:  req.Recurrence,
+		ProjectID:   req.ProjectID,
+		ParentID:    req.ParentID,
+		Tags:        req.Tags,
+	}
+	if projectID != nil {
+		fields.ProjectID = projectID
+	}
 	username, _ := c.Get("username")
-	newTask, err := h.taskService.CreateTask(c.Request.Context(), req.Title, username.(string))
+	newTask, err := h.taskService.CreateTask(auditContext(c), username.(string), fields)
 	if err != nil {
-		h.logger.Error("[Layer: task_handler] [Method: CreateTask] Error: ", err)
+		if projectID != nil {
+			switch {
+			case errors.Is(err, services.ErrProjectNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Proyecto no encontrado"})
+				return
+			case errors.Is(err, services.ErrProjectArchived):
+				c.JSON(http.StatusConflict, gin.H{"error": "El proyecto está archivado"})
+				return
+			}
+		}
+		if message, ok := taskFieldError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		h.logger.Errorf("[Layer: task_handler] [Method: %s] Error: %v", method, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear la tarea"})
 		return
 	}
-	resp := models.TaskResponse{
-		ID:        newTask.ID,
-		Title:     newTask.Title,
-		Completed: newTask.Completed,
-		Owner:     newTask.Owner,
-	}
-	c.JSON(http.StatusCreated, resp)
+	c.Header("ETag", taskETag(newTask))
+	c.JSON(http.StatusCreated, toTaskResponse(newTask))
 }
 
 // UpdateTask godoc
 // @Summary      Actualizar tarea
-// @Description  Actualiza una tarea existente del usuario autenticado
+// @Description  Actualiza una tarea existente del usuario autenticado. Con If-Match solo escribe si la tarea sigue en esa versión
 // @Tags         tasks
 // @Accept       json
 // @Produce      json
 // @Param        id path int true "ID de la tarea"
+// @Param        If-Match header string false "ETag obtenido al leer la tarea"
 // @Param        request body models.UpdateTaskRequest true "Datos de la tarea"
 // @Success      200 {object} models.TaskResponse
+// @Header       200 {string} ETag "Nueva versión de la tarea"
 // @Failure      400 {object} map[string]string
 // @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      412 {object} map[string]string
 // @Security     ApiKeyAuth
+// @Security     APIKeyHeader
 // @Router       /api/tasks/{id} [put]
 func (h *taskHandler) UpdateTask(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -89,64 +174,268 @@ func (h *taskHandler) UpdateTask(c *gin.Context) {
 	var req models.UpdateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Warn("[Layer: task_handler] [Method: UpdateTask] Datos inválidos: ", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "El título no puede estar vacío"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": bindErrorMessage(err)})
 		return
 	}
 	username, _ := c.Get("username")
-	updatedTask, err := h.taskService.UpdateTask(c.Request.Context(), id, req.Title, req.Completed, username.(string))
+	expectedVersion, ok := h.expectedVersion(c, id, username.(string))
+	if !ok {
+		preconditionFailed(c)
+		return
+	}
+	updatedTask, err := h.taskService.UpdateTask(auditContext(c), id, username.(string), services.TaskFields{
+		Title:       req.Title,
+		Description: req.Description,
+		Completed:   req.Completed,
+		Priority:    req.Priority,
+		DueAt:       req.DueAt,
+		Recurrence:  req.Recurrence,
+		ProjectID:   req.ProjectID,
+		ParentID:    req.ParentID,
+		Tags:        req.Tags,
+	}, expectedVersion)
 	if err != nil {
+		if errors.Is(err, services.ErrVersionConflict) {
+			preconditionFailed(c)
+			return
+		}
+		if errors.Is(err, services.ErrTaskBlocked) {
+			c.JSON(http.StatusConflict, gin.H{"error": "La tarea tiene bloqueos pendientes"})
+			return
+		}
+		if message, ok := taskFieldError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
 		h.logger.Warn("[Layer: task_handler] [Method: UpdateTask] No encontrada: ", err)
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
 		return
 	}
-	resp := models.TaskResponse{
-		ID:        updatedTask.ID,
-		Title:     updatedTask.Title,
-		Completed: updatedTask.Completed,
-		Owner:     updatedTask.Owner,
+	c.Header("ETag", taskETag(updatedTask))
+	c.JSON(http.StatusOK, toTaskResponse(updatedTask))
+}
+
+// PatchTask godoc
+// @Summary      Actualizar tarea parcialmente
+// @Description  Modifica solo los campos enviados. Acepta JSON Merge Patch (application/merge-patch+json, también application/json) o JSON Patch (application/json-patch+json)
+// @Tags         tasks
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Param        id path int true "ID de la tarea"
+// @Param        If-Match header string false "ETag obtenido al leer la tarea"
+// @Param        request body object true "Merge patch o lista de operaciones JSON Patch"
+// @Success      200 {object} models.TaskResponse
+// @Header       200 {string} ETag "Nueva versión de la tarea"
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      412 {object} map[string]string
+// @Failure      415 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tasks/{id} [patch]
+func (h *taskHandler) PatchTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: PatchTask] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
 	}
-	c.JSON(http.StatusOK, resp)
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: PatchTask] No se pudo leer el cuerpo: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el cuerpo"})
+		return
+	}
+	ifMatch, ok := ifMatchVersions(c)
+	if !ok {
+		preconditionFailed(c)
+		return
+	}
+	username, _ := c.Get("username")
+
+	// el parche se aplica sobre la versión leída y se escribe solo si sigue vigente; sin If-Match, si otra
+	// escritura se adelantó se vuelve a leer y a aplicar (las operaciones test se evalúan de nuevo)
+	var updated *models.Task
+	for attempt := 1; ; attempt++ {
+		current, err := h.taskService.GetTaskByID(c.Request.Context(), id, username.(string))
+		if err != nil {
+			h.logger.Warn("[Layer: task_handler] [Method: PatchTask] No encontrada: ", err)
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
+			return
+		}
+		if ifMatch != nil && !slices.Contains(ifMatch, current.Version) {
+			preconditionFailed(c)
+			return
+		}
+
+		fields, mask, err := applyTaskPatch(c.ContentType(), toTaskDocument(current), body)
+		if err != nil {
+			var patchErr *patchError
+			if errors.As(err, &patchErr) {
+				h.logger.Warnf("[Layer: task_handler] [Method: PatchTask] Parche rechazado para la tarea '%d': %s", id, patchErr.message)
+				c.JSON(patchErr.status, gin.H{"error": patchErr.message})
+				return
+			}
+			h.logger.Error("[Layer: task_handler] [Method: PatchTask] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar la tarea"})
+			return
+		}
+		if len(mask) == 0 {
+			c.Header("ETag", taskETag(current))
+			c.JSON(http.StatusOK, toTaskResponse(current))
+			return
+		}
+
+		updated, err = h.taskService.PatchTask(auditContext(c), id, username.(string), fields, mask, current.Version)
+		if err == nil {
+			break
+		}
+		if message, ok := taskFieldError(err); ok {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": message})
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrVersionConflict) && ifMatch != nil:
+			preconditionFailed(c)
+		case errors.Is(err, services.ErrVersionConflict) && attempt < maxPatchAttempts:
+			h.logger.Warnf("[Layer: task_handler] [Method: PatchTask] La tarea '%d' cambió durante el parche, reintentando", id)
+			continue
+		case errors.Is(err, services.ErrVersionConflict):
+			h.logger.Warnf("[Layer: task_handler] [Method: PatchTask] La tarea '%d' siguió cambiando tras %d intentos", id, attempt)
+			c.JSON(http.StatusConflict, gin.H{"error": "La tarea cambió mientras se aplicaba el parche; vuelve a intentarlo"})
+		case errors.Is(err, services.ErrTaskBlocked):
+			c.JSON(http.StatusConflict, gin.H{"error": "La tarea tiene bloqueos pendientes"})
+		case errors.Is(err, services.ErrTaskNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
+		default:
+			h.logger.Error("[Layer: task_handler] [Method: PatchTask] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar la tarea"})
+		}
+		return
+	}
+	c.Header("ETag", taskETag(updated))
+	c.JSON(http.StatusOK, toTaskResponse(updated))
 }
 
 // GetTasks godoc
 // @Summary      Listar tareas
-// @Description  Obtiene todas las tareas del usuario autenticado
+// @Description  Obtiene las tareas del usuario autenticado paginadas por cursor. Para la siguiente página envía next_cursor en after
 // @Tags         tasks
 // @Produce      json
-// @Success      200 {array} models.TaskResponse
+// @Param        limit query int false "Tamaño de página (por defecto 20, máximo 100)"
+// @Param        after query string false "Cursor next_cursor de la página anterior"
+// @Param        completed query bool false "Filtrar por estado"
+// @Param        q query string false "Texto contenido en el título"
+// @Param        priority query string false "low, medium, high o urgent"
+// @Param        due_before query string false "Solo tareas con due_at anterior a esta fecha (RFC 3339)"
+// @Param        overdue query bool false "true: pendientes con due_at vencido; false: el resto"
+// @Param        project_id query string false "ID de proyecto o inbox para las tareas sin proyecto"
+// @Param        parent_id query string false "ID de la tarea padre o root para las tareas raíz"
+// @Param        include query string false "subtasks agrega el árbol de subtareas de cada tarea"
+// @Param        tag query []string false "Nombre de etiqueta; se puede repetir" collectionFormat(multi)
+// @Param        tag_mode query string false "all: con todas las etiquetas; any: con alguna" default(all)
+// @Param        sort query string false "created_at, updated_at o title; prefijo - para descendente" default(created_at)
+// @Success      200 {object} models.TaskListResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
 // @Failure      500 {object} map[string]string
 // @Security     ApiKeyAuth
+// @Security     APIKeyHeader
 // @Router       /api/tasks [get]
 func (h *taskHandler) GetTasks(c *gin.Context) {
 	username, _ := c.Get("username")
-	tasks, err := h.taskService.GetTasksByUser(c.Request.Context(), username.(string))
-	if err != nil {
-		h.logger.Error("[Layer: task_handler] [Method: GetTasks] Error: ", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener tareas"})
+	h.listTasks(c, services.TaskQuery{Owner: username.(string)}, "GetTasks")
+}
+
+// GetProjectTasks godoc
+// @Summary      Listar tareas de un proyecto
+// @Description  Obtiene las tareas del proyecto con la misma paginación y filtros de /api/tasks
+// @Tags         projects
+// @Produce      json
+// @Param        id path int true "ID del proyecto"
+// @Param        limit query int false "Tamaño de página (por defecto 20, máximo 100)"
+// @Param        after query string false "Cursor next_cursor de la página anterior"
+// @Param        completed query bool false "Filtrar por estado"
+// @Param        q query string false "Texto contenido en el título"
+// @Param        priority query string false "low, medium, high o urgent"
+// @Param        due_before query string false "Solo tareas con due_at anterior a esta fecha (RFC 3339)"
+// @Param        overdue query bool false "true: pendientes con due_at vencido; false: el resto"
+// @Param        tag query []string false "Nombre de etiqueta; se puede repetir" collectionFormat(multi)
+// @Param        tag_mode query string false "all: con todas las etiquetas; any: con alguna" default(all)
+// @Param        include query string false "subtasks agrega el árbol de subtareas de cada tarea"
+// @Param        sort query string false "created_at, updated_at o title; prefijo - para descendente" default(created_at)
+// @Success      200 {object} models.TaskListResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/projects/{id}/tasks [get]
+func (h *taskHandler) GetProjectTasks(c *gin.Context) {
+	projectID, ok := h.idParam(c, "GetProjectTasks")
+	if !ok {
 		return
 	}
-	var resp []models.TaskResponse
-	for _, t := range tasks {
-		resp = append(resp, models.TaskResponse{
-			ID:        t.ID,
-			Title:     t.Title,
-			Completed: t.Completed,
-			Owner:     t.Owner,
-		})
+	username, _ := c.Get("username")
+	h.listTasks(c, services.TaskQuery{Owner: username.(string), ProjectID: &projectID}, "GetProjectTasks")
+}
+
+// GetSubtasks godoc
+// @Summary      Listar subtareas
+// @Description  Obtiene las subtareas directas de una tarea con la misma paginación y filtros de /api/tasks
+// @Tags         tasks
+// @Produce      json
+// @Param        id path int true "ID de la tarea padre"
+// @Param        limit query int false "Tamaño de página (por defecto 20, máximo 100)"
+// @Param        after query string false "Cursor next_cursor de la página anterior"
+// @Param        completed query bool false "Filtrar por estado"
+// @Param        include query string false "subtasks agrega el árbol de subtareas de cada tarea"
+// @Param        sort query string false "created_at, updated_at o title; prefijo - para descendente" default(created_at)
+// @Success      200 {object} models.TaskListResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tasks/{id}/subtasks [get]
+func (h *taskHandler) GetSubtasks(c *gin.Context) {
+	parentID, ok := h.idParam(c, "GetSubtasks")
+	if !ok {
+		return
+	}
+	username, _ := c.Get("username")
+	h.listTasks(c, services.TaskQuery{Owner: username.(string), ParentID: &parentID}, "GetSubtasks")
+}
+
+func (h *taskHandler) idParam(c *gin.Context, method string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		h.logger.Warnf("[Layer: task_handler] [Method: %s] ID inválido: '%s'", method, c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return 0, false
 	}
-	c.JSON(http.StatusOK, resp)
+	return uint(id), true
 }
 
 // GetTask godoc
 // @Summary      Obtener tarea
-// @Description  Obtiene una tarea específica del usuario autenticado
+// @Description  Obtiene una tarea específica del usuario autenticado. Responde 304 si If-None-Match coincide con la versión actual (salvo con include=subtasks)
 // @Tags         tasks
 // @Produce      json
 // @Param        id path int true "ID de la tarea"
+// @Param        include query string false "subtasks agrega el árbol de subtareas"
+// @Param        If-None-Match header string false "ETag de la copia que ya tiene el cliente"
 // @Success      200 {object} models.TaskResponse
+// @Header       200 {string} ETag "Versión de la tarea"
+// @Success      304 "La tarea no cambió"
 // @Failure      400 {object} map[string]string
 // @Failure      404 {object} map[string]string
 // @Security     ApiKeyAuth
+// @Security     APIKeyHeader
 // @Router       /api/tasks/{id} [get]
 func (h *taskHandler) GetTask(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -155,6 +444,11 @@ func (h *taskHandler) GetTask(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
 		return
 	}
+	includeSubtasks, ok := includesSubtasks(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "include solo admite subtasks"})
+		return
+	}
 	username, _ := c.Get("username")
 	task, err := h.taskService.GetTaskByID(c.Request.Context(), id, username.(string))
 	if err != nil {
@@ -162,25 +456,40 @@ func (h *taskHandler) GetTask(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
 		return
 	}
-	resp := models.TaskResponse{
-		ID:        task.ID,
-		Title:     task.Title,
-		Completed: task.Completed,
-		Owner:     task.Owner,
+	etag := taskETag(task)
+	c.Header("ETag", etag)
+	if !includeSubtasks {
+		// el ETag es la versión de la tarea; no cambia cuando cambian sus subtareas
+		if header := c.GetHeader("If-None-Match"); header != "" && etagMatches(header, etag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.JSON(http.StatusOK, toTaskResponse(task))
+		return
 	}
-	c.JSON(http.StatusOK, resp)
+	tree, err := h.taskService.SubtaskTree(c.Request.Context(), username.(string), []uint{task.ID})
+	if err != nil {
+		h.logger.Error("[Layer: task_handler] [Method: GetTask] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener tareas"})
+		return
+	}
+	c.JSON(http.StatusOK, toTaskTree(task, tree))
 }
 
 // DeleteTask godoc
 // @Summary      Eliminar tarea
-// @Description  Elimina una tarea del usuario autenticado
+// @Description  Manda una tarea del usuario autenticado (y sus subtareas) a la papelera. Con permanent=true la borra definitivamente, esté o no en la papelera. Con If-Match solo elimina si la tarea sigue en esa versión
 // @Tags         tasks
 // @Produce      json
 // @Param        id path int true "ID de la tarea"
+// @Param        permanent query bool false "Borrar definitivamente sin pasar por la papelera"
+// @Param        If-Match header string false "ETag obtenido al leer la tarea"
 // @Success      200 {object} map[string]string
 // @Failure      400 {object} map[string]string
 // @Failure      404 {object} map[string]string
+// @Failure      412 {object} map[string]string
 // @Security     ApiKeyAuth
+// @Security     APIKeyHeader
 // @Router       /api/tasks/{id} [delete]
 func (h *taskHandler) DeleteTask(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -189,12 +498,409 @@ func (h *taskHandler) DeleteTask(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
 		return
 	}
+	permanent := false
+	if raw := c.Query("permanent"); raw != "" {
+		if permanent, err = strconv.ParseBool(raw); err != nil {
+			h.logger.Warnf("[Layer: task_handler] [Method: DeleteTask] permanent inválido: '%s'", raw)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "permanent debe ser true o false"})
+			return
+		}
+	}
 	username, _ := c.Get("username")
-	err = h.taskService.DeleteTask(c.Request.Context(), id, username.(string))
+	expectedVersion, ok := h.expectedVersion(c, id, username.(string))
+	if !ok {
+		preconditionFailed(c)
+		return
+	}
+	if permanent {
+		err = h.taskService.PurgeTask(auditContext(c), id, username.(string), expectedVersion)
+	} else {
+		err = h.taskService.DeleteTask(auditContext(c), id, username.(string), expectedVersion)
+	}
 	if err != nil {
+		if errors.Is(err, services.ErrVersionConflict) {
+			preconditionFailed(c)
+			return
+		}
 		h.logger.Warn("[Layer: task_handler] [Method: DeleteTask] No encontrada: ", err)
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
 		return
 	}
+	if permanent {
+		c.JSON(http.StatusOK, gin.H{"message": "Tarea eliminada permanentemente"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Tarea eliminada exitosamente"})
 }
+
+// GetAllTasks godoc
+// @Summary      Listar todas las tareas (admin)
+// @Description  Obtiene las tareas de todos los usuarios paginadas por cursor. Para la siguiente página envía next_cursor en after
+// @Tags         admin
+// @Produce      json
+// @Param        limit query int false "Tamaño de página (por defecto 20, máximo 100)"
+// @Param        after query string false "Cursor next_cursor de la página anterior"
+// @Param        sort query string false "created_at, updated_at o title; prefijo - para descendente" default(created_at)
+// @Success      200 {object} models.TaskListResponse
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/admin/tasks [get]
+func (h *taskHandler) GetAllTasks(c *gin.Context) {
+	query := services.TaskQuery{Sort: c.Query("sort"), After: c.Query("after")}
+	limit, ok := h.limitParam(c, "GetAllTasks")
+	if !ok {
+		return
+	}
+	query.Limit = limit
+	page, err := h.taskService.GetAllTasks(c.Request.Context(), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSort):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort debe ser created_at, updated_at o title (prefijo - para descendente)"})
+		case errors.Is(err, services.ErrInvalidCursor):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor inválido"})
+		default:
+			h.logger.Error("[Layer: task_handler] [Method: GetAllTasks] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener tareas"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, toTaskListResponse(page, nil))
+}
+
+// GetUserTasks godoc
+// @Summary      Listar tareas de un usuario (admin)
+// @Description  Obtiene las tareas de cualquier usuario con la misma paginación y filtros de /api/tasks
+// @Tags         admin
+// @Produce      json
+// @Param        username path string true "Username del dueño"
+// @Param        limit query int false "Tamaño de página (por defecto 20, máximo 100)"
+// @Param        after query string false "Cursor next_cursor de la página anterior"
+// @Param        completed query bool false "Filtrar por estado"
+// @Param        q query string false "Texto contenido en el título"
+// @Param        priority query string false "low, medium, high o urgent"
+// @Param        due_before query string false "Solo tareas con due_at anterior a esta fecha (RFC 3339)"
+// @Param        overdue query bool false "true: pendientes con due_at vencido; false: el resto"
+// @Param        project_id query string false "ID de proyecto o inbox para las tareas sin proyecto"
+// @Param        parent_id query string false "ID de la tarea padre o root para las tareas raíz"
+// @Param        include query string false "subtasks agrega el árbol de subtareas de cada tarea"
+// @Param        tag query []string false "Nombre de etiqueta; se puede repetir" collectionFormat(multi)
+// @Param        tag_mode query string false "all: con todas las etiquetas; any: con alguna" default(all)
+// @Param        sort query string false "created_at, updated_at o title; prefijo - para descendente" default(created_at)
+// @Success      200 {object} models.TaskListResponse
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/admin/users/{username}/tasks [get]
+func (h *taskHandler) GetUserTasks(c *gin.Context) {
+	h.listTasks(c, services.TaskQuery{Owner: c.Param("username")}, "GetUserTasks")
+}
+
+// listTasks completa query con los parámetros de listado; Owner (y ProjectID o ParentID en las rutas anidadas) los fija quien llama.
+func (h *taskHandler) listTasks(c *gin.Context, query services.TaskQuery, method string) {
+	query.Search = c.Query("q")
+	query.Tags = c.QueryArray("tag")
+	query.TagMode = c.Query("tag_mode")
+	query.Sort = c.Query("sort")
+	query.After = c.Query("after")
+	if raw := c.Query("project_id"); raw != "" && query.ProjectID == nil {
+		projectID := uint(0)
+		if raw != "inbox" {
+			id, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil || id == 0 {
+				h.logger.Warnf("[Layer: task_handler] [Method: %s] project_id inválido: '%s'", method, raw)
+				c.JSON(http.StatusBadRequest, gin.H{"error": "project_id debe ser un ID de proyecto o inbox"})
+				return
+			}
+			projectID = uint(id)
+		}
+		query.ProjectID = &projectID
+	}
+	if raw := c.Query("parent_id"); raw != "" && query.ParentID == nil {
+		parentID := uint(0)
+		if raw != "root" {
+			id, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil || id == 0 {
+				h.logger.Warnf("[Layer: task_handler] [Method: %s] parent_id inválido: '%s'", method, raw)
+				c.JSON(http.StatusBadRequest, gin.H{"error": "parent_id debe ser un ID de tarea o root"})
+				return
+			}
+			parentID = uint(id)
+		}
+		query.ParentID = &parentID
+	}
+	includeSubtasks, ok := includesSubtasks(c)
+	if !ok {
+		h.logger.Warnf("[Layer: task_handler] [Method: %s] include inválido: '%s'", method, c.Query("include"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "include solo admite subtasks"})
+		return
+	}
+	limit, ok := h.limitParam(c, method)
+	if !ok {
+		return
+	}
+	query.Limit = limit
+	if raw := c.Query("priority"); raw != "" {
+		query.Priority = raw
+	}
+	if raw := c.Query("due_before"); raw != "" {
+		dueBefore, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			h.logger.Warnf("[Layer: task_handler] [Method: %s] due_before inválido: '%s'", method, raw)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "due_before debe tener formato RFC 3339"})
+			return
+		}
+		query.DueBefore = &dueBefore
+	}
+	if raw := c.Query("overdue"); raw != "" {
+		overdue, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.logger.Warnf("[Layer: task_handler] [Method: %s] overdue inválido: '%s'", method, raw)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "overdue debe ser true o false"})
+			return
+		}
+		query.Overdue = &overdue
+	}
+	if raw := c.Query("completed"); raw != "" {
+		completed, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.logger.Warnf("[Layer: task_handler] [Method: %s] completed inválido: '%s'", method, raw)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "completed debe ser true o false"})
+			return
+		}
+		query.Completed = &completed
+	}
+
+	page, err := h.taskService.ListTasks(c.Request.Context(), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSort):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort debe ser created_at, updated_at o title (prefijo - para descendente)"})
+		case errors.Is(err, services.ErrInvalidCursor):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor inválido"})
+		case errors.Is(err, services.ErrInvalidPriority):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "priority debe ser low, medium, high o urgent"})
+		case errors.Is(err, services.ErrProjectNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Proyecto no encontrado"})
+		case errors.Is(err, services.ErrTaskNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
+		case errors.Is(err, services.ErrInvalidTagMode):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tag_mode debe ser all o any"})
+		case errors.Is(err, services.ErrInvalidTagName), errors.Is(err, services.ErrTooManyTags):
+			message, _ := taskFieldError(err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		default:
+			h.logger.Errorf("[Layer: task_handler] [Method: %s] Error: %v", method, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener tareas"})
+		}
+		return
+	}
+	var tree map[uint][]*models.Task
+	if includeSubtasks && len(page.Tasks) > 0 {
+		ids := make([]uint, 0, len(page.Tasks))
+		for _, t := range page.Tasks {
+			ids = append(ids, t.ID)
+		}
+		if tree, err = h.taskService.SubtaskTree(c.Request.Context(), query.Owner, ids); err != nil {
+			h.logger.Errorf("[Layer: task_handler] [Method: %s] Error: %v", method, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener tareas"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, toTaskListResponse(page, tree))
+}
+
+// limitParam lee el tamaño de página opcional; 0 deja el valor por defecto del servicio
+func (h *taskHandler) limitParam(c *gin.Context, method string) (int, bool) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 {
+		h.logger.Warnf("[Layer: task_handler] [Method: %s] limit inválido: '%s'", method, raw)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit debe ser un entero positivo"})
+		return 0, false
+	}
+	return limit, true
+}
+
+func toTaskListResponse(page *services.TaskPage, tree map[uint][]*models.Task) models.TaskListResponse {
+	resp := models.TaskListResponse{
+		Items:      make([]models.TaskResponse, 0, len(page.Tasks)),
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	}
+	for _, t := range page.Tasks {
+		resp.Items = append(resp.Items, toTaskTree(t, tree))
+	}
+	return resp
+}
+
+// GetTaskAsAdmin godoc
+// @Summary      Obtener cualquier tarea (admin)
+// @Description  Obtiene una tarea sin importar su dueño
+// @Tags         admin
+// @Produce      json
+// @Param        id path int true "ID de la tarea"
+// @Success      200 {object} models.TaskResponse
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/admin/tasks/{id} [get]
+func (h *taskHandler) GetTaskAsAdmin(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: GetTaskAsAdmin] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	task, err := h.taskService.GetTaskByIDAsAdmin(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: GetTaskAsAdmin] No encontrada: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
+		return
+	}
+	c.JSON(http.StatusOK, toTaskResponse(task))
+}
+
+// DeleteTaskAsAdmin godoc
+// @Summary      Eliminar cualquier tarea (admin)
+// @Description  Elimina una tarea sin importar su dueño
+// @Tags         admin
+// @Produce      json
+// @Param        id path int true "ID de la tarea"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/admin/tasks/{id} [delete]
+func (h *taskHandler) DeleteTaskAsAdmin(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: DeleteTaskAsAdmin] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if err := h.taskService.DeleteTaskAsAdmin(auditContext(c), id); err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: DeleteTaskAsAdmin] No encontrada: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tarea eliminada exitosamente"})
+}
+
+func toTaskResponse(t *models.Task) models.TaskResponse {
+	resp := models.TaskResponse{
+		ID:               t.ID,
+		Title:            t.Title,
+		Description:      t.Description,
+		Completed:        t.Completed,
+		CompletedAt:      t.CompletedAt,
+		Priority:         t.Priority,
+		DueAt:            t.DueAt,
+		Recurrence:       t.Recurrence,
+		NextOccurrenceID: t.NextOccurrenceID,
+		ProjectID:        t.ProjectID,
+		ParentID:         t.ParentID,
+		Owner:            t.Owner,
+		Version:          t.Version,
+		Tags:             tagNames(t),
+		Blocked:          t.Blocked,
+		CreatedAt:        t.CreatedAt,
+		UpdatedAt:        t.UpdatedAt,
+	}
+	if t.DeletedAt.Valid {
+		resp.DeletedAt = &t.DeletedAt.Time
+	}
+	return resp
+}
+
+// toTaskTree arma la respuesta con sus subtareas anidadas; tree agrupa las subtareas por ID del padre.
+func toTaskTree(t *models.Task, tree map[uint][]*models.Task) models.TaskResponse {
+	resp := toTaskResponse(t)
+	for _, child := range tree[t.ID] {
+		resp.Subtasks = append(resp.Subtasks, toTaskTree(child, tree))
+	}
+	return resp
+}
+
+// includesSubtasks lee ?include=; por ahora la única expansión es subtasks.
+func includesSubtasks(c *gin.Context) (bool, bool) {
+	raw := c.Query("include")
+	if raw == "" {
+		return false, true
+	}
+	for _, value := range strings.Split(raw, ",") {
+		if strings.TrimSpace(value) != "subtasks" {
+			return false, false
+		}
+	}
+	return true, true
+}
+
+func tagNames(t *models.Task) []string {
+	names := make([]string, 0, len(t.Tags))
+	for _, tag := range t.Tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// bindErrorMessage traduce los errores de binding: una fecha mal formada, un campo con otro tipo o un JSON
+// inválido tienen su propio mensaje; el del título es solo para la validación de binding:"required".
+func bindErrorMessage(err error) string {
+	var parseErr *time.ParseError
+	if errors.As(err, &parseErr) {
+		return "due_at debe tener formato RFC 3339"
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Sprintf("El campo %s tiene un tipo inválido", typeErr.Field)
+	}
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		for _, fieldErr := range validationErrs {
+			if fieldErr.Field() == "Title" {
+				return "El título no puede estar vacío"
+			}
+		}
+	}
+	return "El cuerpo no es un JSON válido"
+}
+
+// taskFieldError devuelve el mensaje para los errores de validación de campos del servicio.
+func taskFieldError(err error) (string, bool) {
+	switch {
+	case errors.Is(err, services.ErrTitleRequired):
+		return "El título no puede estar vacío", true
+	case errors.Is(err, services.ErrInvalidPriority):
+		return "priority debe ser low, medium, high o urgent", true
+	case errors.Is(err, services.ErrDescriptionTooLong):
+		return fmt.Sprintf("La descripción no puede superar %d caracteres", services.MaxDescriptionLength), true
+	case errors.Is(err, services.ErrInvalidTagName):
+		return fmt.Sprintf("Cada etiqueta debe tener entre 1 y %d caracteres", services.MaxTagNameLength), true
+	case errors.Is(err, services.ErrTooManyTags):
+		return fmt.Sprintf("Una tarea admite como máximo %d etiquetas", services.MaxTagsPerTask), true
+	case errors.Is(err, services.ErrProjectNotFound):
+		return "project_id no corresponde a ningún proyecto del usuario", true
+	case errors.Is(err, services.ErrProjectArchived):
+		return "El proyecto está archivado", true
+	case errors.Is(err, services.ErrParentNotFound):
+		return "parent_id no corresponde a ninguna tarea del usuario", true
+	case errors.Is(err, services.ErrTaskCycle):
+		return "Una tarea no puede ser subtarea de sí misma ni de sus subtareas", true
+	case errors.Is(err, services.ErrInvalidRecurrence):
+		return "recurrence debe ser una RRULE válida con FREQ, INTERVAL, BYDAY, COUNT o UNTIL", true
+	case errors.Is(err, services.ErrRecurrenceNeedsDueAt):
+		return "Una tarea recurrente necesita due_at", true
+	case errors.Is(err, services.ErrMaxDepthExceeded):
+		return fmt.Sprintf("La jerarquía de subtareas admite como máximo %d niveles", services.MaxTaskDepth), true
+	}
+	return "", false
+}
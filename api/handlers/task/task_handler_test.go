@@ -7,12 +7,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 func TestTaskHandler_CreateTask(t *testing.T) {
@@ -31,12 +34,65 @@ func TestTaskHandler_CreateTask(t *testing.T) {
 			requestBody: models.CreateTaskRequest{Title: "Nueva tarea"},
 			username:    "user1",
 			mockSetup: func(m *mockTaskService) {
-				m.On("CreateTask", mock.Anything, "Nueva tarea", "user1").
+				m.On("CreateTask", mock.Anything, "user1", services.TaskFields{Title: "Nueva tarea"}).
 					Return(&models.Task{Title: "Nueva tarea", Completed: false, Owner: "user1"}, nil)
 			},
 			expectedStatus: http.StatusCreated,
 			expectedBody:   `"title":"Nueva tarea"`,
 		},
+		{
+			testName:    "Crear tarea con fecha y prioridad",
+			requestBody: `{"title":"Informe","description":"**ya**","priority":"high","due_at":"2026-12-31T18:00:00-05:00"}`,
+			username:    "user1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("CreateTask", mock.Anything, "user1", mock.MatchedBy(func(f services.TaskFields) bool {
+					return f.Title == "Informe" && f.Description == "**ya**" && f.Priority == "high" &&
+						f.DueAt != nil && f.DueAt.Equal(time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC))
+				})).Return(&models.Task{Title: "Informe", Priority: "high", Owner: "user1"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"priority":"high"`,
+		},
+		{
+			testName:    "Prioridad inválida",
+			requestBody: models.CreateTaskRequest{Title: "Informe", Priority: "critical"},
+			username:    "user1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("CreateTask", mock.Anything, "user1", services.TaskFields{Title: "Informe", Priority: "critical"}).
+					Return((*models.Task)(nil), services.ErrInvalidPriority)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"priority debe ser low, medium, high o urgent"`,
+		},
+		{
+			testName:    "Crear tarea con etiquetas",
+			requestBody: models.CreateTaskRequest{Title: "Informe", Tags: []string{"trabajo", "urgente"}},
+			username:    "user1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("CreateTask", mock.Anything, "user1", services.TaskFields{Title: "Informe", Tags: []string{"trabajo", "urgente"}}).
+					Return(&models.Task{Title: "Informe", Owner: "user1", Tags: []models.Tag{{Name: "trabajo"}, {Name: "urgente"}}}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"tags":["trabajo","urgente"]`,
+		},
+		{
+			testName:    "Etiqueta inválida",
+			requestBody: models.CreateTaskRequest{Title: "Informe", Tags: []string{""}},
+			username:    "user1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("CreateTask", mock.Anything, "user1", services.TaskFields{Title: "Informe", Tags: []string{""}}).
+					Return((*models.Task)(nil), services.ErrInvalidTagName)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"Cada etiqueta debe tener entre 1 y 50 caracteres"`,
+		},
+		{
+			testName:       "Fecha mal formada",
+			requestBody:    `{"title":"Informe","due_at":"mañana"}`,
+			username:       "user1",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"due_at debe tener formato RFC 3339"`,
+		},
 		{
 			testName:       "Título vacío",
 			requestBody:    models.CreateTaskRequest{Title: ""},
@@ -51,7 +107,15 @@ func TestTaskHandler_CreateTask(t *testing.T) {
 			username:       "user1",
 			mockSetup:      nil,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `"error":"El título no puede estar vacío"`,
+			expectedBody:   `"error":"El cuerpo no es un JSON válido"`,
+		},
+		{
+			testName:       "Campo con otro tipo",
+			requestBody:    `{"title":"Informe","priority":1}`,
+			username:       "user1",
+			mockSetup:      nil,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"El campo priority tiene un tipo inválido"`,
 		},
 	}
 
@@ -110,7 +174,7 @@ func TestTaskHandler_UpdateTask(t *testing.T) {
 			requestBody: models.UpdateTaskRequest{Title: "Actualizada", Completed: true},
 			username:    "user1",
 			mockSetup: func(m *mockTaskService) {
-				m.On("UpdateTask", mock.Anything, 1, "Actualizada", true, "user1").
+				m.On("UpdateTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Actualizada", Completed: true}, uint(0)).
 					Return(&models.Task{Title: "Actualizada", Completed: true, Owner: "user1"}, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -140,7 +204,7 @@ func TestTaskHandler_UpdateTask(t *testing.T) {
 			requestBody: models.UpdateTaskRequest{Title: "Actualizada", Completed: true},
 			username:    "user1",
 			mockSetup: func(m *mockTaskService) {
-				m.On("UpdateTask", mock.Anything, 1, "Actualizada", true, "user1").
+				m.On("UpdateTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Actualizada", Completed: true}, uint(0)).
 					Return((*models.Task)(nil), errors.New("Tarea no encontrada"))
 			},
 			expectedStatus: http.StatusNotFound,
@@ -185,6 +249,292 @@ func TestTaskHandler_UpdateTask(t *testing.T) {
 	}
 }
 
+func TestTaskHandler_PatchTask(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	current := &models.Task{Title: "Tarea", Completed: false, Priority: "medium", Owner: "user1", Version: 1}
+	renamed := &models.Task{Title: "Otra", Completed: false, Priority: "medium", Owner: "user1", Version: 2}
+	testScenarios := []struct {
+		testName       string
+		id             string
+		contentType    string
+		requestBody    string
+		mockSetup      func(*mockTaskService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName:    "Merge patch solo completed",
+			id:          "1",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"completed":true}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil)
+				m.On("PatchTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Tarea", Completed: true, Priority: "medium"}, []string{"completed"}, uint(1)).
+					Return(&models.Task{Title: "Tarea", Completed: true, Priority: "medium", Owner: "user1"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"completed":true`,
+		},
+		{
+			testName:    "JSON Patch con test y replace",
+			id:          "1",
+			contentType: "application/json-patch+json",
+			requestBody: `[{"op":"test","path":"/title","value":"Tarea"},{"op":"replace","path":"/title","value":"Nueva"}]`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil)
+				m.On("PatchTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Nueva", Priority: "medium"}, []string{"title"}, uint(1)).
+					Return(&models.Task{Title: "Nueva", Owner: "user1"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"title":"Nueva"`,
+		},
+		{
+			testName:    "Parche sin cambios no escribe",
+			id:          "1",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"completed":false}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"completed":false`,
+		},
+		{
+			testName:    "Merge patch con fecha límite",
+			id:          "1",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"due_at":"2026-12-31T18:00:00Z","priority":"high"}`,
+			mockSetup: func(m *mockTaskService) {
+				due := time.Date(2026, 12, 31, 18, 0, 0, 0, time.UTC)
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil)
+				m.On("PatchTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Tarea", Priority: "high", DueAt: &due}, []string{"priority", "due_at"}, uint(1)).
+					Return(&models.Task{Title: "Tarea", Priority: "high", DueAt: &due, Owner: "user1"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"due_at":"2026-12-31T18:00:00Z"`,
+		},
+		{
+			testName:    "JSON Patch agrega una etiqueta",
+			id:          "1",
+			contentType: "application/json-patch+json",
+			requestBody: `[{"op":"add","path":"/tags/-","value":"casa"}]`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil)
+				m.On("PatchTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Tarea", Priority: "medium", Tags: []string{"casa"}}, []string{"tags"}, uint(1)).
+					Return(&models.Task{Title: "Tarea", Owner: "user1", Tags: []models.Tag{{Name: "casa"}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"tags":["casa"]`,
+		},
+		{
+			testName:    "Merge patch quita las etiquetas",
+			id:          "2",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"tags":null}`,
+			mockSetup: func(m *mockTaskService) {
+				tagged := &models.Task{Title: "Tarea", Priority: "medium", Owner: "user1", Version: 1, Tags: []models.Tag{{Name: "casa"}}}
+				m.On("GetTaskByID", mock.Anything, 2, "user1").Return(tagged, nil)
+				m.On("PatchTask", mock.Anything, 2, "user1", services.TaskFields{Title: "Tarea", Priority: "medium", Tags: []string{}}, []string{"tags"}, uint(1)).
+					Return(&models.Task{Title: "Tarea", Owner: "user1"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"tags":[]`,
+		},
+		{
+			testName:    "Reordenar etiquetas no escribe",
+			id:          "3",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"tags":["b","a"]}`,
+			mockSetup: func(m *mockTaskService) {
+				tagged := &models.Task{Title: "Tarea", Priority: "medium", Owner: "user1", Version: 1, Tags: []models.Tag{{Name: "a"}, {Name: "b"}}}
+				m.On("GetTaskByID", mock.Anything, 3, "user1").Return(tagged, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"tags":["a","b"]`,
+		},
+		{
+			testName:    "Fecha límite mal formada",
+			id:          "1",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"due_at":"pronto"}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"error":"due_at debe tener formato RFC 3339"`,
+		},
+		{
+			testName:    "Operación test fallida",
+			id:          "1",
+			contentType: "application/json-patch+json",
+			requestBody: `[{"op":"test","path":"/completed","value":true}]`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"error":"La operación test no se cumple"`,
+		},
+		{
+			testName:    "JSON Patch mal formado",
+			id:          "1",
+			contentType: "application/json-patch+json",
+			requestBody: `{"completed":true}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"JSON Patch inválido"`,
+		},
+		{
+			testName:    "Campo no editable",
+			id:          "1",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"owner":"user2"}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"error":"Solo se pueden modificar los campos title, description, completed, priority, due_at, recurrence, project_id, parent_id y tags"`,
+		},
+		{
+			testName:    "Eliminar el título",
+			id:          "1",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"title":null}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"error":"El campo title no puede eliminarse"`,
+		},
+		{
+			testName:    "Tipo inválido",
+			id:          "1",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"completed":"si"}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"error":"Tipo inválido para el campo completed"`,
+		},
+		{
+			testName:    "Título vacío",
+			id:          "1",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"title":""}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil)
+				m.On("PatchTask", mock.Anything, 1, "user1", services.TaskFields{Title: "", Priority: "medium"}, []string{"title"}, uint(1)).
+					Return((*models.Task)(nil), services.ErrTitleRequired)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"error":"El título no puede estar vacío"`,
+		},
+		{
+			testName:    "Otra escritura se adelanta y el parche se vuelve a aplicar",
+			id:          "1",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"completed":true}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil).Once()
+				m.On("PatchTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Tarea", Completed: true, Priority: "medium"}, []string{"completed"}, uint(1)).
+					Return((*models.Task)(nil), services.ErrVersionConflict).Once()
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(renamed, nil).Once()
+				m.On("PatchTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Otra", Completed: true, Priority: "medium"}, []string{"completed"}, uint(2)).
+					Return(&models.Task{Title: "Otra", Completed: true, Priority: "medium", Owner: "user1", Version: 3}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"title":"Otra"`,
+		},
+		{
+			testName:    "La operación test se evalúa sobre la versión vigente",
+			id:          "1",
+			contentType: "application/json-patch+json",
+			requestBody: `[{"op":"test","path":"/title","value":"Tarea"},{"op":"replace","path":"/completed","value":true}]`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil).Once()
+				m.On("PatchTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Tarea", Completed: true, Priority: "medium"}, []string{"completed"}, uint(1)).
+					Return((*models.Task)(nil), services.ErrVersionConflict).Once()
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(renamed, nil).Once()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"error":"La operación test no se cumple"`,
+		},
+		{
+			testName:    "La tarea sigue cambiando",
+			id:          "1",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"completed":true}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil).Times(3)
+				m.On("PatchTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Tarea", Completed: true, Priority: "medium"}, []string{"completed"}, uint(1)).
+					Return((*models.Task)(nil), services.ErrVersionConflict).Times(3)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"error":"La tarea cambió mientras se aplicaba el parche; vuelve a intentarlo"`,
+		},
+		{
+			testName:    "Content-Type no soportado",
+			id:          "1",
+			contentType: "text/plain",
+			requestBody: `completed=true`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(current, nil)
+			},
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedBody:   `"error":"Content-Type debe ser application/merge-patch+json o application/json-patch+json"`,
+		},
+		{
+			testName:    "Tarea no encontrada",
+			id:          "2",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"completed":true}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 2, "user1").Return((*models.Task)(nil), services.ErrTaskNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"Tarea no encontrada"`,
+		},
+		{
+			testName:       "ID inválido",
+			id:             "abc",
+			contentType:    "application/merge-patch+json",
+			requestBody:    `{"completed":true}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"ID inválido"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockTaskService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			logger := logrus.New()
+			handler := NewTaskHandler(mockService, logger)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.PATCH("/tasks/:id", handler.PatchTask)
+
+			req, _ := http.NewRequest(http.MethodPatch, "/tasks/"+tt.id, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestTaskHandler_GetTask(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -257,8 +607,10 @@ func TestTaskHandler_GetTask(t *testing.T) {
 func TestTaskHandler_GetTasks(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
+	completed := true
 	testScenarios := []struct {
 		testName       string
+		query          string
 		mockSetup      func(*mockTaskService)
 		username       string
 		expectedStatus int
@@ -268,21 +620,124 @@ func TestTaskHandler_GetTasks(t *testing.T) {
 			testName: "Obtener lista de tareas",
 			username: "user1",
 			mockSetup: func(m *mockTaskService) {
-				m.On("GetTasksByUser", mock.Anything, "user1").
-					Return([]*models.Task{
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1"}).
+					Return(&services.TaskPage{Tasks: []*models.Task{
 						{Title: "Tarea 1", Completed: false, Owner: "user1"},
 						{Title: "Tarea 2", Completed: true, Owner: "user1"},
-					}, nil)
+					}, NextCursor: "cursor123", Total: 7}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"next_cursor":"cursor123","total":7`,
+		},
+		{
+			testName: "Filtros, orden y cursor",
+			query:    "?limit=5&after=cursor123&completed=true&q=pan&sort=-updated_at",
+			username: "user1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1", Limit: 5, After: "cursor123", Completed: &completed, Search: "pan", Sort: "-updated_at"}).
+					Return(&services.TaskPage{Tasks: []*models.Task{{Title: "Comprar pan", Completed: true, Owner: "user1"}}, Total: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"title":"Comprar pan"`,
+		},
+		{
+			testName: "Página vacía",
+			username: "user1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1"}).Return(&services.TaskPage{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"items":[],"next_cursor":"","total":0}`,
+		},
+		{
+			testName: "Filtros de planificación",
+			query:    "?priority=urgent&overdue=true&due_before=2026-12-31T00:00:00Z",
+			username: "user1",
+			mockSetup: func(m *mockTaskService) {
+				dueBefore := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+				overdue := true
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1", Priority: "urgent", Overdue: &overdue, DueBefore: &dueBefore}).
+					Return(&services.TaskPage{Tasks: []*models.Task{{Title: "Vencida", Priority: "urgent", Owner: "user1"}}, Total: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"title":"Vencida"`,
+		},
+		{
+			testName: "Filtro por etiquetas",
+			query:    "?tag=trabajo&tag=urgente&tag_mode=any",
+			username: "user1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1", Tags: []string{"trabajo", "urgente"}, TagMode: "any"}).
+					Return(&services.TaskPage{Tasks: []*models.Task{{Title: "Informe", Owner: "user1", Tags: []models.Tag{{Name: "trabajo"}}}}, Total: 1}, nil)
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   `"title":"Tarea 1"`,
+			expectedBody:   `"tags":["trabajo"]`,
+		},
+		{
+			testName: "tag_mode inválido",
+			query:    "?tag=trabajo&tag_mode=some",
+			username: "user1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1", Tags: []string{"trabajo"}, TagMode: "some"}).
+					Return((*services.TaskPage)(nil), services.ErrInvalidTagMode)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"tag_mode debe ser all o any"`,
+		},
+		{
+			testName:       "due_before inválido",
+			query:          "?due_before=ayer",
+			username:       "user1",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"due_before debe tener formato RFC 3339"`,
+		},
+		{
+			testName:       "overdue inválido",
+			query:          "?overdue=quizas",
+			username:       "user1",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"overdue debe ser true o false"`,
+		},
+		{
+			testName:       "Limit inválido",
+			query:          "?limit=0",
+			username:       "user1",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"limit debe ser un entero positivo"`,
+		},
+		{
+			testName:       "Completed inválido",
+			query:          "?completed=quizas",
+			username:       "user1",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"completed debe ser true o false"`,
+		},
+		{
+			testName: "Orden inválido",
+			query:    "?sort=owner",
+			username: "user1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1", Sort: "owner"}).Return((*services.TaskPage)(nil), services.ErrInvalidSort)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"sort debe ser created_at, updated_at o title (prefijo - para descendente)"`,
+		},
+		{
+			testName: "Cursor inválido",
+			query:    "?after=basura",
+			username: "user1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1", After: "basura"}).Return((*services.TaskPage)(nil), services.ErrInvalidCursor)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"Cursor inválido"`,
 		},
 		{
 			testName: "Error al obtener tareas",
 			username: "user1",
 			mockSetup: func(m *mockTaskService) {
-				m.On("GetTasksByUser", mock.Anything, "user1").
-					Return([]*models.Task{}, errors.New("Error al obtener tareas"))
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1"}).
+					Return((*services.TaskPage)(nil), errors.New("Error al obtener tareas"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   `"error":"Error al obtener tareas"`,
@@ -304,53 +759,1294 @@ func TestTaskHandler_GetTasks(t *testing.T) {
 			})
 			router.GET("/tasks", handler.GetTasks)
 
-			req, _ := http.NewRequest(http.MethodGet, "/tasks", nil)
+			req, _ := http.NewRequest(http.MethodGet, "/tasks"+tt.query, nil)
 			w := httptest.NewRecorder()
 
 			router.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
 		})
 	}
 }
 
-func TestTaskHandler_DeleteTask(t *testing.T) {
+func TestTaskHandler_ProjectRoutes(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
+	projectID, inbox := uint(3), uint(0)
 	testScenarios := []struct {
 		testName       string
-		id             string
+		method         string
+		path           string
+		requestBody    string
 		mockSetup      func(*mockTaskService)
-		username       string
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
-			testName: "Eliminar tarea exitosa",
-			id:       "1",
-			username: "user1",
+			testName: "Listar tareas del proyecto",
+			method:   http.MethodGet,
+			path:     "/projects/3/tasks?completed=true",
 			mockSetup: func(m *mockTaskService) {
-				m.On("DeleteTask", mock.Anything, 1, "user1").
-					Return(nil)
+				completed := true
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1", ProjectID: &projectID, Completed: &completed}).
+					Return(&services.TaskPage{Tasks: []*models.Task{{Title: "Informe", ProjectID: &projectID, Owner: "user1"}}, Total: 1}, nil)
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   `"message":"Tarea eliminada exitosamente"`,
+			expectedBody:   `"project_id":3`,
 		},
 		{
-			testName:       "ID inválido",
-			id:             "abc",
-			username:       "user1",
-			mockSetup:      nil,
+			testName: "Proyecto ajeno",
+			method:   http.MethodGet,
+			path:     "/projects/9/tasks",
+			mockSetup: func(m *mockTaskService) {
+				other := uint(9)
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1", ProjectID: &other}).
+					Return((*services.TaskPage)(nil), services.ErrProjectNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"Proyecto no encontrado"`,
+		},
+		{
+			testName:       "ID de proyecto inválido",
+			method:         http.MethodGet,
+			path:           "/projects/abc/tasks",
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   `"error":"ID inválido"`,
 		},
 		{
-			testName: "Tarea no encontrada",
-			id:       "1",
+			testName: "Bandeja de entrada",
+			method:   http.MethodGet,
+			path:     "/tasks?project_id=inbox",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1", ProjectID: &inbox}).
+					Return(&services.TaskPage{Tasks: []*models.Task{{Title: "Suelta", Owner: "user1"}}, Total: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"project_id":null`,
+		},
+		{
+			testName:       "project_id inválido",
+			method:         http.MethodGet,
+			path:           "/tasks?project_id=todos",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"project_id debe ser un ID de proyecto o inbox"`,
+		},
+		{
+			testName:    "Crear tarea en el proyecto",
+			method:      http.MethodPost,
+			path:        "/projects/3/tasks",
+			requestBody: `{"title":"Informe","project_id":7}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("CreateTask", mock.Anything, "user1", services.TaskFields{Title: "Informe", ProjectID: &projectID}).
+					Return(&models.Task{Title: "Informe", ProjectID: &projectID, Owner: "user1"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"project_id":3`,
+		},
+		{
+			testName:    "Crear en proyecto archivado",
+			method:      http.MethodPost,
+			path:        "/projects/3/tasks",
+			requestBody: `{"title":"Informe"}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("CreateTask", mock.Anything, "user1", services.TaskFields{Title: "Informe", ProjectID: &projectID}).
+					Return((*models.Task)(nil), services.ErrProjectArchived)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"error":"El proyecto está archivado"`,
+		},
+		{
+			testName:    "project_id ajeno en el cuerpo",
+			method:      http.MethodPost,
+			path:        "/tasks",
+			requestBody: `{"title":"Informe","project_id":9}`,
+			mockSetup: func(m *mockTaskService) {
+				other := uint(9)
+				m.On("CreateTask", mock.Anything, "user1", services.TaskFields{Title: "Informe", ProjectID: &other}).
+					Return((*models.Task)(nil), services.ErrProjectNotFound)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"project_id no corresponde a ningún proyecto del usuario"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockTaskService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewTaskHandler(mockService, logrus.New())
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.GET("/tasks", handler.GetTasks)
+			router.POST("/tasks", handler.CreateTask)
+			router.GET("/projects/:id/tasks", handler.GetProjectTasks)
+			router.POST("/projects/:id/tasks", handler.CreateProjectTask)
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_Subtasks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	parentID, root := uint(1), uint(0)
+	testScenarios := []struct {
+		testName       string
+		method         string
+		path           string
+		requestBody    string
+		mockSetup      func(*mockTaskService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Listar subtareas",
+			method:   http.MethodGet,
+			path:     "/tasks/1/subtasks",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1", ParentID: &parentID}).
+					Return(&services.TaskPage{Tasks: []*models.Task{{Title: "Hija", ParentID: &parentID, Owner: "user1"}}, Total: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"parent_id":1`,
+		},
+		{
+			testName: "Subtareas de tarea ajena",
+			method:   http.MethodGet,
+			path:     "/tasks/1/subtasks",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1", ParentID: &parentID}).
+					Return((*services.TaskPage)(nil), services.ErrTaskNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"Tarea no encontrada"`,
+		},
+		{
+			testName: "Solo tareas raíz con subtareas anidadas",
+			method:   http.MethodGet,
+			path:     "/tasks?parent_id=root&include=subtasks",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user1", ParentID: &root}).
+					Return(&services.TaskPage{Tasks: []*models.Task{{Model: gorm.Model{ID: 1}, Title: "Raíz", Owner: "user1"}}, Total: 1}, nil)
+				m.On("SubtaskTree", mock.Anything, "user1", []uint{1}).
+					Return(map[uint][]*models.Task{1: {{Model: gorm.Model{ID: 2}, Title: "Hija", ParentID: &parentID, Owner: "user1"}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"subtasks":[{"id":2,"title":"Hija"`,
+		},
+		{
+			testName:       "parent_id inválido",
+			method:         http.MethodGet,
+			path:           "/tasks?parent_id=todas",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"parent_id debe ser un ID de tarea o root"`,
+		},
+		{
+			testName:       "include desconocido",
+			method:         http.MethodGet,
+			path:           "/tasks/1?include=tags",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"include solo admite subtasks"`,
+		},
+		{
+			testName: "Tarea con subtareas",
+			method:   http.MethodGet,
+			path:     "/tasks/1?include=subtasks",
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(&models.Task{Model: gorm.Model{ID: 1}, Title: "Raíz", Owner: "user1"}, nil)
+				m.On("SubtaskTree", mock.Anything, "user1", []uint{1}).
+					Return(map[uint][]*models.Task{1: {{Model: gorm.Model{ID: 2}, Title: "Hija", ParentID: &parentID, Owner: "user1"}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"subtasks":[{"id":2,"title":"Hija"`,
+		},
+		{
+			testName:    "Crear subtarea",
+			method:      http.MethodPost,
+			path:        "/tasks",
+			requestBody: `{"title":"Hija","parent_id":1}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("CreateTask", mock.Anything, "user1", services.TaskFields{Title: "Hija", ParentID: &parentID}).
+					Return(&models.Task{Title: "Hija", ParentID: &parentID, Owner: "user1"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"parent_id":1`,
+		},
+		{
+			testName:    "Padre inexistente",
+			method:      http.MethodPost,
+			path:        "/tasks",
+			requestBody: `{"title":"Hija","parent_id":1}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("CreateTask", mock.Anything, "user1", services.TaskFields{Title: "Hija", ParentID: &parentID}).
+					Return((*models.Task)(nil), services.ErrParentNotFound)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"parent_id no corresponde a ninguna tarea del usuario"`,
+		},
+		{
+			testName:    "Jerarquía demasiado profunda",
+			method:      http.MethodPost,
+			path:        "/tasks",
+			requestBody: `{"title":"Hija","parent_id":1}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("CreateTask", mock.Anything, "user1", services.TaskFields{Title: "Hija", ParentID: &parentID}).
+					Return((*models.Task)(nil), services.ErrMaxDepthExceeded)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"La jerarquía de subtareas admite como máximo 5 niveles"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockTaskService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewTaskHandler(mockService, logrus.New())
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.GET("/tasks", handler.GetTasks)
+			router.GET("/tasks/:id", handler.GetTask)
+			router.POST("/tasks", handler.CreateTask)
+			router.GET("/tasks/:id/subtasks", handler.GetSubtasks)
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_Blockers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		method         string
+		path           string
+		requestBody    string
+		mockSetup      func(*mockTaskService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Listar bloqueos",
+			method:   http.MethodGet,
+			path:     "/tasks/2/blockers",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListBlockers", mock.Anything, 2, "user1").Return([]*models.Task{{Model: gorm.Model{ID: 1}, Title: "Diseño", Owner: "user1"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"id":1,"title":"Diseño"`,
+		},
+		{
+			testName:    "Agregar bloqueo",
+			method:      http.MethodPost,
+			path:        "/tasks/2/blockers",
+			requestBody: `{"blocker_id":1}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("AddBlocker", mock.Anything, 2, "user1", uint(1)).Return(&models.Task{Model: gorm.Model{ID: 2}, Title: "Construcción", Owner: "user1", Blocked: true}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"blocked":true`,
+		},
+		{
+			testName:       "Sin blocker_id",
+			method:         http.MethodPost,
+			path:           "/tasks/2/blockers",
+			requestBody:    `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"blocker_id es obligatorio"`,
+		},
+		{
+			testName:    "Bloqueo de otro usuario",
+			method:      http.MethodPost,
+			path:        "/tasks/2/blockers",
+			requestBody: `{"blocker_id":9}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("AddBlocker", mock.Anything, 2, "user1", uint(9)).Return((*models.Task)(nil), services.ErrBlockerNotFound)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"blocker_id no corresponde a ninguna tarea del usuario"`,
+		},
+		{
+			testName:    "Ciclo",
+			method:      http.MethodPost,
+			path:        "/tasks/1/blockers",
+			requestBody: `{"blocker_id":2}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("AddBlocker", mock.Anything, 1, "user1", uint(2)).Return((*models.Task)(nil), services.ErrDependencyCycle)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"error":"La dependencia formaría un ciclo"`,
+		},
+		{
+			testName: "Quitar bloqueo inexistente",
+			method:   http.MethodDelete,
+			path:     "/tasks/2/blockers/3",
+			mockSetup: func(m *mockTaskService) {
+				m.On("RemoveBlocker", mock.Anything, 2, "user1", uint(3)).Return((*models.Task)(nil), services.ErrDependencyNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"La tarea no está bloqueada por esa tarea"`,
+		},
+		{
+			testName: "Quitar bloqueo",
+			method:   http.MethodDelete,
+			path:     "/tasks/2/blockers/1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("RemoveBlocker", mock.Anything, 2, "user1", uint(1)).Return(&models.Task{Model: gorm.Model{ID: 2}, Title: "Construcción", Owner: "user1"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"blocked":false`,
+		},
+		{
+			testName:    "Completar tarea bloqueada",
+			method:      http.MethodPut,
+			path:        "/tasks/2",
+			requestBody: `{"title":"Construcción","completed":true}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("UpdateTask", mock.Anything, 2, "user1", services.TaskFields{Title: "Construcción", Completed: true}, uint(0)).
+					Return((*models.Task)(nil), services.ErrTaskBlocked)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"error":"La tarea tiene bloqueos pendientes"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockTaskService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewTaskHandler(mockService, logrus.New())
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.PUT("/tasks/:id", handler.UpdateTask)
+			router.GET("/tasks/:id/blockers", handler.GetBlockers)
+			router.POST("/tasks/:id/blockers", handler.AddBlocker)
+			router.DELETE("/tasks/:id/blockers/:blocker_id", handler.RemoveBlocker)
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_Recurrence(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2026, time.January, 5, 9, 0, 0, 0, time.UTC)
+	testScenarios := []struct {
+		testName       string
+		method         string
+		path           string
+		requestBody    string
+		mockSetup      func(*mockTaskService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName:    "Previsualizar regla",
+			method:      http.MethodPost,
+			path:        "/tasks/recurrence/preview",
+			requestBody: `{"rule":"FREQ=WEEKLY","start":"2026-01-05T09:00:00Z","count":2}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("PreviewRecurrence", mock.Anything, "FREQ=WEEKLY", start, 2).
+					Return(&services.RecurrencePreview{Rule: "FREQ=WEEKLY", Occurrences: []time.Time{start, start.AddDate(0, 0, 7)}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"occurrences":["2026-01-05T09:00:00Z","2026-01-12T09:00:00Z"]`,
+		},
+		{
+			testName:       "Sin regla",
+			method:         http.MethodPost,
+			path:           "/tasks/recurrence/preview",
+			requestBody:    `{"count":2}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"rule es obligatorio"`,
+		},
+		{
+			testName:    "Regla inválida",
+			method:      http.MethodPost,
+			path:        "/tasks/recurrence/preview",
+			requestBody: `{"rule":"FREQ=HOURLY","start":"2026-01-05T09:00:00Z"}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("PreviewRecurrence", mock.Anything, "FREQ=HOURLY", start, 0).
+					Return((*services.RecurrencePreview)(nil), services.ErrInvalidRecurrence)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"recurrence debe ser una RRULE válida con FREQ, INTERVAL, BYDAY, COUNT o UNTIL"`,
+		},
+		{
+			testName:    "Tarea recurrente sin due_at",
+			method:      http.MethodPost,
+			path:        "/tasks",
+			requestBody: `{"title":"Regar","recurrence":"FREQ=DAILY"}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("CreateTask", mock.Anything, "user1", services.TaskFields{Title: "Regar", Recurrence: "FREQ=DAILY"}).
+					Return((*models.Task)(nil), services.ErrRecurrenceNeedsDueAt)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"Una tarea recurrente necesita due_at"`,
+		},
+		{
+			testName:    "Completar ocurrencia",
+			method:      http.MethodPut,
+			path:        "/tasks/1",
+			requestBody: `{"title":"Regar","completed":true,"recurrence":"FREQ=DAILY","due_at":"2026-01-05T09:00:00Z"}`,
+			mockSetup: func(m *mockTaskService) {
+				next := uint(2)
+				m.On("UpdateTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Regar", Completed: true, Recurrence: "FREQ=DAILY", DueAt: &start}, uint(0)).
+					Return(&models.Task{Model: gorm.Model{ID: 1}, Title: "Regar", Completed: true, Recurrence: "FREQ=DAILY", DueAt: &start, NextOccurrenceID: &next, Owner: "user1"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"next_occurrence_id":2`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockTaskService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewTaskHandler(mockService, logrus.New())
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.POST("/tasks", handler.CreateTask)
+			router.PUT("/tasks/:id", handler.UpdateTask)
+			router.POST("/tasks/recurrence/preview", handler.PreviewRecurrence)
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_BlockerInvalidatesETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(mockTaskService)
+	mockService.On("GetTaskByID", mock.Anything, 2, "user1").Return(&models.Task{Model: gorm.Model{ID: 2}, Title: "Construcción", Owner: "user1", Version: 1}, nil).Once()
+	mockService.On("AddBlocker", mock.Anything, 2, "user1", uint(1)).Return(&models.Task{Model: gorm.Model{ID: 2}, Title: "Construcción", Owner: "user1", Version: 2, Blocked: true}, nil).Once()
+	mockService.On("GetTaskByID", mock.Anything, 2, "user1").Return(&models.Task{Model: gorm.Model{ID: 2}, Title: "Construcción", Owner: "user1", Version: 2, Blocked: true}, nil).Once()
+	handler := NewTaskHandler(mockService, logrus.New())
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("username", "user1")
+	})
+	router.GET("/tasks/:id", handler.GetTask)
+	router.POST("/tasks/:id/blockers", handler.AddBlocker)
+	get := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/tasks/2", nil)
+		req.Header.Set("If-None-Match", `"1"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusNotModified, get().Code)
+
+	req, _ := http.NewRequest(http.MethodPost, "/tasks/2/blockers", bytes.NewBufferString(`{"blocker_id":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	// Caso: la copia guardada antes del bloqueo ya no sirve
+	w = get()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), `"blocked":true`)
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_Preconditions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	task := &models.Task{Title: "Tarea", Owner: "user1", Version: 3}
+	testScenarios := []struct {
+		testName       string
+		method         string
+		header         string
+		value          string
+		requestBody    string
+		mockSetup      func(*mockTaskService)
+		expectedStatus int
+		expectedETag   string
+		expectedBody   string
+	}{
+		{
+			testName: "GET devuelve ETag",
+			method:   http.MethodGet,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(task, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"3"`,
+			expectedBody:   `"version":3`,
+		},
+		{
+			testName: "GET con If-None-Match vigente",
+			method:   http.MethodGet,
+			header:   "If-None-Match",
+			value:    `"2", W/"3"`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(task, nil)
+			},
+			expectedStatus: http.StatusNotModified,
+			expectedETag:   `"3"`,
+		},
+		{
+			testName: "GET con If-None-Match viejo",
+			method:   http.MethodGet,
+			header:   "If-None-Match",
+			value:    `"2"`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(task, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"3"`,
+			expectedBody:   `"title":"Tarea"`,
+		},
+		{
+			testName:    "PUT con If-Match vigente",
+			method:      http.MethodPut,
+			header:      "If-Match",
+			value:       `"3"`,
+			requestBody: `{"title":"Nueva","completed":true}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("UpdateTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Nueva", Completed: true}, uint(3)).
+					Return(&models.Task{Title: "Nueva", Completed: true, Owner: "user1", Version: 4}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4"`,
+			expectedBody:   `"version":4`,
+		},
+		{
+			testName:    "PUT con If-Match viejo",
+			method:      http.MethodPut,
+			header:      "If-Match",
+			value:       `"2"`,
+			requestBody: `{"title":"Nueva","completed":true}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("UpdateTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Nueva", Completed: true}, uint(2)).
+					Return((*models.Task)(nil), services.ErrVersionConflict)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `"error":"La tarea cambió desde la versión indicada en If-Match"`,
+		},
+		{
+			testName:       "PUT con If-Match débil",
+			method:         http.MethodPut,
+			header:         "If-Match",
+			value:          `W/"3"`,
+			requestBody:    `{"title":"Nueva","completed":true}`,
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `"error":"La tarea cambió desde la versión indicada en If-Match"`,
+		},
+		{
+			testName:    "PUT con lista If-Match que incluye la versión actual",
+			method:      http.MethodPut,
+			header:      "If-Match",
+			value:       `"2", "3"`,
+			requestBody: `{"title":"Nueva","completed":true}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(task, nil)
+				m.On("UpdateTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Nueva", Completed: true}, uint(3)).
+					Return(&models.Task{Title: "Nueva", Completed: true, Owner: "user1", Version: 4}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4"`,
+		},
+		{
+			testName:    "PUT con lista If-Match sin la versión actual",
+			method:      http.MethodPut,
+			header:      "If-Match",
+			value:       `"1", "2"`,
+			requestBody: `{"title":"Nueva","completed":true}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(task, nil)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `"error":"La tarea cambió desde la versión indicada en If-Match"`,
+		},
+		{
+			testName: "DELETE con etiqueta débil y fuerte",
+			method:   http.MethodDelete,
+			header:   "If-Match",
+			value:    `W/"3", "3"`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("DeleteTask", mock.Anything, 1, "user1", uint(3)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"message":"Tarea eliminada exitosamente"`,
+		},
+		{
+			testName:    "PATCH con lista If-Match",
+			method:      http.MethodPatch,
+			header:      "If-Match",
+			value:       `"1", "3"`,
+			requestBody: `{"completed":true}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(task, nil)
+				m.On("PatchTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Tarea", Completed: true}, []string{"completed"}, uint(3)).
+					Return(&models.Task{Title: "Tarea", Completed: true, Owner: "user1", Version: 4}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4"`,
+		},
+		{
+			testName:    "PATCH con If-Match viejo",
+			method:      http.MethodPatch,
+			header:      "If-Match",
+			value:       `"2"`,
+			requestBody: `{"completed":true}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(task, nil)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `"error":"La tarea cambió desde la versión indicada en If-Match"`,
+		},
+		{
+			testName:    "PATCH con cambio concurrente",
+			method:      http.MethodPatch,
+			header:      "If-Match",
+			value:       `"3"`,
+			requestBody: `{"completed":true}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByID", mock.Anything, 1, "user1").Return(task, nil)
+				m.On("PatchTask", mock.Anything, 1, "user1", services.TaskFields{Title: "Tarea", Completed: true}, []string{"completed"}, uint(3)).
+					Return((*models.Task)(nil), services.ErrVersionConflict)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `"error":"La tarea cambió desde la versión indicada en If-Match"`,
+		},
+		{
+			testName: "DELETE con If-Match viejo",
+			method:   http.MethodDelete,
+			header:   "If-Match",
+			value:    `"2"`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("DeleteTask", mock.Anything, 1, "user1", uint(2)).Return(services.ErrVersionConflict)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `"error":"La tarea cambió desde la versión indicada en If-Match"`,
+		},
+		{
+			testName: "DELETE con If-Match *",
+			method:   http.MethodDelete,
+			header:   "If-Match",
+			value:    `*`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("DeleteTask", mock.Anything, 1, "user1", uint(0)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"message":"Tarea eliminada exitosamente"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockTaskService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			logger := logrus.New()
+			handler := NewTaskHandler(mockService, logger)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.GET("/tasks/:id", handler.GetTask)
+			router.PUT("/tasks/:id", handler.UpdateTask)
+			router.PATCH("/tasks/:id", handler.PatchTask)
+			router.DELETE("/tasks/:id", handler.DeleteTask)
+
+			req, _ := http.NewRequest(tt.method, "/tasks/1", bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedETag, w.Header().Get("ETag"))
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			if tt.expectedStatus == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_Trash(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	deletedAt := time.Date(2026, time.January, 5, 9, 0, 0, 0, time.UTC)
+
+	testScenarios := []struct {
+		testName       string
+		method         string
+		path           string
+		mockSetup      func(*mockTaskService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Listar papelera",
+			method:   http.MethodGet,
+			path:     "/tasks/trash",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTrash", mock.Anything, "user1").Return([]*models.Task{
+					{Model: gorm.Model{ID: 1, DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}}, Title: "Vieja", Owner: "user1"},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"deleted_at":"2026-01-05T09:00:00Z"`,
+		},
+		{
+			testName: "Restaurar tarea",
+			method:   http.MethodPost,
+			path:     "/tasks/1/restore",
+			mockSetup: func(m *mockTaskService) {
+				m.On("RestoreTask", mock.Anything, 1, "user1").Return(&models.Task{Model: gorm.Model{ID: 1}, Title: "Vieja", Owner: "user1", Version: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"title":"Vieja"`,
+		},
+		{
+			testName: "Restaurar tarea fuera de la papelera",
+			method:   http.MethodPost,
+			path:     "/tasks/1/restore",
+			mockSetup: func(m *mockTaskService) {
+				m.On("RestoreTask", mock.Anything, 1, "user1").Return((*models.Task)(nil), services.ErrTaskNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"La tarea no está en la papelera"`,
+		},
+		{
+			testName: "Restaurar subtarea con el padre en la papelera",
+			method:   http.MethodPost,
+			path:     "/tasks/2/restore",
+			mockSetup: func(m *mockTaskService) {
+				m.On("RestoreTask", mock.Anything, 2, "user1").Return((*models.Task)(nil), services.ErrParentTrashed)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"error":"La tarea padre está en la papelera; restáurala primero"`,
+		},
+		{
+			testName: "Borrar definitivamente",
+			method:   http.MethodDelete,
+			path:     "/tasks/1?permanent=true",
+			mockSetup: func(m *mockTaskService) {
+				m.On("PurgeTask", mock.Anything, 1, "user1", uint(0)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"message":"Tarea eliminada permanentemente"`,
+		},
+		{
+			testName:       "permanent inválido",
+			method:         http.MethodDelete,
+			path:           "/tasks/1?permanent=quizas",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"permanent debe ser true o false"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockTaskService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewTaskHandler(mockService, logrus.New())
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.GET("/tasks/trash", handler.GetTrash)
+			router.POST("/tasks/:id/restore", handler.RestoreTask)
+			router.DELETE("/tasks/:id", handler.DeleteTask)
+
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_History(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		path           string
+		mockSetup      func(*mockTaskService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Historial",
+			path:     "/tasks/1/history?limit=1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTaskHistory", mock.Anything, 1, "user1", 1, "").Return(&services.TaskHistoryPage{
+					Events: []*models.TaskEvent{{ID: 7, TaskID: 1, Version: 2, Actor: "user1", Action: models.TaskEventUpdated,
+						Field: "title", OldValue: `"Vieja"`, NewValue: `"Nueva"`, RequestID: "req-1"}},
+					NextCursor: "abc",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"field":"title","old_value":"Vieja","new_value":"Nueva","request_id":"req-1"`,
+		},
+		{
+			testName: "Evento sin campo",
+			path:     "/tasks/1/history",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTaskHistory", mock.Anything, 1, "user1", 0, "").Return(&services.TaskHistoryPage{
+					Events: []*models.TaskEvent{{ID: 1, TaskID: 1, Version: 1, Actor: "user1", Action: models.TaskEventCreated}},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"action":"created","created_at"`,
+		},
+		{
+			testName: "Tarea ajena",
+			path:     "/tasks/2/history",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTaskHistory", mock.Anything, 2, "user1", 0, "").Return((*services.TaskHistoryPage)(nil), services.ErrTaskNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"Tarea no encontrada"`,
+		},
+		{
+			testName: "Cursor inválido",
+			path:     "/tasks/1/history?after=nope",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTaskHistory", mock.Anything, 1, "user1", 0, "nope").Return((*services.TaskHistoryPage)(nil), services.ErrInvalidCursor)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"Cursor inválido"`,
+		},
+		{
+			testName:       "limit inválido",
+			path:           "/tasks/1/history?limit=0",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"limit debe ser un entero positivo"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockTaskService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewTaskHandler(mockService, logrus.New())
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.GET("/tasks/:id/history", handler.GetTaskHistory)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_Revert(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		path           string
+		ifMatch        string
+		mockSetup      func(*mockTaskService)
+		expectedStatus int
+		expectedBody   string
+		expectedETag   string
+	}{
+		{
+			testName: "Revertir",
+			path:     "/tasks/1/revert?version=2",
+			ifMatch:  `"5"`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("RevertTask", mock.Anything, 1, "user1", uint(2), uint(5)).Return(&models.Task{Model: gorm.Model{ID: 1}, Title: "Vieja", Owner: "user1", Version: 6}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"title":"Vieja"`,
+			expectedETag:   `"6"`,
+		},
+		{
+			testName:       "Sin If-Match",
+			path:           "/tasks/1/revert?version=2",
+			expectedStatus: http.StatusPreconditionRequired,
+			expectedBody:   `"error":"Revertir requiere If-Match con el ETag de la versión actual"`,
+		},
+		{
+			testName:       "If-Match comodín",
+			path:           "/tasks/1/revert?version=2",
+			ifMatch:        "*",
+			expectedStatus: http.StatusPreconditionRequired,
+			expectedBody:   `"error":"Revertir requiere If-Match`,
+		},
+		{
+			testName:       "Sin version",
+			path:           "/tasks/1/revert",
+			ifMatch:        `"5"`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"version debe ser un entero positivo"`,
+		},
+		{
+			testName: "Edición concurrente",
+			path:     "/tasks/1/revert?version=2",
+			ifMatch:  `"4"`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("RevertTask", mock.Anything, 1, "user1", uint(2), uint(4)).Return((*models.Task)(nil), services.ErrVersionConflict)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `"error":"La tarea cambió desde la versión indicada en If-Match"`,
+		},
+		{
+			testName: "Versión inexistente",
+			path:     "/tasks/1/revert?version=9",
+			ifMatch:  `"5"`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("RevertTask", mock.Anything, 1, "user1", uint(9), uint(5)).Return((*models.Task)(nil), services.ErrRevisionNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"La versión no es anterior a la actual o no está en el historial"`,
+		},
+		{
+			testName: "Proyecto eliminado",
+			path:     "/tasks/1/revert?version=2",
+			ifMatch:  `"5"`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("RevertTask", mock.Anything, 1, "user1", uint(2), uint(5)).Return((*models.Task)(nil), services.ErrProjectNotFound)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"error":"La versión ya no se puede restaurar: project_id no corresponde a ningún proyecto del usuario"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockTaskService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewTaskHandler(mockService, logrus.New())
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.POST("/tasks/:id/revert", handler.RevertTask)
+
+			req, _ := http.NewRequest(http.MethodPost, tt.path, nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.Equal(t, tt.expectedETag, w.Header().Get("ETag"))
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_Bulk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		requestBody    string
+		mockSetup      func(*mockTaskService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName:    "Lote atómico aplicado",
+			requestBody: `{"operations":[{"op":"create","task":{"title":"Nueva"}},{"op":"complete","id":2,"version":3}]}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("BulkTasks", mock.Anything, "user1", "", []services.BulkOperation{
+					{Op: services.BulkOpCreate, Fields: services.TaskFields{Title: "Nueva"}},
+					{Op: services.BulkOpComplete, ID: 2, ExpectedVersion: 3},
+				}).Return([]services.BulkResult{
+					{Task: &models.Task{Model: gorm.Model{ID: 5}, Title: "Nueva", Owner: "user1", Version: 1}},
+					{Task: &models.Task{Model: gorm.Model{ID: 2}, Title: "Vieja", Owner: "user1", Completed: true, Version: 4}},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"mode":"atomic","applied":2,"results":[{"index":0,"op":"create","status":201,"task":{"id":5`,
+		},
+		{
+			testName:    "Lote atómico con un fallo",
+			requestBody: `{"mode":"atomic","operations":[{"op":"delete","id":1},{"op":"delete","id":9}]}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("BulkTasks", mock.Anything, "user1", "atomic", mock.Anything).Return([]services.BulkResult{
+					{Err: services.ErrBulkAborted},
+					{Err: services.ErrTaskNotFound},
+				}, nil)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"applied":0,"results":[{"index":0,"op":"delete","status":424,"error":"No se aplicó porque otra operación del lote falló"},{"index":1,"op":"delete","status":404,"error":"Tarea no encontrada"}]`,
+		},
+		{
+			testName:    "Best effort",
+			requestBody: `{"mode":"best_effort","operations":[{"op":"delete","id":1},{"op":"update","id":2,"version":1,"task":{"title":"Otra"}},{"op":"move","id":3}]}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("BulkTasks", mock.Anything, "user1", "best_effort", mock.Anything).Return([]services.BulkResult{
+					{},
+					{Err: services.ErrVersionConflict},
+					{Err: services.ErrInvalidBulkOperation},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"applied":1,"results":[{"index":0,"op":"delete","status":200},{"index":1,"op":"update","status":412,"error":"La tarea cambió desde la versión indicada"},{"index":2,"op":"move","status":400,"error":"op debe ser create, update, delete o complete"}]`,
+		},
+		{
+			testName:    "Modo inválido",
+			requestBody: `{"mode":"later","operations":[{"op":"delete","id":1}]}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("BulkTasks", mock.Anything, "user1", "later", mock.Anything).Return([]services.BulkResult(nil), services.ErrInvalidBulkMode)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"mode debe ser atomic o best_effort"`,
+		},
+		{
+			testName:    "Lote vacío",
+			requestBody: `{"operations":[]}`,
+			mockSetup: func(m *mockTaskService) {
+				m.On("BulkTasks", mock.Anything, "user1", "", []services.BulkOperation{}).Return([]services.BulkResult(nil), services.ErrInvalidBulkSize)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"operations debe tener entre 1 y 100 operaciones"`,
+		},
+		{
+			testName:       "Sin operations",
+			requestBody:    `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"operations es obligatorio"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockTaskService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewTaskHandler(mockService, logrus.New())
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.POST("/tasks/bulk", handler.BulkTasks)
+
+			req, _ := http.NewRequest(http.MethodPost, "/tasks/bulk", bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_Search(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		path           string
+		mockSetup      func(*mockTaskService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Buscar",
+			path:     "/tasks/search?q=compr*&limit=5",
+			mockSetup: func(m *mockTaskService) {
+				m.On("SearchTasks", mock.Anything, "user1", "compr*", 5).Return([]*services.TaskSearchResult{
+					{Task: &models.Task{Model: gorm.Model{ID: 1}, Title: "Comprar leche", Owner: "user1"}, Score: 1.5,
+						TitleHighlight: "<mark>Comprar</mark> leche", Snippet: "Pasar por el súper"},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"score":1.5,"title_highlight":"\u003cmark\u003eComprar\u003c/mark\u003e leche","snippet":"Pasar por el súper"`,
+		},
+		{
+			testName: "Sin resultados",
+			path:     "/tasks/search?q=nada",
+			mockSetup: func(m *mockTaskService) {
+				m.On("SearchTasks", mock.Anything, "user1", "nada", 0).Return([]*services.TaskSearchResult{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[]`,
+		},
+		{
+			testName:       "Sin q",
+			path:           "/tasks/search",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"q es obligatorio"`,
+		},
+		{
+			testName:       "limit inválido",
+			path:           "/tasks/search?q=leche&limit=0",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"limit debe ser un entero positivo"`,
+		},
+		{
+			testName: "Búsqueda sin términos",
+			path:     "/tasks/search?q=%22%22",
+			mockSetup: func(m *mockTaskService) {
+				m.On("SearchTasks", mock.Anything, "user1", `""`, 0).Return([]*services.TaskSearchResult(nil), services.ErrInvalidSearchQuery)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"La búsqueda debe tener entre 1 y 10 palabras o frases"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockTaskService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			handler := NewTaskHandler(mockService, logrus.New())
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "user1")
+			})
+			router.GET("/tasks/search", handler.SearchTasks)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_DeleteTask(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		id             string
+		mockSetup      func(*mockTaskService)
+		username       string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Eliminar tarea exitosa",
+			id:       "1",
+			username: "user1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("DeleteTask", mock.Anything, 1, "user1", uint(0)).
+					Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"message":"Tarea eliminada exitosamente"`,
+		},
+		{
+			testName:       "ID inválido",
+			id:             "abc",
+			username:       "user1",
+			mockSetup:      nil,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"ID inválido"`,
+		},
+		{
+			testName: "Tarea no encontrada",
+			id:       "1",
 			username: "user1",
 			mockSetup: func(m *mockTaskService) {
-				m.On("DeleteTask", mock.Anything, 1, "user1").
+				m.On("DeleteTask", mock.Anything, 1, "user1", uint(0)).
 					Return(errors.New("Tarea no encontrada"))
 			},
 			expectedStatus: http.StatusNotFound,
@@ -383,3 +2079,123 @@ func TestTaskHandler_DeleteTask(t *testing.T) {
 		})
 	}
 }
+
+func TestTaskHandler_AdminRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		method         string
+		path           string
+		mockSetup      func(*mockTaskService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Listar todas las tareas",
+			method:   http.MethodGet,
+			path:     "/admin/tasks",
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetAllTasks", mock.Anything, services.TaskQuery{}).
+					Return(&services.TaskPage{Tasks: []*models.Task{{Title: "A", Owner: "user1"}, {Title: "B", Owner: "user2"}}, NextCursor: "c2", Total: 5}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"next_cursor":"c2","total":5`,
+		},
+		{
+			testName: "Listar todas las tareas con cursor",
+			method:   http.MethodGet,
+			path:     "/admin/tasks?limit=2&after=c2&sort=-created_at",
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetAllTasks", mock.Anything, services.TaskQuery{Limit: 2, After: "c2", Sort: "-created_at"}).
+					Return(&services.TaskPage{Tasks: []*models.Task{{Title: "C", Owner: "user3"}}, Total: 5}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"owner":"user3"`,
+		},
+		{
+			testName:       "Listar todas las tareas con limit inválido",
+			method:         http.MethodGet,
+			path:           "/admin/tasks?limit=0",
+			mockSetup:      nil,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"limit debe ser un entero positivo"`,
+		},
+		{
+			testName: "Listar todas las tareas con cursor inválido",
+			method:   http.MethodGet,
+			path:     "/admin/tasks?after=nope",
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetAllTasks", mock.Anything, services.TaskQuery{After: "nope"}).
+					Return((*services.TaskPage)(nil), services.ErrInvalidCursor)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"Cursor inválido"`,
+		},
+		{
+			testName: "Listar tareas de un usuario",
+			method:   http.MethodGet,
+			path:     "/admin/users/user2/tasks",
+			mockSetup: func(m *mockTaskService) {
+				m.On("ListTasks", mock.Anything, services.TaskQuery{Owner: "user2"}).
+					Return(&services.TaskPage{Tasks: []*models.Task{{Title: "B", Owner: "user2"}}, Total: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"title":"B"`,
+		},
+		{
+			testName: "Obtener tarea ajena",
+			method:   http.MethodGet,
+			path:     "/admin/tasks/1",
+			mockSetup: func(m *mockTaskService) {
+				m.On("GetTaskByIDAsAdmin", mock.Anything, 1).
+					Return(&models.Task{Title: "A", Owner: "user1"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"owner":"user1"`,
+		},
+		{
+			testName: "Eliminar tarea inexistente",
+			method:   http.MethodDelete,
+			path:     "/admin/tasks/9",
+			mockSetup: func(m *mockTaskService) {
+				m.On("DeleteTaskAsAdmin", mock.Anything, 9).Return(errors.New("Tarea no encontrada"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `"error":"Tarea no encontrada"`,
+		},
+		{
+			testName:       "ID inválido",
+			method:         http.MethodGet,
+			path:           "/admin/tasks/abc",
+			mockSetup:      nil,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"error":"ID inválido"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockTaskService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+			logger := logrus.New()
+			handler := NewTaskHandler(mockService, logger)
+
+			router := gin.New()
+			router.GET("/admin/tasks", handler.GetAllTasks)
+			router.GET("/admin/users/:username/tasks", handler.GetUserTasks)
+			router.GET("/admin/tasks/:id", handler.GetTaskAsAdmin)
+			router.DELETE("/admin/tasks/:id", handler.DeleteTaskAsAdmin)
+
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
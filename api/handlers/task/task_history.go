@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTaskHistory godoc
+// @Summary      Historial de una tarea
+// @Description  Lista los cambios de una tarea (alta, campos modificados, eliminación y restauración) del más reciente al más antiguo. También funciona con tareas en la papelera
+// @Tags         tasks
+// @Produce      json
+// @Param        id path int true "ID de la tarea"
+// @Param        limit query int false "Tamaño de página (por defecto 20, máximo 100)"
+// @Param        after query string false "Cursor next_cursor de la página anterior"
+// @Success      200 {object} models.TaskEventListResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tasks/{id}/history [get]
+func (h *taskHandler) GetTaskHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: GetTaskHistory] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 {
+			h.logger.Warnf("[Layer: task_handler] [Method: GetTaskHistory] limit inválido: '%s'", raw)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit debe ser un entero positivo"})
+			return
+		}
+	}
+	username, _ := c.Get("username")
+	page, err := h.taskService.ListTaskHistory(c.Request.Context(), id, username.(string), limit, c.Query("after"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTaskNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
+		case errors.Is(err, services.ErrInvalidCursor):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor inválido"})
+		default:
+			h.logger.Error("[Layer: task_handler] [Method: GetTaskHistory] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el historial"})
+		}
+		return
+	}
+	resp := models.TaskEventListResponse{Items: make([]models.TaskEventResponse, 0, len(page.Events)), NextCursor: page.NextCursor}
+	for _, e := range page.Events {
+		resp.Items = append(resp.Items, toTaskEventResponse(e))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevertTask godoc
+// @Summary      Revertir tarea
+// @Description  Vuelve los campos de la tarea a como estaban en una versión de su historial. La reversión es una versión nueva y queda en el historial. Requiere If-Match con la versión actual para no pisar ediciones concurrentes
+// @Tags         tasks
+// @Produce      json
+// @Param        id path int true "ID de la tarea"
+// @Param        version query int true "Versión a restaurar"
+// @Param        If-Match header string true "ETag de la versión actual de la tarea"
+// @Success      200 {object} models.TaskResponse
+// @Header       200 {string} ETag "Nueva versión de la tarea"
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      412 {object} map[string]string
+// @Failure      428 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tasks/{id}/revert [post]
+func (h *taskHandler) RevertTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: RevertTask] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	version, err := strconv.ParseUint(c.Query("version"), 10, 32)
+	if err != nil || version == 0 {
+		h.logger.Warnf("[Layer: task_handler] [Method: RevertTask] version inválida: '%s'", c.Query("version"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "version debe ser un entero positivo"})
+		return
+	}
+	username, _ := c.Get("username")
+	expectedVersion, ok := h.expectedVersion(c, id, username.(string))
+	if !ok {
+		preconditionFailed(c)
+		return
+	}
+	if expectedVersion == 0 {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "Revertir requiere If-Match con el ETag de la versión actual"})
+		return
+	}
+	task, err := h.taskService.RevertTask(auditContext(c), id, username.(string), uint(version), expectedVersion)
+	if err != nil {
+		if message, ok := taskFieldError(err); ok {
+			c.JSON(http.StatusConflict, gin.H{"error": "La versión ya no se puede restaurar: " + message})
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrTaskNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tarea no encontrada"})
+		case errors.Is(err, services.ErrRevisionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "La versión no es anterior a la actual o no está en el historial"})
+		case errors.Is(err, services.ErrVersionConflict):
+			preconditionFailed(c)
+		case errors.Is(err, services.ErrTaskBlocked):
+			c.JSON(http.StatusConflict, gin.H{"error": "La tarea tiene bloqueos pendientes"})
+		default:
+			h.logger.Error("[Layer: task_handler] [Method: RevertTask] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo revertir la tarea"})
+		}
+		return
+	}
+	c.Header("ETag", taskETag(task))
+	c.JSON(http.StatusOK, toTaskResponse(task))
+}
+
+func toTaskEventResponse(e *models.TaskEvent) models.TaskEventResponse {
+	resp := models.TaskEventResponse{
+		ID:        e.ID,
+		TaskID:    e.TaskID,
+		Version:   e.Version,
+		Actor:     e.Actor,
+		Action:    e.Action,
+		Field:     e.Field,
+		RequestID: e.RequestID,
+		CreatedAt: e.CreatedAt,
+	}
+	// los valores ya están en JSON; solo los eventos de campo y reverted los tienen
+	if e.Field != "" {
+		resp.OldValue = json.RawMessage(e.OldValue)
+	}
+	if e.NewValue != "" {
+		resp.NewValue = json.RawMessage(e.NewValue)
+	}
+	return resp
+}
+
+// auditContext es el contexto de las escrituras: lleva quién hace el cambio y el request ID para el historial.
+func auditContext(c *gin.Context) context.Context {
+	return services.WithAudit(c.Request.Context(), services.Audit{
+		Actor:     c.GetString("username"),
+		RequestID: c.GetString("request_id"),
+	})
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
+	"slices"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	contentTypeMergePatch = "application/merge-patch+json"
+	contentTypeJSONPatch  = "application/json-patch+json"
+
+	// maxPatchAttempts acota cuántas veces se vuelve a aplicar un parche sin If-Match si otra escritura se adelanta
+	maxPatchAttempts = 3
+)
+
+// taskDocument es la representación sobre la que se aplican los parches; solo tiene campos editables.
+type taskDocument struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	Priority    string     `json:"priority"`
+	DueAt       *time.Time `json:"due_at"`
+	Recurrence  string     `json:"recurrence"`
+	ProjectID   *uint      `json:"project_id"`
+	ParentID    *uint      `json:"parent_id"`
+	Tags        []string   `json:"tags"`
+}
+
+func toTaskDocument(task *models.Task) taskDocument {
+	return taskDocument{
+		Title:       task.Title,
+		Description: task.Description,
+		Completed:   task.Completed,
+		Priority:    task.Priority,
+		DueAt:       task.DueAt,
+		Recurrence:  task.Recurrence,
+		ProjectID:   task.ProjectID,
+		ParentID:    task.ParentID,
+		Tags:        tagNames(task),
+	}
+}
+
+// patchError lleva el status HTTP y el mensaje para el cliente.
+type patchError struct {
+	status  int
+	message string
+}
+
+func (e *patchError) Error() string {
+	return e.message
+}
+
+// applyTaskPatch aplica un JSON Merge Patch (RFC 7396) o un JSON Patch (RFC 6902) según el Content-Type
+// y devuelve los campos resultantes junto con la máscara de los que cambiaron.
+func applyTaskPatch(contentType string, current taskDocument, patch []byte) (services.TaskFields, []string, error) {
+	original, err := json.Marshal(current)
+	if err != nil {
+		return services.TaskFields{}, nil, err
+	}
+
+	var patched []byte
+	switch contentType {
+	case contentTypeMergePatch, "application/json":
+		if !json.Valid(patch) {
+			return services.TaskFields{}, nil, &patchError{http.StatusBadRequest, "El cuerpo no es un JSON válido"}
+		}
+		if patched, err = jsonpatch.MergePatch(original, patch); err != nil {
+			return services.TaskFields{}, nil, &patchError{http.StatusBadRequest, "Merge patch inválido"}
+		}
+	case contentTypeJSONPatch:
+		operations, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return services.TaskFields{}, nil, &patchError{http.StatusBadRequest, "JSON Patch inválido"}
+		}
+		if patched, err = operations.Apply(original); err != nil {
+			if errors.Is(err, jsonpatch.ErrTestFailed) {
+				return services.TaskFields{}, nil, &patchError{http.StatusConflict, "La operación test no se cumple"}
+			}
+			return services.TaskFields{}, nil, &patchError{http.StatusConflict, "No se pudo aplicar el JSON Patch"}
+		}
+	default:
+		return services.TaskFields{}, nil, &patchError{http.StatusUnsupportedMediaType,
+			fmt.Sprintf("Content-Type debe ser %s o %s", contentTypeMergePatch, contentTypeJSONPatch)}
+	}
+
+	result, err := decodeTaskDocument(patched)
+	if err != nil {
+		return services.TaskFields{}, nil, err
+	}
+
+	var mask []string
+	if result.Title != current.Title {
+		mask = append(mask, services.FieldTitle)
+	}
+	if result.Description != current.Description {
+		mask = append(mask, services.FieldDescription)
+	}
+	if result.Completed != current.Completed {
+		mask = append(mask, services.FieldCompleted)
+	}
+	if result.Priority != current.Priority {
+		mask = append(mask, services.FieldPriority)
+	}
+	if !sameTime(result.DueAt, current.DueAt) {
+		mask = append(mask, services.FieldDueAt)
+	}
+	if result.Recurrence != current.Recurrence {
+		mask = append(mask, services.FieldRecurrence)
+	}
+	if !samePointer(result.ProjectID, current.ProjectID) {
+		mask = append(mask, services.FieldProjectID)
+	}
+	if !samePointer(result.ParentID, current.ParentID) {
+		mask = append(mask, services.FieldParentID)
+	}
+	fields := services.TaskFields{
+		Title:       result.Title,
+		Description: result.Description,
+		Completed:   result.Completed,
+		Priority:    result.Priority,
+		DueAt:       result.DueAt,
+		Recurrence:  result.Recurrence,
+		ProjectID:   result.ProjectID,
+		ParentID:    result.ParentID,
+	}
+	if !sameTags(result.Tags, current.Tags) {
+		mask = append(mask, services.FieldTags)
+		// null o eliminar tags deja la tarea sin etiquetas
+		fields.Tags = append([]string{}, result.Tags...)
+	}
+	return fields, mask, nil
+}
+
+func decodeTaskDocument(raw []byte) (taskDocument, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return taskDocument{}, &patchError{http.StatusUnprocessableEntity, "El resultado del parche debe ser un objeto"}
+	}
+	// description, due_at, recurrence, project_id, parent_id y tags pueden eliminarse (quedan vacíos); el resto es obligatorio
+	for _, name := range []string{services.FieldTitle, services.FieldCompleted, services.FieldPriority} {
+		value, ok := fields[name]
+		if !ok || string(value) == "null" {
+			return taskDocument{}, &patchError{http.StatusUnprocessableEntity, fmt.Sprintf("El campo %s no puede eliminarse", name)}
+		}
+	}
+
+	var doc taskDocument
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return taskDocument{}, &patchError{http.StatusUnprocessableEntity, fmt.Sprintf("Tipo inválido para el campo %s", typeErr.Field)}
+		}
+		var parseErr *time.ParseError
+		if errors.As(err, &parseErr) {
+			return taskDocument{}, &patchError{http.StatusUnprocessableEntity, "due_at debe tener formato RFC 3339"}
+		}
+		return taskDocument{}, &patchError{http.StatusUnprocessableEntity, "Solo se pueden modificar los campos title, description, completed, priority, due_at, recurrence, project_id, parent_id y tags"}
+	}
+	return doc, nil
+}
+
+// sameTags compara las etiquetas como conjunto: reordenarlas no es un cambio.
+func sameTags(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+// samePointer compara los valores apuntados; dos nil son iguales.
+func samePointer[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PreviewRecurrence godoc
+// @Summary      Previsualizar recurrencia
+// @Description  Lista las próximas ocurrencias de una RRULE (FREQ, INTERVAL, BYDAY, COUNT, UNTIL) empezando por start. count es 10 por defecto y como máximo 50
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Param        request body models.RecurrencePreviewRequest true "Regla a previsualizar"
+// @Success      200 {object} models.RecurrencePreviewResponse
+// @Failure      400 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tasks/recurrence/preview [post]
+func (h *taskHandler) PreviewRecurrence(c *gin.Context) {
+	var req models.RecurrencePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: PreviewRecurrence] Datos inválidos: ", err)
+		var parseErr *time.ParseError
+		if errors.As(err, &parseErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start debe tener formato RFC 3339"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rule es obligatorio"})
+		return
+	}
+	start := time.Now().UTC().Truncate(time.Second)
+	if req.Start != nil {
+		start = *req.Start
+	}
+	preview, err := h.taskService.PreviewRecurrence(c.Request.Context(), req.Rule, start, req.Count)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRecurrence) {
+			message, _ := taskFieldError(err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		h.logger.Error("[Layer: task_handler] [Method: PreviewRecurrence] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo calcular la recurrencia"})
+		return
+	}
+	c.JSON(http.StatusOK, models.RecurrencePreviewResponse{Rule: preview.Rule, Occurrences: preview.Occurrences})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchTasks godoc
+// @Summary      Buscar tareas
+// @Description  Busca en el título y la descripción de las tareas del usuario autenticado, de la más relevante a la menos. Todas las palabras tienen que aparecer; "palabra*" busca por prefijo y el texto entre comillas como frase. Las tareas en la papelera no se incluyen
+// @Tags         tasks
+// @Produce      json
+// @Param        q query string true "Texto a buscar, por ejemplo: comprar \"billetes de tren\" super*"
+// @Param        limit query int false "Cantidad máxima de resultados (por defecto 20, máximo 100)"
+// @Success      200 {array} models.TaskSearchResponse
+// @Failure      400 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tasks/search [get]
+func (h *taskHandler) SearchTasks(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		h.logger.Warn("[Layer: task_handler] [Method: SearchTasks] Búsqueda sin q")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q es obligatorio"})
+		return
+	}
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 {
+			h.logger.Warnf("[Layer: task_handler] [Method: SearchTasks] limit inválido: '%s'", raw)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit debe ser un entero positivo"})
+			return
+		}
+	}
+	username, _ := c.Get("username")
+	results, err := h.taskService.SearchTasks(c.Request.Context(), username.(string), q, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSearchQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La búsqueda debe tener entre 1 y 10 palabras o frases"})
+			return
+		}
+		h.logger.Error("[Layer: task_handler] [Method: SearchTasks] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar tareas"})
+		return
+	}
+	resp := make([]models.TaskSearchResponse, 0, len(results))
+	for _, r := range results {
+		resp = append(resp, models.TaskSearchResponse{
+			Task:           toTaskResponse(r.Task),
+			Score:          r.Score,
+			TitleHighlight: r.TitleHighlight,
+			Snippet:        r.Snippet,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
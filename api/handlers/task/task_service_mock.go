@@ -3,6 +3,8 @@ package handlers
 import (
 	"context"
 	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -11,23 +13,91 @@ type mockTaskService struct {
 	mock.Mock
 }
 
-func (m *mockTaskService) GetTasksByUser(ctx context.Context, username string) ([]*models.Task, error) {
-	args := m.Called(ctx, username)
-	return args.Get(0).([]*models.Task), args.Error(1)
+func (m *mockTaskService) ListTasks(ctx context.Context, query services.TaskQuery) (*services.TaskPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(*services.TaskPage), args.Error(1)
 }
 func (m *mockTaskService) GetTaskByID(ctx context.Context, id int, username string) (*models.Task, error) {
 	args := m.Called(ctx, id, username)
 	return args.Get(0).(*models.Task), args.Error(1)
 }
-func (m *mockTaskService) CreateTask(ctx context.Context, title string, username string) (*models.Task, error) {
-	args := m.Called(ctx, title, username)
+func (m *mockTaskService) CreateTask(ctx context.Context, username string, fields services.TaskFields) (*models.Task, error) {
+	args := m.Called(ctx, username, fields)
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+func (m *mockTaskService) UpdateTask(ctx context.Context, id int, username string, fields services.TaskFields, expectedVersion uint) (*models.Task, error) {
+	args := m.Called(ctx, id, username, fields, expectedVersion)
 	return args.Get(0).(*models.Task), args.Error(1)
 }
-func (m *mockTaskService) UpdateTask(ctx context.Context, id int, title string, completed bool, username string) (*models.Task, error) {
-	args := m.Called(ctx, id, title, completed, username)
+func (m *mockTaskService) PatchTask(ctx context.Context, id int, username string, fields services.TaskFields, mask []string, expectedVersion uint) (*models.Task, error) {
+	args := m.Called(ctx, id, username, fields, mask, expectedVersion)
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+func (m *mockTaskService) DeleteTask(ctx context.Context, id int, username string, expectedVersion uint) error {
+	args := m.Called(ctx, id, username, expectedVersion)
+	return args.Error(0)
+}
+func (m *mockTaskService) GetAllTasks(ctx context.Context, query services.TaskQuery) (*services.TaskPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(*services.TaskPage), args.Error(1)
+}
+func (m *mockTaskService) GetTaskByIDAsAdmin(ctx context.Context, id int) (*models.Task, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(*models.Task), args.Error(1)
 }
-func (m *mockTaskService) DeleteTask(ctx context.Context, id int, username string) error {
+func (m *mockTaskService) DeleteTaskAsAdmin(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *mockTaskService) SubtaskTree(ctx context.Context, username string, rootIDs []uint) (map[uint][]*models.Task, error) {
+	args := m.Called(ctx, username, rootIDs)
+	return args.Get(0).(map[uint][]*models.Task), args.Error(1)
+}
+func (m *mockTaskService) ListBlockers(ctx context.Context, id int, username string) ([]*models.Task, error) {
 	args := m.Called(ctx, id, username)
+	return args.Get(0).([]*models.Task), args.Error(1)
+}
+func (m *mockTaskService) AddBlocker(ctx context.Context, id int, username string, blockerID uint) (*models.Task, error) {
+	args := m.Called(ctx, id, username, blockerID)
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+func (m *mockTaskService) RemoveBlocker(ctx context.Context, id int, username string, blockerID uint) (*models.Task, error) {
+	args := m.Called(ctx, id, username, blockerID)
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+func (m *mockTaskService) PreviewRecurrence(ctx context.Context, rule string, start time.Time, count int) (*services.RecurrencePreview, error) {
+	args := m.Called(ctx, rule, start, count)
+	return args.Get(0).(*services.RecurrencePreview), args.Error(1)
+}
+func (m *mockTaskService) ListTrash(ctx context.Context, username string) ([]*models.Task, error) {
+	args := m.Called(ctx, username)
+	return args.Get(0).([]*models.Task), args.Error(1)
+}
+func (m *mockTaskService) RestoreTask(ctx context.Context, id int, username string) (*models.Task, error) {
+	args := m.Called(ctx, id, username)
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+func (m *mockTaskService) PurgeTask(ctx context.Context, id int, username string, expectedVersion uint) error {
+	args := m.Called(ctx, id, username, expectedVersion)
 	return args.Error(0)
 }
+func (m *mockTaskService) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *mockTaskService) ListTaskHistory(ctx context.Context, id int, username string, limit int, after string) (*services.TaskHistoryPage, error) {
+	args := m.Called(ctx, id, username, limit, after)
+	return args.Get(0).(*services.TaskHistoryPage), args.Error(1)
+}
+func (m *mockTaskService) RevertTask(ctx context.Context, id int, username string, version uint, expectedVersion uint) (*models.Task, error) {
+	args := m.Called(ctx, id, username, version, expectedVersion)
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+func (m *mockTaskService) BulkTasks(ctx context.Context, username, mode string, ops []services.BulkOperation) ([]services.BulkResult, error) {
+	args := m.Called(ctx, username, mode, ops)
+	return args.Get(0).([]services.BulkResult), args.Error(1)
+}
+func (m *mockTaskService) SearchTasks(ctx context.Context, username, query string, limit int) ([]*services.TaskSearchResult, error) {
+	args := m.Called(ctx, username, query, limit)
+	return args.Get(0).([]*services.TaskSearchResult), args.Error(1)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrash godoc
+// @Summary      Listar papelera
+// @Description  Obtiene las tareas eliminadas del usuario autenticado, de la más reciente a la más antigua. Se purgan automáticamente al vencer el período de retención
+// @Tags         tasks
+// @Produce      json
+// @Success      200 {array} models.TaskResponse
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tasks/trash [get]
+func (h *taskHandler) GetTrash(c *gin.Context) {
+	username, _ := c.Get("username")
+	tasks, err := h.taskService.ListTrash(c.Request.Context(), username.(string))
+	if err != nil {
+		h.logger.Error("[Layer: task_handler] [Method: GetTrash] Error: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la papelera"})
+		return
+	}
+	resp := make([]models.TaskResponse, 0, len(tasks))
+	for _, t := range tasks {
+		resp = append(resp, toTaskResponse(t))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RestoreTask godoc
+// @Summary      Restaurar tarea
+// @Description  Saca una tarea de la papelera junto con las subtareas que se eliminaron con ella
+// @Tags         tasks
+// @Produce      json
+// @Param        id path int true "ID de la tarea"
+// @Success      200 {object} models.TaskResponse
+// @Header       200 {string} ETag "Nueva versión de la tarea"
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     APIKeyHeader
+// @Router       /api/tasks/{id}/restore [post]
+func (h *taskHandler) RestoreTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.logger.Warn("[Layer: task_handler] [Method: RestoreTask] ID inválido: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	username, _ := c.Get("username")
+	task, err := h.taskService.RestoreTask(auditContext(c), id, username.(string))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTaskNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "La tarea no está en la papelera"})
+		case errors.Is(err, services.ErrParentTrashed):
+			c.JSON(http.StatusConflict, gin.H{"error": "La tarea padre está en la papelera; restáurala primero"})
+		default:
+			h.logger.Error("[Layer: task_handler] [Method: RestoreTask] Error: ", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo restaurar la tarea"})
+		}
+		return
+	}
+	c.Header("ETag", taskETag(task))
+	c.JSON(http.StatusOK, toTaskResponse(task))
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	APIKeyScopeRead      = "read"
+	APIKeyScopeReadWrite = "read_write"
+)
+
+// APIKey guarda solo el hash de la llave; Prefix permite reconocerla en listados.
+type APIKey struct {
+	gorm.Model
+	Username   string `gorm:"index;not null"`
+	Name       string `gorm:"not null"`
+	Prefix     string `gorm:"not null"`
+	KeyHash    string `gorm:"uniqueIndex;not null"`
+	Scope      string `gorm:"not null"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
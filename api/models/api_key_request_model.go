@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scope     string     `json:"scope" binding:"omitempty,oneof=read read_write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// BulkTaskRequest ejecuta varias operaciones en una transacción. mode es atomic (por defecto: todas o
+// ninguna) o best_effort (se aplican las que se puedan).
+type BulkTaskRequest struct {
+	Mode       string                 `json:"mode" example:"atomic"`
+	Operations []BulkOperationRequest `json:"operations" binding:"required"`
+}
+
+// BulkOperationRequest: create usa task; update usa id y task (reemplaza la tarea como PUT); delete y
+// complete solo id. version cumple la función de If-Match para esa operación.
+type BulkOperationRequest struct {
+	Op      string                 `json:"op" example:"complete"`
+	ID      int                    `json:"id"`
+	Version uint                   `json:"version"`
+	Task    *BulkTaskRequestFields `json:"task"`
+}
+
+// BulkTaskRequestFields son los campos de create y update; se validan por operación, no al leer el lote.
+type BulkTaskRequestFields struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	Priority    string     `json:"priority" example:"medium"`
+	DueAt       *time.Time `json:"due_at" example:"2026-12-31T18:00:00Z"`
+	Recurrence  string     `json:"recurrence"`
+	ProjectID   *uint      `json:"project_id"`
+	ParentID    *uint      `json:"parent_id"`
+	Tags        []string   `json:"tags"`
+}
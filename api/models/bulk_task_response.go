@@ -0,0 +1,16 @@
+package models
+
+// BulkOperationResult lleva el código HTTP que habría tenido la operación por separado.
+type BulkOperationResult struct {
+	Index  int           `json:"index"`
+	Op     string        `json:"op"`
+	Status int           `json:"status"`
+	Task   *TaskResponse `json:"task,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+type BulkTaskResponse struct {
+	Mode    string                `json:"mode"`
+	Applied int                   `json:"applied"`
+	Results []BulkOperationResult `json:"results"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "gorm.io/gorm"
+
+// ExternalIdentity enlaza la cuenta de un proveedor externo (issuer + subject) con un usuario local.
+type ExternalIdentity struct {
+	gorm.Model
+	Issuer   string `gorm:"uniqueIndex:idx_external_identity;not null"`
+	Subject  string `gorm:"uniqueIndex:idx_external_identity;not null"`
+	Username string `gorm:"index;not null"`
+	Email    string
+}
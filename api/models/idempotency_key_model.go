@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKey guarda la respuesta de una escritura hecha con el header Idempotency-Key para
+// repetirla si el cliente reintenta. Las claves son por usuario; Status 0 indica que la petición
+// original todavía está en curso.
+type IdempotencyKey struct {
+	Owner       string `gorm:"primaryKey"`
+	Key         string `gorm:"primaryKey"`
+	Fingerprint string `gorm:"not null"` // hash del método, la ruta, If-Match y el cuerpo
+	Status      int
+	ContentType string
+	ETag        string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"index;not null"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// LoginAttempt cuenta los fallos de login por clave ("user:<username>" o "ip:<ip>").
+type LoginAttempt struct {
+	Key           string    `gorm:"primaryKey"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"index;not null"`
+	LockedUntil   time.Time `gorm:"index"`
+}
@@ -2,4 +2,5 @@ package models
 
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
 }
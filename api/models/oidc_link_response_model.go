@@ -0,0 +1,6 @@
+package models
+
+// OIDCLinkResponse es la URL del proveedor a la que el navegador debe ir para enlazar la identidad.
+type OIDCLinkResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "gorm.io/gorm"
+
+// Project agrupa tareas de un usuario; las tareas sin proyecto forman la bandeja de entrada.
+type Project struct {
+	gorm.Model
+	Name     string `gorm:"not null"`
+	Color    string // hexadecimal #RRGGBB, opcional
+	Archived bool   `gorm:"not null;default:false"`
+	Owner    string `gorm:"index;not null"`
+}
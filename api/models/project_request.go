@@ -0,0 +1,6 @@
+package models
+
+type ProjectRequest struct {
+	Name  string `json:"name" binding:"required" example:"Trabajo"`
+	Color string `json:"color" example:"#1E88E5"`
+}
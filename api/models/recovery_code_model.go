@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode es un código de un solo uso para entrar cuando no se tiene la app TOTP.
+type RecoveryCode struct {
+	gorm.Model
+	Username string `gorm:"index;not null"`
+	CodeHash string `gorm:"uniqueIndex;not null"`
+	UsedAt   *time.Time
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// RecurrencePreviewRequest pide las próximas ocurrencias de una regla; sin start se usa el momento actual.
+type RecurrencePreviewRequest struct {
+	Rule  string     `json:"rule" binding:"required" example:"FREQ=WEEKLY;BYDAY=MO,WE"`
+	Start *time.Time `json:"start" example:"2026-01-05T09:00:00Z"`
+	Count int        `json:"count" example:"10"`
+}
@@ -0,0 +1,8 @@
+package models
+
+import "time"
+
+type RecurrencePreviewResponse struct {
+	Rule        string      `json:"rule"`
+	Occurrences []time.Time `json:"occurrences"`
+}
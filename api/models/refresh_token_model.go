@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken guarda solo el hash del token; FamilyID agrupa todas las
+// rotaciones que nacen de un mismo login.
+type RefreshToken struct {
+	gorm.Model
+	TokenHash string     `gorm:"uniqueIndex;not null"`
+	FamilyID  string     `gorm:"index;not null"`
+	Username  string     `gorm:"index;not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // se marca al rotar; un segundo uso indica robo del token
+	RevokedAt *time.Time
+}
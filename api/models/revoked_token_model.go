@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// RevokedToken es una entrada de la lista de revocación. ID puede ser el jti
+// de un access token o el id de sesión (sid) que comparten todos sus tokens.
+type RevokedToken struct {
+	ID        string    `gorm:"primaryKey"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Session representa un login; su ID es el sid de los access tokens y el
+// FamilyID de los refresh tokens que se emiten para ese login.
+type Session struct {
+	ID         string `gorm:"primaryKey"`
+	Username   string `gorm:"index;not null"`
+	ClientIP   string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+}
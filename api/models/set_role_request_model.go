@@ -0,0 +1,5 @@
+package models
+
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Tag es una etiqueta de un usuario; el nombre es único por dueño. Se borra de verdad (sin
+// DeletedAt) para que el nombre quede libre y la relación con las tareas se limpie.
+type Tag struct {
+	ID        uint   `gorm:"primaryKey"`
+	Owner     string `gorm:"not null;uniqueIndex:idx_tags_owner_name"`
+	Name      string `gorm:"not null;uniqueIndex:idx_tags_owner_name"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
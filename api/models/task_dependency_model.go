@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// TaskDependency indica que TaskID no puede completarse mientras BlockerID siga pendiente.
+// Ambas tareas son del mismo dueño y las aristas forman un grafo sin ciclos.
+type TaskDependency struct {
+	TaskID    uint `gorm:"primaryKey"`
+	BlockerID uint `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,5 @@
+package models
+
+type BlockerRequest struct {
+	BlockerID uint `json:"blocker_id" binding:"required"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+const (
+	TaskEventCreated  = "created"
+	TaskEventUpdated  = "updated"
+	TaskEventDeleted  = "deleted"
+	TaskEventRestored = "restored"
+	TaskEventReverted = "reverted"
+	TaskEventPurged   = "purged"
+)
+
+// TaskEvent es una entrada del historial de una tarea; la tabla solo recibe inserciones.
+// Las actualizaciones dejan un evento por campo modificado con los valores en JSON; created, deleted,
+// restored, reverted y purged no llevan campo (reverted guarda en NewValue la versión restaurada).
+// Version es la de la tarea después del cambio. Los eventos sobreviven al borrado definitivo de la tarea.
+type TaskEvent struct {
+	ID        uint `gorm:"primaryKey"`
+	TaskID    uint `gorm:"index"`
+	Version   uint
+	Actor     string // username de quien hizo el cambio
+	Action    string
+	Field     string
+	OldValue  string
+	NewValue  string
+	RequestID string `gorm:"index"`
+	CreatedAt time.Time
+}
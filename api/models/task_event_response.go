@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type TaskEventResponse struct {
+	ID        uint            `json:"id"`
+	TaskID    uint            `json:"task_id"`
+	Version   uint            `json:"version"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Field     string          `json:"field,omitempty"`
+	OldValue  json.RawMessage `json:"old_value,omitempty" swaggertype:"object"`
+	NewValue  json.RawMessage `json:"new_value,omitempty" swaggertype:"object"`
+	RequestID string          `json:"request_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// TaskEventListResponse es una página del historial, del evento más reciente al más antiguo.
+type TaskEventListResponse struct {
+	Items      []TaskEventResponse `json:"items"`
+	NextCursor string              `json:"next_cursor"`
+}
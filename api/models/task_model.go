@@ -1,10 +1,36 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+const (
+	TaskPriorityLow    = "low"
+	TaskPriorityMedium = "medium"
+	TaskPriorityHigh   = "high"
+	TaskPriorityUrgent = "urgent"
+)
+
+// Task guarda DueAt y CompletedAt en UTC para que los filtros por fecha comparen bien en SQLite.
+// Version se incrementa en cada escritura y es el ETag. Las etiquetas viven en la tabla task_tags.
+// Blocked no es una columna: se calcula al leer a partir de task_dependencies.
+// Recurrence es una RRULE; al completar la tarea se crea la siguiente ocurrencia y su ID queda en NextOccurrenceID.
 type Task struct {
 	gorm.Model
-	Title     string `json:"title" binding:"required"`
-	Completed bool   `json:"completed"`
-	Owner     string `json:"-"` // el username dueño de la tarea
+	Title            string     `json:"title" binding:"required"`
+	Description      string     `json:"description"` // markdown
+	Completed        bool       `json:"completed"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	Priority         string     `json:"priority" gorm:"not null;default:medium"`
+	DueAt            *time.Time `json:"due_at" gorm:"index"`
+	Recurrence       string     `json:"recurrence"`
+	NextOccurrenceID *uint      `json:"next_occurrence_id"`
+	ProjectID        *uint      `json:"project_id" gorm:"index"` // nil: bandeja de entrada
+	ParentID         *uint      `json:"parent_id" gorm:"index"`  // nil: tarea raíz
+	Owner            string     `json:"-" gorm:"index"`          // el username dueño de la tarea
+	Version          uint       `json:"version" gorm:"not null;default:1"`
+	Tags             []Tag      `json:"-" gorm:"many2many:task_tags"`
+	Blocked          bool       `json:"blocked" gorm:"->;-:migration"`
 }
@@ -1,10 +1,28 @@
 package models
 
+import "time"
+
 type CreateTaskRequest struct {
-	Title string `json:"title" binding:"required"`
+	Title       string     `json:"title" binding:"required"`
+	Description string     `json:"description"`
+	Priority    string     `json:"priority" example:"medium"`
+	DueAt       *time.Time `json:"due_at" example:"2026-12-31T18:00:00Z"`
+	Recurrence  string     `json:"recurrence" example:"FREQ=WEEKLY;BYDAY=MO"`
+	ProjectID   *uint      `json:"project_id"`
+	ParentID    *uint      `json:"parent_id"`
+	Tags        []string   `json:"tags"`
 }
 
+// UpdateTaskRequest reemplaza la tarea (sin project_id pasa a la bandeja de entrada y sin parent_id queda como raíz);
+// si tags se omite las etiquetas no cambian y [] las quita todas.
 type UpdateTaskRequest struct {
-	Title     string `json:"title" binding:"required"`
-	Completed bool   `json:"completed"`
+	Title       string     `json:"title" binding:"required"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	Priority    string     `json:"priority" example:"medium"`
+	DueAt       *time.Time `json:"due_at" example:"2026-12-31T18:00:00Z"`
+	Recurrence  string     `json:"recurrence" example:"FREQ=WEEKLY;BYDAY=MO"`
+	ProjectID   *uint      `json:"project_id"`
+	ParentID    *uint      `json:"parent_id"`
+	Tags        []string   `json:"tags"`
 }
@@ -1,8 +1,34 @@
 package models
 
+import "time"
+
 type TaskResponse struct {
-	ID        uint   `json:"id"`
-	Title     string `json:"title"`
-	Completed bool   `json:"completed"`
-	Owner     string `json:"owner"`
+	ID               uint       `json:"id"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description"`
+	Completed        bool       `json:"completed"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	Priority         string     `json:"priority"`
+	DueAt            *time.Time `json:"due_at"`
+	Recurrence       string     `json:"recurrence"`
+	NextOccurrenceID *uint      `json:"next_occurrence_id"`
+	ProjectID        *uint      `json:"project_id"`
+	ParentID         *uint      `json:"parent_id"`
+	Owner            string     `json:"owner"`
+	Version          uint       `json:"version"`
+	Tags             []string   `json:"tags"`
+	Blocked          bool       `json:"blocked"` // tiene bloqueos sin completar
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	// DeletedAt solo viene en las tareas de la papelera
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Subtasks solo se incluye con ?include=subtasks
+	Subtasks []TaskResponse `json:"subtasks,omitempty"`
+}
+
+// TaskListResponse es una página del listado; next_cursor va vacío en la última página.
+type TaskListResponse struct {
+	Items      []TaskResponse `json:"items"`
+	NextCursor string         `json:"next_cursor"`
+	Total      int64          `json:"total"`
 }
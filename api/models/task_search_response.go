@@ -0,0 +1,10 @@
+package models
+
+// TaskSearchResponse es una tarea encontrada por la búsqueda. title_highlight y snippet son HTML escapado
+// con las coincidencias entre <mark> y </mark>; snippet es un fragmento de la descripción.
+type TaskSearchResponse struct {
+	Task           TaskResponse `json:"task"`
+	Score          float64      `json:"score"`
+	TitleHighlight string       `json:"title_highlight"`
+	Snippet        string       `json:"snippet"`
+}
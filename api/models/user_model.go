@@ -0,0 +1,21 @@
+package models
+
+import "gorm.io/gorm"
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User guarda el secreto TOTP desde que inicia el enrolamiento, pero solo se
+// exige el segundo factor cuando TOTPEnabled es true.
+type User struct {
+	gorm.Model
+	Username        string `json:"username" gorm:"uniqueIndex;not null"`
+	PasswordHash    string `json:"-" gorm:"not null"`
+	Role            string `json:"role" gorm:"not null;default:user"`
+	Disabled        bool   `json:"disabled" gorm:"not null;default:false"`
+	TOTPSecret      string `json:"-"`
+	TOTPEnabled     bool   `json:"two_factor_enabled" gorm:"not null;default:false"`
+	TOTPLastCounter int64  `json:"-" gorm:"not null;default:0"`
+}
@@ -0,0 +1,9 @@
+package models
+
+type UserResponse struct {
+	ID               uint   `json:"id"`
+	Username         string `json:"username"`
+	Role             string `json:"role"`
+	Disabled         bool   `json:"disabled"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled"`
+}
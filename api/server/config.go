@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"prueba_tecnica_go_guarapo/api/models"
+	authServices "prueba_tecnica_go_guarapo/api/services/auth"
+	idempotencyServices "prueba_tecnica_go_guarapo/api/services/idempotency"
+	oidcServices "prueba_tecnica_go_guarapo/api/services/oidc"
+	taskServices "prueba_tecnica_go_guarapo/api/services/task"
+)
+
+func loadTokenConfig(logger *logrus.Logger) authServices.TokenConfig {
+	cfg := authServices.TokenConfig{
+		Algorithm:       os.Getenv("JWT_ALGORITHM"),
+		Secret:          os.Getenv("JWT_SECRET"),
+		PrivateKeyFile:  os.Getenv("JWT_PRIVATE_KEY_FILE"),
+		PublicKeyFile:   os.Getenv("JWT_PUBLIC_KEY_FILE"),
+		Issuer:          os.Getenv("JWT_ISSUER"),
+		AccessTokenTTL:  durationFromEnv(logger, "JWT_ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: durationFromEnv(logger, "JWT_REFRESH_TOKEN_TTL", 7*24*time.Hour),
+	}
+	if (cfg.Algorithm == "" || strings.EqualFold(cfg.Algorithm, authServices.AlgorithmHS256)) && cfg.Secret == "" {
+		bytes := make([]byte, 32)
+		_, _ = rand.Read(bytes)
+		cfg.Secret = hex.EncodeToString(bytes)
+		logger.Warn("[Layer: Server] [Method: loadTokenConfig] JWT_SECRET no definido, se usa un secreto aleatorio (los tokens no sobreviven reinicios)")
+	}
+	return cfg
+}
+
+func durationFromEnv(logger *logrus.Logger, key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		logger.Warnf("[Layer: Server] [Method: durationFromEnv] Valor inválido para %s: '%s', se usa %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+func intFromEnv(logger *logrus.Logger, key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		logger.Warnf("[Layer: Server] [Method: intFromEnv] Valor inválido para %s: '%s', se usa %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+func boolFromEnv(logger *logrus.Logger, key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		logger.Warnf("[Layer: Server] [Method: boolFromEnv] Valor inválido para %s: '%s', se usa %t", key, value, fallback)
+		return fallback
+	}
+	return b
+}
+
+func taskServiceOptions(db *gorm.DB, logger *logrus.Logger) []taskServices.Option {
+	var opts []taskServices.Option
+	switch enabled, err := taskServices.SetupTaskSearch(db); {
+	case err != nil:
+		logger.Error("[Layer: Server] [Method: taskServiceOptions] No se pudo crear el índice de búsqueda, se usa LIKE: ", err)
+	case enabled:
+		opts = append(opts, taskServices.WithFullTextSearch())
+	default:
+		logger.Warn("[Layer: Server] [Method: taskServiceOptions] SQLite sin FTS5 (compila con -tags sqlite_fts5), la búsqueda usa LIKE")
+	}
+	if boolFromEnv(logger, "TASK_AUTO_COMPLETE_PARENT", false) {
+		opts = append(opts, taskServices.WithParentAutoComplete())
+	}
+	if boolFromEnv(logger, "TASK_ALLOW_BLOCKED_COMPLETION", false) {
+		opts = append(opts, taskServices.WithBlockedCompletion())
+	}
+	return opts
+}
+
+func loadLockoutPolicy(logger *logrus.Logger) authServices.LockoutPolicy {
+	policy := authServices.DefaultLockoutPolicy()
+	policy.MaxFailures = intFromEnv(logger, "LOGIN_MAX_FAILURES", policy.MaxFailures)
+	policy.MaxIPFailures = intFromEnv(logger, "LOGIN_MAX_IP_FAILURES", policy.MaxIPFailures)
+	policy.LockoutDuration = durationFromEnv(logger, "LOGIN_LOCKOUT_DURATION", policy.LockoutDuration)
+	if policy.FreeAttempts >= policy.MaxFailures {
+		policy.FreeAttempts = policy.MaxFailures - 1
+	}
+	return policy
+}
+
+// loadOIDCConfig devuelve false si no hay proveedor configurado; en ese caso las rutas OIDC no se registran.
+func loadOIDCConfig(logger *logrus.Logger) (oidcServices.OIDCConfig, bool) {
+	cfg := oidcServices.OIDCConfig{
+		IssuerURL:    os.Getenv("OIDC_ISSUER_URL"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:       strings.Fields(os.Getenv("OIDC_SCOPES")),
+	}
+	if cfg.IssuerURL == "" {
+		return cfg, false
+	}
+	if cfg.ClientID == "" || cfg.RedirectURL == "" {
+		logger.Warn("[Layer: Server] [Method: loadOIDCConfig] OIDC_ISSUER_URL definido sin OIDC_CLIENT_ID u OIDC_REDIRECT_URL, login OIDC deshabilitado")
+		return cfg, false
+	}
+	return cfg, true
+}
+
+func newRevocationStore(db *gorm.DB, logger *logrus.Logger) authServices.RevocationStore {
+	switch strings.ToLower(os.Getenv("REVOCATION_STORE")) {
+	case "memory":
+		logger.Info("[Layer: Server] [Method: newRevocationStore] Usando lista de revocación en memoria")
+		return authServices.NewMemoryRevocationStore()
+	default:
+		return authServices.NewSQLiteRevocationStore(db)
+	}
+}
+
+func newAttemptStore(db *gorm.DB, logger *logrus.Logger) authServices.AttemptStore {
+	switch strings.ToLower(os.Getenv("LOGIN_ATTEMPT_STORE")) {
+	case "memory":
+		logger.Info("[Layer: Server] [Method: newAttemptStore] Usando contador de intentos de login en memoria")
+		return authServices.NewMemoryAttemptStore()
+	default:
+		return authServices.NewSQLiteAttemptStore(db)
+	}
+}
+
+func newIdempotencyStore(db *gorm.DB, logger *logrus.Logger) idempotencyServices.IdempotencyStore {
+	switch strings.ToLower(os.Getenv("IDEMPOTENCY_STORE")) {
+	case "memory":
+		logger.Info("[Layer: Server] [Method: newIdempotencyStore] Usando claves de idempotencia en memoria")
+		return idempotencyServices.NewMemoryIdempotencyStore()
+	default:
+		return idempotencyServices.NewSQLiteIdempotencyStore(db)
+	}
+}
+
+// bootstrapAdmin crea o promueve la cuenta indicada en ADMIN_USERNAME para
+// que exista al menos un administrador.
+func (s *Server) bootstrapAdmin(authService authServices.AuthService) {
+	username := os.Getenv("ADMIN_USERNAME")
+	if username == "" {
+		return
+	}
+	ctx := context.Background()
+	_, err := authService.Register(ctx, username, os.Getenv("ADMIN_PASSWORD"))
+	if err != nil && !errors.Is(err, authServices.ErrUsernameTaken) {
+		s.logger.Fatal("No se pudo crear el usuario administrador:", err)
+	}
+	if _, err := authService.SetUserRole(ctx, username, models.RoleAdmin); err != nil {
+		s.logger.Fatal("No se pudo asignar el rol de administrador:", err)
+	}
+}
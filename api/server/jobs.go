@@ -0,0 +1,19 @@
+package server
+
+import (
+	"context"
+	"time"
+)
+
+// runEvery ejecuta job en segundo plano cada interval mientras el proceso viva.
+func (s *Server) runEvery(name string, interval time.Duration, job func(ctx context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := job(context.Background()); err != nil {
+				s.logger.Errorf("[Layer: Server] [Method: runEvery] Job '%s' failed: %v", name, err)
+			}
+		}
+	}()
+}
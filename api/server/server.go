@@ -7,7 +7,13 @@ package server
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
+// @securityDefinitions.apikey APIKeyHeader
+// @in header
+// @name X-API-Key
 import (
+	"context"
+	"time"
+
 	_ "prueba_tecnica_go_guarapo/api/docs"
 
 	"github.com/gin-gonic/gin"
@@ -17,10 +23,13 @@ import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	apiKeyHandlers "prueba_tecnica_go_guarapo/api/handlers/apikey"
 	authHandlers "prueba_tecnica_go_guarapo/api/handlers/auth"
 	taskHandlers "prueba_tecnica_go_guarapo/api/handlers/task"
 	"prueba_tecnica_go_guarapo/api/models"
+	apiKeyServices "prueba_tecnica_go_guarapo/api/services/apikey"
 	authServices "prueba_tecnica_go_guarapo/api/services/auth"
+	oidcServices "prueba_tecnica_go_guarapo/api/services/oidc"
 	taskServices "prueba_tecnica_go_guarapo/api/services/task"
 	middleware "prueba_tecnica_go_guarapo/api/utils"
 )
@@ -33,11 +42,12 @@ type Server struct {
 
 func NewServer(logger *logrus.Logger) *Server {
 	router := gin.Default()
+	router.Use(middleware.RequestID())
 	db, err := gorm.Open(sqlite.Open("tasks.db"), &gorm.Config{})
 	if err != nil {
 		logger.Fatal("No se pudo conectar a la base de datos:", err)
 	}
-	db.AutoMigrate(&models.Task{})
+	db.AutoMigrate(&models.Task{}, &models.User{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.Session{}, &models.APIKey{}, &models.ExternalIdentity{}, &models.RecoveryCode{}, &models.LoginAttempt{}, &models.Tag{}, &models.Project{}, &models.TaskDependency{}, &models.TaskEvent{}, &models.IdempotencyKey{})
 	return &Server{
 		router: router,
 		logger: logger,
@@ -46,27 +56,170 @@ func NewServer(logger *logrus.Logger) *Server {
 }
 
 func (s *Server) Start(addr string) {
-	authService := authServices.NewAuthService(s.logger)
-	taskService := taskServices.NewTaskService(s.db, s.logger)
+	tokenManager, err := authServices.NewTokenManager(loadTokenConfig(s.logger))
+	if err != nil {
+		s.logger.Fatal("No se pudo configurar la firma de tokens:", err)
+	}
+	revocations := newRevocationStore(s.db, s.logger)
+	attempts := newAttemptStore(s.db, s.logger)
+	idempotencyKeys := newIdempotencyStore(s.db, s.logger)
+	lockoutPolicy := loadLockoutPolicy(s.logger)
+	authService := authServices.NewAuthService(s.db, tokenManager, revocations, s.logger,
+		authServices.WithLoginThrottle(attempts, lockoutPolicy))
+	s.bootstrapAdmin(authService)
+	apiKeyService := apiKeyServices.NewAPIKeyService(s.db, s.logger)
+	taskService := taskServices.NewTaskService(s.db, s.logger, taskServiceOptions(s.db, s.logger)...)
+	tagService := taskServices.NewTagService(s.db, s.logger)
+	projectService := taskServices.NewProjectService(s.db, s.logger)
 
 	authHandler := authHandlers.NewAuthHandler(authService, s.logger)
+	apiKeyHandler := apiKeyHandlers.NewAPIKeyHandler(apiKeyService, s.logger)
 	taskHandler := taskHandlers.NewTaskHandler(taskService, s.logger)
+	tagHandler := taskHandlers.NewTagHandler(tagService, s.logger)
+	projectHandler := taskHandlers.NewProjectHandler(projectService, s.logger)
+
+	auth := middleware.AuthMiddleware(authService, apiKeyService)
+	idempotency := middleware.Idempotency(idempotencyKeys, durationFromEnv(s.logger, "IDEMPOTENCY_KEY_TTL", 24*time.Hour))
 
 	api := s.router.Group("/api")
 	{
+		api.POST("/register", authHandler.Register)
 		api.POST("/login", authHandler.Login)
+		api.POST("/login/2fa", authHandler.LoginTwoFactor)
+		api.POST("/token/refresh", authHandler.Refresh)
+
+		if oidcConfig, ok := loadOIDCConfig(s.logger); ok {
+			oidcHandler := authHandlers.NewOIDCHandler(oidcServices.NewOIDCService(oidcConfig, s.logger), authService, s.logger)
+			oidc := api.Group("/auth/oidc")
+			{
+				oidc.GET("/login", oidcHandler.Login)
+				oidc.POST("/link", auth, middleware.RequireSession(), oidcHandler.Link)
+				oidc.GET("/callback", oidcHandler.Callback)
+			}
+		}
+
+		logout := api.Group("/logout")
+		logout.Use(auth, middleware.RequireSession())
+		{
+			logout.POST("", authHandler.Logout)
+			logout.POST("/all", authHandler.LogoutAll)
+		}
+
+		sessions := api.Group("/sessions")
+		sessions.Use(auth, middleware.RequireSession())
+		{
+			sessions.GET("", authHandler.ListSessions)
+			sessions.DELETE("/:id", authHandler.RevokeSession)
+		}
+
+		twoFactor := api.Group("/2fa")
+		twoFactor.Use(auth, middleware.RequireSession())
+		{
+			twoFactor.POST("/setup", authHandler.SetupTwoFactor)
+			twoFactor.POST("/verify", authHandler.VerifyTwoFactor)
+		}
+
+		keys := api.Group("/keys")
+		keys.Use(auth, middleware.RequireSession())
+		{
+			keys.GET("", apiKeyHandler.ListKeys)
+			keys.POST("", apiKeyHandler.CreateKey)
+			keys.DELETE("/:id", apiKeyHandler.RevokeKey)
+		}
 
 		tasks := api.Group("/tasks")
-		tasks.Use(middleware.AuthMiddleware(authService))
+		tasks.Use(auth, idempotency)
 		{
 			tasks.GET("", taskHandler.GetTasks)
+			tasks.GET("/trash", taskHandler.GetTrash)
+			tasks.GET("/search", taskHandler.SearchTasks)
 			tasks.GET("/:id", taskHandler.GetTask)
+			tasks.GET("/:id/subtasks", taskHandler.GetSubtasks)
+			tasks.GET("/:id/history", taskHandler.GetTaskHistory)
+			tasks.POST("/:id/revert", taskHandler.RevertTask)
+			tasks.GET("/:id/blockers", taskHandler.GetBlockers)
+			tasks.POST("/:id/blockers", taskHandler.AddBlocker)
+			tasks.DELETE("/:id/blockers/:blocker_id", taskHandler.RemoveBlocker)
 			tasks.POST("", taskHandler.CreateTask)
+			tasks.POST("/recurrence/preview", taskHandler.PreviewRecurrence)
+			tasks.POST("/bulk", taskHandler.BulkTasks)
 			tasks.PUT("/:id", taskHandler.UpdateTask)
+			tasks.PATCH("/:id", taskHandler.PatchTask)
 			tasks.DELETE("/:id", taskHandler.DeleteTask)
+			tasks.POST("/:id/restore", taskHandler.RestoreTask)
+		}
+
+		tags := api.Group("/tags")
+		tags.Use(auth)
+		{
+			tags.GET("", tagHandler.ListTags)
+			tags.GET("/:id", tagHandler.GetTag)
+			tags.POST("", tagHandler.CreateTag)
+			tags.PUT("/:id", tagHandler.UpdateTag)
+			tags.DELETE("/:id", tagHandler.DeleteTag)
+		}
+
+		projects := api.Group("/projects")
+		projects.Use(auth)
+		{
+			projects.GET("", projectHandler.ListProjects)
+			projects.GET("/:id", projectHandler.GetProject)
+			projects.POST("", projectHandler.CreateProject)
+			projects.PUT("/:id", projectHandler.UpdateProject)
+			projects.DELETE("/:id", projectHandler.DeleteProject)
+			projects.POST("/:id/archive", projectHandler.ArchiveProject)
+			projects.POST("/:id/unarchive", projectHandler.UnarchiveProject)
+			projects.GET("/:id/tasks", taskHandler.GetProjectTasks)
+			projects.POST("/:id/tasks", idempotency, taskHandler.CreateProjectTask)
+		}
+
+		admin := api.Group("/admin")
+		admin.Use(auth, middleware.RequireRole(models.RoleAdmin))
+		{
+			admin.GET("/users", authHandler.ListUsers)
+			admin.POST("/users/:username/disable", authHandler.DisableUser)
+			admin.POST("/users/:username/enable", authHandler.EnableUser)
+			admin.POST("/users/:username/unlock", authHandler.UnlockUser)
+			admin.PUT("/users/:username/role", authHandler.SetUserRole)
+			admin.GET("/users/:username/tasks", taskHandler.GetUserTasks)
+			admin.GET("/tasks", taskHandler.GetAllTasks)
+			admin.GET("/tasks/:id", taskHandler.GetTaskAsAdmin)
+			admin.DELETE("/tasks/:id", taskHandler.DeleteTaskAsAdmin)
 		}
 	}
 
+	s.runEvery("revocation-cleanup", 10*time.Minute, func(ctx context.Context) error {
+		deleted, err := revocations.DeleteExpired(ctx, time.Now())
+		if deleted > 0 {
+			s.logger.Infof("[Layer: Server] [Method: Start] Removed %d expired revocation entries", deleted)
+		}
+		return err
+	})
+	s.runEvery("login-attempts-cleanup", 10*time.Minute, func(ctx context.Context) error {
+		now := time.Now()
+		deleted, err := attempts.DeleteExpired(ctx, now, now.Add(-lockoutPolicy.Window))
+		if deleted > 0 {
+			s.logger.Infof("[Layer: Server] [Method: Start] Removed %d stale login attempt entries", deleted)
+		}
+		return err
+	})
+
+	trashRetention := durationFromEnv(s.logger, "TASK_TRASH_RETENTION", taskServices.DefaultTrashRetention)
+	s.runEvery("trash-purge", time.Hour, func(ctx context.Context) error {
+		purged, err := taskService.PurgeTrash(ctx, time.Now().Add(-trashRetention))
+		if purged > 0 {
+			s.logger.Infof("[Layer: Server] [Method: Start] Purged %d tasks from the trash", purged)
+		}
+		return err
+	})
+	s.runEvery("idempotency-cleanup", 10*time.Minute, func(ctx context.Context) error {
+		deleted, err := idempotencyKeys.DeleteExpired(ctx, time.Now())
+		if deleted > 0 {
+			s.logger.Infof("[Layer: Server] [Method: Start] Removed %d expired idempotency keys", deleted)
+		}
+		return err
+	})
+
 	s.router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	s.logger.Infof("[Layer: Server] [Method: Start] Server listened in %s", addr)
 	s.router.Run(addr)
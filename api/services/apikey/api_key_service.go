@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"prueba_tecnica_go_guarapo/api/models"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	keyPrefix = "gk_"
+	// lastUsedInterval evita escribir en la base de datos en cada petición con la misma llave.
+	lastUsedInterval = time.Minute
+)
+
+type APIKeyService interface {
+	CreateKey(ctx context.Context, username, name, scope string, expiresAt *time.Time) (*models.APIKey, string, error)
+	ListKeys(ctx context.Context, username string) ([]*models.APIKey, error)
+	RevokeKey(ctx context.Context, id int, username string) error
+	ValidateKey(ctx context.Context, rawKey string) (*models.APIKey, error)
+}
+
+type apiKeyService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewAPIKeyService(db *gorm.DB, logger *logrus.Logger) APIKeyService {
+	return &apiKeyService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *apiKeyService) CreateKey(ctx context.Context, username, name, scope string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		s.logger.Errorln("[Layer: api_key_service] [Method: CreateKey] Error: Name is required")
+		return nil, "", ErrNameRequired
+	}
+	if scope == "" {
+		scope = models.APIKeyScopeRead
+	}
+	if scope != models.APIKeyScopeRead && scope != models.APIKeyScopeReadWrite {
+		s.logger.Warnf("[Layer: api_key_service] [Method: CreateKey] Warning: Invalid scope '%s'", scope)
+		return nil, "", ErrInvalidScope
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		s.logger.Warnf("[Layer: api_key_service] [Method: CreateKey] Warning: Expiry in the past for user '%s'", username)
+		return nil, "", ErrInvalidExpiry
+	}
+
+	raw, err := generateKey()
+	if err != nil {
+		s.logger.Error("[Layer: api_key_service] [Method: CreateKey] Error: ", err)
+		return nil, "", err
+	}
+	key := &models.APIKey{
+		Username:  username,
+		Name:      name,
+		Prefix:    raw[:len(keyPrefix)+8],
+		KeyHash:   hashKey(raw),
+		Scope:     scope,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.db.WithContext(ctx).Create(key).Error; err != nil {
+		s.logger.Error("[Layer: api_key_service] [Method: CreateKey] Error: ", err)
+		return nil, "", err
+	}
+	s.logger.Infof("[Layer: api_key_service] [Method: CreateKey] Info: API key '%d' (%s) created for user '%s'", key.ID, key.Prefix, username)
+	return key, raw, nil
+}
+
+func (s *apiKeyService) ListKeys(ctx context.Context, username string) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	if err := s.db.WithContext(ctx).
+		Where("username = ? AND revoked_at IS NULL", username).
+		Order("id").
+		Find(&keys).Error; err != nil {
+		s.logger.Error("[Layer: api_key_service] [Method: ListKeys] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: api_key_service] [Method: ListKeys] Info: User '%s' requested their API keys", username)
+	return keys, nil
+}
+
+func (s *apiKeyService) RevokeKey(ctx context.Context, id int, username string) error {
+	result := s.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND username = ? AND revoked_at IS NULL", id, username).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		s.logger.Error("[Layer: api_key_service] [Method: RevokeKey] Error: ", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		s.logger.Warnf("[Layer: api_key_service] [Method: RevokeKey] Warning: API key '%d' not found or not owned by user '%s'", id, username)
+		return ErrAPIKeyNotFound
+	}
+	s.logger.Infof("[Layer: api_key_service] [Method: RevokeKey] Info: API key '%d' revoked for user '%s'", id, username)
+	return nil
+}
+
+func (s *apiKeyService) ValidateKey(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := s.db.WithContext(ctx).Where("key_hash = ?", hashKey(rawKey)).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Warnln("[Layer: api_key_service] [Method: ValidateKey] Warning: Unknown API key")
+			return nil, ErrAPIKeyInvalid
+		}
+		s.logger.Error("[Layer: api_key_service] [Method: ValidateKey] Error: ", err)
+		return nil, err
+	}
+	now := time.Now()
+	if key.RevokedAt != nil {
+		s.logger.Warnf("[Layer: api_key_service] [Method: ValidateKey] Warning: API key '%s' is revoked", key.Prefix)
+		return nil, ErrAPIKeyRevoked
+	}
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		s.logger.Warnf("[Layer: api_key_service] [Method: ValidateKey] Warning: API key '%s' has expired", key.Prefix)
+		return nil, ErrAPIKeyExpired
+	}
+
+	var disabled int64
+	if err := s.db.WithContext(ctx).Model(&models.User{}).
+		Where("username = ? AND disabled = ?", key.Username, true).
+		Count(&disabled).Error; err != nil {
+		s.logger.Error("[Layer: api_key_service] [Method: ValidateKey] Error: ", err)
+		return nil, err
+	}
+	if disabled > 0 {
+		s.logger.Warnf("[Layer: api_key_service] [Method: ValidateKey] Warning: Owner of API key '%s' is disabled", key.Prefix)
+		return nil, ErrOwnerDisabled
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedInterval {
+		if err := s.db.WithContext(ctx).Model(&key).Update("last_used_at", now).Error; err != nil {
+			s.logger.Error("[Layer: api_key_service] [Method: ValidateKey] Error: ", err)
+		}
+	}
+	s.logger.Infof("[Layer: api_key_service] [Method: ValidateKey] Info: API key '%s' is valid for user '%s'", key.Prefix, key.Username)
+	return &key, nil
+}
+
+func generateKey() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return keyPrefix + hex.EncodeToString(bytes), nil
+}
+
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&models.User{}, &models.APIKey{}))
+	return db
+}
+
+func TestAPIKeyService_CreateKey(t *testing.T) {
+	service := NewAPIKeyService(setupTestDB(t), logrus.New())
+	ctx := context.Background()
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	testScenarios := []struct {
+		testName  string
+		name      string
+		scope     string
+		expiresAt *time.Time
+		wantScope string
+		wantErr   error
+	}{
+		{
+			testName: "Nombre vacío",
+			name:     "  ",
+			wantErr:  ErrNameRequired,
+		},
+		{
+			testName: "Scope inválido",
+			name:     "ci",
+			scope:    "admin",
+			wantErr:  ErrInvalidScope,
+		},
+		{
+			testName:  "Expiración en el pasado",
+			name:      "ci",
+			expiresAt: &past,
+			wantErr:   ErrInvalidExpiry,
+		},
+		{
+			testName:  "Scope por defecto de solo lectura",
+			name:      "ci",
+			wantScope: models.APIKeyScopeRead,
+		},
+		{
+			testName:  "Lectura y escritura con expiración",
+			name:      "deploy",
+			scope:     models.APIKeyScopeReadWrite,
+			expiresAt: &future,
+			wantScope: models.APIKeyScopeReadWrite,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			key, raw, err := service.CreateKey(ctx, "user1", tt.name, tt.scope, tt.expiresAt)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, key)
+				assert.Empty(t, raw)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantScope, key.Scope)
+			assert.True(t, strings.HasPrefix(raw, key.Prefix))
+			assert.NotContains(t, key.KeyHash, raw)
+		})
+	}
+}
+
+func TestAPIKeyService_ValidateKey(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAPIKeyService(db, logrus.New())
+	ctx := context.Background()
+	assert.NoError(t, db.Create(&models.User{Username: "user1", PasswordHash: "x"}).Error)
+	assert.NoError(t, db.Create(&models.User{Username: "user2", PasswordHash: "x", Disabled: true}).Error)
+
+	_, valid, err := service.CreateKey(ctx, "user1", "ci", "", nil)
+	assert.NoError(t, err)
+	revoked, revokedRaw, err := service.CreateKey(ctx, "user1", "old", "", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, service.RevokeKey(ctx, int(revoked.ID), "user1"))
+	expiring := time.Now().Add(time.Hour)
+	expired, expiredRaw, err := service.CreateKey(ctx, "user1", "temp", "", &expiring)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Model(expired).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	_, disabledRaw, err := service.CreateKey(ctx, "user2", "ci", "", nil)
+	assert.NoError(t, err)
+
+	testScenarios := []struct {
+		testName string
+		rawKey   string
+		wantErr  error
+	}{
+		{
+			testName: "Llave desconocida",
+			rawKey:   "gk_unknown",
+			wantErr:  ErrAPIKeyInvalid,
+		},
+		{
+			testName: "Llave revocada",
+			rawKey:   revokedRaw,
+			wantErr:  ErrAPIKeyRevoked,
+		},
+		{
+			testName: "Llave expirada",
+			rawKey:   expiredRaw,
+			wantErr:  ErrAPIKeyExpired,
+		},
+		{
+			testName: "Dueño deshabilitado",
+			rawKey:   disabledRaw,
+			wantErr:  ErrOwnerDisabled,
+		},
+		{
+			testName: "Llave válida",
+			rawKey:   valid,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			key, err := service.ValidateKey(ctx, tt.rawKey)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, key)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "user1", key.Username)
+			assert.NotNil(t, key.LastUsedAt)
+		})
+	}
+}
+
+func TestAPIKeyService_ListAndRevoke(t *testing.T) {
+	service := NewAPIKeyService(setupTestDB(t), logrus.New())
+	ctx := context.Background()
+
+	first, _, err := service.CreateKey(ctx, "user1", "ci", "", nil)
+	assert.NoError(t, err)
+	_, _, err = service.CreateKey(ctx, "user1", "deploy", models.APIKeyScopeReadWrite, nil)
+	assert.NoError(t, err)
+	_, _, err = service.CreateKey(ctx, "user2", "ci", "", nil)
+	assert.NoError(t, err)
+
+	keys, err := service.ListKeys(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	// Otro usuario no puede revocar la llave
+	assert.ErrorIs(t, service.RevokeKey(ctx, int(first.ID), "user2"), ErrAPIKeyNotFound)
+	assert.NoError(t, service.RevokeKey(ctx, int(first.ID), "user1"))
+	assert.ErrorIs(t, service.RevokeKey(ctx, int(first.ID), "user1"), ErrAPIKeyNotFound)
+
+	keys, err = service.ListKeys(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Equal(t, "deploy", keys[0].Name)
+}
@@ -0,0 +1,14 @@
+package services
+
+import "errors"
+
+var (
+	ErrNameRequired   = errors.New("api key name is required")
+	ErrInvalidScope   = errors.New("invalid api key scope")
+	ErrInvalidExpiry  = errors.New("api key expiry must be in the future")
+	ErrAPIKeyNotFound = errors.New("api key not found or not owned by user")
+	ErrAPIKeyInvalid  = errors.New("api key is invalid")
+	ErrAPIKeyExpired  = errors.New("api key has expired")
+	ErrAPIKeyRevoked  = errors.New("api key has been revoked")
+	ErrOwnerDisabled  = errors.New("api key owner is disabled")
+)
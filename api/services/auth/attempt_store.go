@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AttemptStore guarda los intentos fallidos de login. RegisterFailure debe ser
+// atómico para que peticiones concurrentes no eviten el bloqueo.
+type AttemptStore interface {
+	// RegisterFailure suma un fallo a la clave y devuelve el total; si el último
+	// fallo es anterior a now-window el conteo vuelve a empezar.
+	RegisterFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error)
+	LockUntil(ctx context.Context, key string, until time.Time) error
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+	Reset(ctx context.Context, key string) error
+	// DeleteExpired borra las claves sin bloqueo vigente cuyo último fallo es anterior a before.
+	DeleteExpired(ctx context.Context, now, before time.Time) (int64, error)
+}
+
+type memoryAttemptStore struct {
+	entries map[string]models.LoginAttempt
+	mutex   sync.Mutex
+}
+
+func NewMemoryAttemptStore() AttemptStore {
+	return &memoryAttemptStore{
+		entries: make(map[string]models.LoginAttempt),
+	}
+}
+
+func (s *memoryAttemptStore) RegisterFailure(_ context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry := s.entries[key]
+	if entry.LastFailureAt.Before(now.Add(-window)) {
+		entry.Failures = 0
+	}
+	entry.Key = key
+	entry.Failures++
+	entry.LastFailureAt = now
+	s.entries[key] = entry
+	return entry.Failures, nil
+}
+
+func (s *memoryAttemptStore) LockUntil(_ context.Context, key string, until time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry := s.entries[key]
+	entry.Key = key
+	if until.After(entry.LockedUntil) {
+		entry.LockedUntil = until
+	}
+	s.entries[key] = entry
+	return nil
+}
+
+func (s *memoryAttemptStore) LockedUntil(_ context.Context, key string) (time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.entries[key].LockedUntil, nil
+}
+
+func (s *memoryAttemptStore) Reset(_ context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *memoryAttemptStore) DeleteExpired(_ context.Context, now, before time.Time) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var deleted int64
+	for key, entry := range s.entries {
+		if !entry.LockedUntil.After(now) && entry.LastFailureAt.Before(before) {
+			delete(s.entries, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+type sqliteAttemptStore struct {
+	db *gorm.DB
+}
+
+func NewSQLiteAttemptStore(db *gorm.DB) AttemptStore {
+	return &sqliteAttemptStore{db: db}
+}
+
+func (s *sqliteAttemptStore) RegisterFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	var failures int
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		entry := &models.LoginAttempt{Key: key, Failures: 1, LastFailureAt: now}
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Set{
+				{Column: clause.Column{Name: "failures"}, Value: gorm.Expr("CASE WHEN last_failure_at < ? THEN 1 ELSE failures + 1 END", now.Add(-window))},
+				{Column: clause.Column{Name: "last_failure_at"}, Value: now},
+			},
+		}).Create(entry).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.LoginAttempt{}).Where("key = ?", key).Pluck("failures", &failures).Error
+	})
+	return failures, err
+}
+
+func (s *sqliteAttemptStore) LockUntil(ctx context.Context, key string, until time.Time) error {
+	entry := &models.LoginAttempt{Key: key, LastFailureAt: until, LockedUntil: until}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Set{{Column: clause.Column{Name: "locked_until"}, Value: gorm.Expr("MAX(locked_until, excluded.locked_until)")}},
+	}).Create(entry).Error
+}
+
+func (s *sqliteAttemptStore) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	var entries []models.LoginAttempt
+	if err := s.db.WithContext(ctx).Where("key = ?", key).Limit(1).Find(&entries).Error; err != nil {
+		return time.Time{}, err
+	}
+	if len(entries) == 0 {
+		return time.Time{}, nil
+	}
+	return entries[0].LockedUntil, nil
+}
+
+func (s *sqliteAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+func (s *sqliteAttemptStore) DeleteExpired(ctx context.Context, now, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).
+		Where("(locked_until IS NULL OR locked_until <= ?) AND last_failure_at < ?", now, before).
+		Delete(&models.LoginAttempt{})
+	return result.RowsAffected, result.Error
+}
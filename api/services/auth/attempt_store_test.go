@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttemptStores(t *testing.T) {
+	testScenarios := []struct {
+		testName string
+		newStore func(t *testing.T) AttemptStore
+	}{
+		{
+			testName: "En memoria",
+			newStore: func(*testing.T) AttemptStore { return NewMemoryAttemptStore() },
+		},
+		{
+			testName: "SQLite",
+			newStore: func(t *testing.T) AttemptStore { return NewSQLiteAttemptStore(setupTestDB(t)) },
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			store := tt.newStore(t)
+			ctx := context.Background()
+			now := time.Now()
+
+			until, err := store.LockedUntil(ctx, "user:ana")
+			assert.NoError(t, err)
+			assert.True(t, until.IsZero())
+
+			for i := 1; i <= 3; i++ {
+				failures, err := store.RegisterFailure(ctx, "user:ana", now, time.Minute)
+				assert.NoError(t, err)
+				assert.Equal(t, i, failures)
+			}
+			// un fallo fuera de la ventana reinicia el conteo
+			failures, err := store.RegisterFailure(ctx, "user:ana", now.Add(2*time.Minute), time.Minute)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, failures)
+
+			assert.NoError(t, store.LockUntil(ctx, "user:ana", now.Add(time.Hour)))
+			// bloquear de nuevo no acorta el bloqueo
+			assert.NoError(t, store.LockUntil(ctx, "user:ana", now.Add(time.Minute)))
+			until, err = store.LockedUntil(ctx, "user:ana")
+			assert.NoError(t, err)
+			assert.WithinDuration(t, now.Add(time.Hour), until, time.Millisecond)
+
+			_, err = store.RegisterFailure(ctx, "ip:10.0.0.1", now, time.Minute)
+			assert.NoError(t, err)
+			// solo se borra la clave sin bloqueo vigente
+			deleted, err := store.DeleteExpired(ctx, now.Add(10*time.Minute), now.Add(5*time.Minute))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), deleted)
+
+			assert.NoError(t, store.Reset(ctx, "user:ana"))
+			until, err = store.LockedUntil(ctx, "user:ana")
+			assert.NoError(t, err)
+			assert.True(t, until.IsZero())
+		})
+	}
+}
+
+func TestAttemptStores_ConcurrentFailures(t *testing.T) {
+	testScenarios := []struct {
+		testName string
+		newStore func(t *testing.T) AttemptStore
+	}{
+		{
+			testName: "En memoria",
+			newStore: func(*testing.T) AttemptStore { return NewMemoryAttemptStore() },
+		},
+		{
+			testName: "SQLite",
+			newStore: func(t *testing.T) AttemptStore {
+				db := setupTestDB(t)
+				// con :memory: cada conexión sería una base distinta
+				sqlDB, err := db.DB()
+				assert.NoError(t, err)
+				sqlDB.SetMaxOpenConns(1)
+				return NewSQLiteAttemptStore(db)
+			},
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			store := tt.newStore(t)
+			ctx := context.Background()
+			now := time.Now()
+
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := store.RegisterFailure(ctx, "user:ana", now, time.Minute)
+					assert.NoError(t, err)
+				}()
+			}
+			wg.Wait()
+
+			failures, err := store.RegisterFailure(ctx, "user:ana", now, time.Minute)
+			assert.NoError(t, err)
+			assert.Equal(t, 21, failures)
+		})
+	}
+}
@@ -2,62 +2,384 @@ package services
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
-	"sync"
+	"errors"
+	"prueba_tecnica_go_guarapo/api/models"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+const minPasswordLength = 8
+
 type AuthService interface {
-	Login(ctx context.Context, username string) string
-	ValidateToken(token string) (string, bool)
+	Register(ctx context.Context, username, password string) (*models.User, error)
+	VerifyCredentials(ctx context.Context, username, password string) (*models.User, error)
+	Login(ctx context.Context, username, password string, client ClientInfo) (*LoginResult, error)
+	CompleteTwoFactorLogin(ctx context.Context, challengeToken, code string, client ClientInfo) (*TokenPair, error)
+	SetupTOTP(ctx context.Context, username string) (*TOTPSetup, error)
+	EnableTOTP(ctx context.Context, username, code string) ([]string, error)
+	LoginExternal(ctx context.Context, identity ExternalIdentity, client ClientInfo) (*LoginResult, error)
+	LinkExternalIdentity(ctx context.Context, username string, identity ExternalIdentity) error
+	Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error)
+	ValidateToken(ctx context.Context, token string) (*TokenClaims, error)
+	Logout(ctx context.Context, claims *TokenClaims) error
+	LogoutAll(ctx context.Context, username string) error
+	ListSessions(ctx context.Context, username string) ([]*models.Session, error)
+	RevokeSession(ctx context.Context, username, sessionID string) error
+	TouchSession(ctx context.Context, sessionID string, client ClientInfo) error
+	ListUsers(ctx context.Context) ([]*models.User, error)
+	SetUserDisabled(ctx context.Context, username string, disabled bool) (*models.User, error)
+	SetUserRole(ctx context.Context, username, role string) (*models.User, error)
+	UnlockUser(ctx context.Context, username string) error
+}
+
+// ClientInfo identifica desde dónde se usa una sesión.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+}
+
+// LoginResult trae el par de tokens, o solo ChallengeToken si la cuenta exige
+// un segundo factor que debe canjearse con CompleteTwoFactorLogin.
+type LoginResult struct {
+	*TokenPair
+	ChallengeToken     string
+	ChallengeExpiresIn time.Duration
 }
 
 type authService struct {
-	tokens map[string]string
-	mutex  sync.RWMutex
-	logger *logrus.Logger
+	db          *gorm.DB
+	tokens      *TokenManager
+	revocations RevocationStore
+	logger      *logrus.Logger
+	// hash usado cuando el usuario no existe para que la respuesta tarde lo mismo
+	dummyHash []byte
+	// intentos fallidos por token de desafío 2FA
+	challenges *challengeAttempts
+	guard      *loginGuard
+}
+
+func NewAuthService(db *gorm.DB, tokens *TokenManager, revocations RevocationStore, logger *logrus.Logger, opts ...Option) AuthService {
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	s := &authService{
+		db:          db,
+		tokens:      tokens,
+		revocations: revocations,
+		logger:      logger,
+		dummyHash:   dummyHash,
+		challenges:  newChallengeAttempts(),
+		guard:       newLoginGuard(NewMemoryAttemptStore(), DefaultLockoutPolicy()),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *authService) Register(ctx context.Context, username, password string) (*models.User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		s.logger.Errorln("[Layer: auth_service] [Method: Register] Error: Username is required")
+		return nil, ErrUsernameRequired
+	}
+	if len(password) < minPasswordLength {
+		s.logger.Warnf("[Layer: auth_service] [Method: Register] Warning: Password too short for user '%s'", username)
+		return nil, ErrPasswordTooShort
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: Register] Error: ", err)
+		return nil, err
+	}
+	if count > 0 {
+		s.logger.Warnf("[Layer: auth_service] [Method: Register] Warning: Username '%s' already registered", username)
+		return nil, ErrUsernameTaken
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: Register] Error: ", err)
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:     username,
+		PasswordHash: string(hash),
+		Role:         models.RoleUser,
+	}
+	if err := s.db.WithContext(ctx).Create(user).Error; err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: Register] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: Register] Info: User '%s' registered with id '%d'", username, user.ID)
+	return user, nil
+}
+
+func (s *authService) VerifyCredentials(ctx context.Context, username, password string) (*models.User, error) {
+	username = strings.TrimSpace(username)
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			_ = bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+			s.logger.Warnf("[Layer: auth_service] [Method: VerifyCredentials] Warning: Unknown user '%s'", username)
+			return nil, ErrInvalidCredentials
+		}
+		s.logger.Error("[Layer: auth_service] [Method: VerifyCredentials] Error: ", err)
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		s.logger.Warnf("[Layer: auth_service] [Method: VerifyCredentials] Warning: Wrong password for user '%s'", username)
+		return nil, ErrInvalidCredentials
+	}
+	return &user, nil
+}
+
+func (s *authService) Login(ctx context.Context, username, password string, client ClientInfo) (*LoginResult, error) {
+	username = strings.TrimSpace(username)
+	if err := s.guard.check(ctx, username, client.IP); err != nil {
+		s.logger.Warnf("[Layer: auth_service] [Method: Login] Warning: Login for user '%s' from '%s' throttled: %v", username, client.IP, err)
+		return nil, err
+	}
+	user, err := s.VerifyCredentials(ctx, username, password)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			if guardErr := s.guard.fail(ctx, username, client.IP); guardErr != nil {
+				s.logger.Error("[Layer: auth_service] [Method: Login] Error: ", guardErr)
+			}
+		}
+		return nil, err
+	}
+	if user.Disabled {
+		s.logger.Warnf("[Layer: auth_service] [Method: Login] Warning: Disabled user '%s' tried to log in", user.Username)
+		return nil, ErrUserDisabled
+	}
+	if user.TOTPEnabled {
+		// el contador de fallos se reinicia recién al verificar el código: si se reiniciara con la
+		// contraseña, repetir el login permitiría adivinar códigos TOTP sin límite
+		result, err := s.twoFactorChallenge(user)
+		if err != nil {
+			s.logger.Error("[Layer: auth_service] [Method: Login] Error: ", err)
+			return nil, err
+		}
+		s.logger.Infof("[Layer: auth_service] [Method: Login] Info: User '%s' must complete two-factor login", user.Username)
+		return result, nil
+	}
+
+	if err := s.guard.succeed(ctx, username); err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: Login] Error: ", err)
+	}
+	pair, familyID, err := s.startSession(ctx, user, client)
+	if err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: Login] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: Login] Info: User '%s' logged in with token family '%s'", user.Username, familyID)
+	return &LoginResult{TokenPair: pair}, nil
 }
 
-func NewAuthService(logger *logrus.Logger) AuthService {
-	return &authService{
-		tokens: make(map[string]string),
-		logger: logger,
+// twoFactorChallenge emite el desafío que se canjea con CompleteTwoFactorLogin en lugar de los tokens.
+func (s *authService) twoFactorChallenge(user *models.User) (*LoginResult, error) {
+	challenge, claims, err := s.tokens.IssueChallenge(user.Username)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{
+		ChallengeToken:     challenge,
+		ChallengeExpiresIn: claims.ExpiresAt.Sub(claims.IssuedAt.Time),
+	}, nil
+}
+
+// startSession crea la sesión y su primer par de tokens para un usuario ya autenticado.
+func (s *authService) startSession(ctx context.Context, user *models.User, client ClientInfo) (*TokenPair, string, error) {
+	familyID, err := newTokenID()
+	if err != nil {
+		return nil, "", err
+	}
+	var pair *TokenPair
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		session := &models.Session{
+			ID:         familyID,
+			Username:   user.Username,
+			ClientIP:   client.IP,
+			UserAgent:  client.UserAgent,
+			CreatedAt:  now,
+			LastUsedAt: now,
+		}
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		var err error
+		pair, err = s.issueTokenPair(tx, user, familyID)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
 	}
+	return pair, familyID, nil
 }
 
-func (s *authService) Login(ctx context.Context, username string) string {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+func (s *authService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error) {
+	var stored models.RefreshToken
+	var pair *TokenPair
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ?", hashToken(refreshToken)).First(&stored).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+		if stored.RevokedAt != nil {
+			return ErrRefreshTokenInvalid
+		}
+		if stored.UsedAt != nil {
+			return ErrRefreshTokenReused
+		}
+		now := time.Now()
+		if now.After(stored.ExpiresAt) {
+			return ErrRefreshTokenExpired
+		}
+
+		// la condición sobre used_at evita que dos peticiones concurrentes roten el mismo token
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", stored.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+		var user models.User
+		if err := tx.Where("username = ?", stored.Username).First(&user).Error; err != nil {
+			return err
+		}
+		if user.Disabled {
+			return ErrUserDisabled
+		}
+		if err := tx.Model(&models.Session{}).Where("id = ?", stored.FamilyID).Updates(map[string]interface{}{
+			"last_used_at": now,
+			"client_ip":    client.IP,
+			"user_agent":   client.UserAgent,
+		}).Error; err != nil {
+			return err
+		}
 
-	token := s.generateToken()
-	s.tokens[token] = username
-	s.logger.Infof("[Layer: auth_service] [Method: Login] Info: User '%s' logged in with token '%s'\n", username, token)
-	return token
+		var err error
+		pair, err = s.issueTokenPair(tx, &user, stored.FamilyID)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrRefreshTokenReused):
+			s.logger.Warnf("[Layer: auth_service] [Method: Refresh] Warning: Reuse detected for token family '%s' of user '%s'", stored.FamilyID, stored.Username)
+			if revokeErr := s.revokeFamily(ctx, stored.FamilyID); revokeErr != nil {
+				s.logger.Error("[Layer: auth_service] [Method: Refresh] Error: ", revokeErr)
+				return nil, revokeErr
+			}
+		case errors.Is(err, ErrRefreshTokenInvalid), errors.Is(err, ErrRefreshTokenExpired), errors.Is(err, ErrUserDisabled):
+			s.logger.Warnf("[Layer: auth_service] [Method: Refresh] Warning: Rejected refresh token: %v", err)
+		default:
+			s.logger.Error("[Layer: auth_service] [Method: Refresh] Error: ", err)
+		}
+		return nil, err
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: Refresh] Info: Token family '%s' of user '%s' rotated", stored.FamilyID, stored.Username)
+	return pair, nil
+}
+
+func (s *authService) ValidateToken(ctx context.Context, token string) (*TokenClaims, error) {
+	claims, err := s.tokens.Parse(token)
+	if err != nil {
+		s.logger.Warnf("[Layer: auth_service] [Method: ValidateToken] Warning: Rejected token: %v", err)
+		return nil, err
+	}
+	for _, id := range []string{claims.ID, claims.SessionID} {
+		if id == "" {
+			continue
+		}
+		revoked, err := s.revocations.IsRevoked(ctx, id)
+		if err != nil {
+			s.logger.Error("[Layer: auth_service] [Method: ValidateToken] Error: ", err)
+			return nil, err
+		}
+		if revoked {
+			s.logger.Warnf("[Layer: auth_service] [Method: ValidateToken] Warning: Token '%s' of user '%s' is revoked", claims.ID, claims.Subject)
+			return nil, ErrTokenRevoked
+		}
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: ValidateToken] Info: Token '%s' is valid for user '%s'", claims.ID, claims.Subject)
+	return claims, nil
 }
 
-func (s *authService) ValidateToken(token string) (string, bool) {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
+func (s *authService) Logout(ctx context.Context, claims *TokenClaims) error {
+	if err := s.revocations.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: Logout] Error: ", err)
+		return err
+	}
+	if claims.SessionID != "" {
+		if err := s.revokeFamily(ctx, claims.SessionID); err != nil {
+			s.logger.Error("[Layer: auth_service] [Method: Logout] Error: ", err)
+			return err
+		}
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: Logout] Info: User '%s' logged out token '%s'", claims.Subject, claims.ID)
+	return nil
+}
 
-	username, exists := s.tokens[token]
-	if exists {
-		s.logger.Infof("[Layer: auth_service] [Method: ValidateToken] Info: Token '%s' is valid for user '%s'\n", token, username)
-	} else {
-		s.logger.Warnf("[Layer: auth_service] [Method: ValidateToken] Warning: Invalid token '%s'\n", token)
+func (s *authService) LogoutAll(ctx context.Context, username string) error {
+	var sessionIDs []string
+	if err := s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("username = ? AND revoked_at IS NULL", username).
+		Pluck("id", &sessionIDs).Error; err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: LogoutAll] Error: ", err)
+		return err
 	}
-	return username, exists
+	for _, sessionID := range sessionIDs {
+		if err := s.revokeFamily(ctx, sessionID); err != nil {
+			s.logger.Error("[Layer: auth_service] [Method: LogoutAll] Error: ", err)
+			return err
+		}
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: LogoutAll] Info: Revoked %d sessions of user '%s'", len(sessionIDs), username)
+	return nil
 }
 
-func (s *authService) generateToken() string {
-	bytes := make([]byte, 16)
-	_, err := rand.Read(bytes)
+func (s *authService) issueTokenPair(db *gorm.DB, user *models.User, familyID string) (*TokenPair, error) {
+	accessToken, _, err := s.tokens.Issue(user.Username, familyID, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	rawRefresh, refreshHash, expiresAt, err := s.tokens.NewRefreshToken()
 	if err != nil {
-		s.logger.Errorf("[Layer: auth_service] [Method: generateToken] Error: Failed to generate random bytes: %v\n", err)
-		return ""
+		return nil, err
+	}
+	record := &models.RefreshToken{
+		TokenHash: refreshHash,
+		FamilyID:  familyID,
+		Username:  user.Username,
+		ExpiresAt: expiresAt,
+	}
+	if err := db.Create(record).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.Session{}).Where("id = ?", familyID).Update("expires_at", expiresAt).Error; err != nil {
+		return nil, err
 	}
-	token := hex.EncodeToString(bytes)
-	s.logger.Infof("[Layer: auth_service] [Method: generateToken] Info: Generated token '%s'\n", token)
-	return token
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawRefresh,
+		ExpiresIn:    s.tokens.AccessTokenTTL(),
+	}, nil
 }
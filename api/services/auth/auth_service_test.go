@@ -2,24 +2,116 @@ package services
 
 import (
 	"context"
+	"prueba_tecnica_go_guarapo/api/models"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&models.User{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.Session{}, &models.ExternalIdentity{}, &models.RecoveryCode{}, &models.LoginAttempt{}))
+	return db
+}
+
+var testClient = ClientInfo{IP: "127.0.0.1", UserAgent: "go-test"}
+
+func newTestTokenManager(t *testing.T) *TokenManager {
+	tm, err := NewTokenManager(TokenConfig{Secret: "test-secret"})
+	assert.NoError(t, err)
+	return tm
+}
+
+func TestAuthService_Register(t *testing.T) {
+	service := NewAuthService(setupTestDB(t), newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New())
+	ctx := context.Background()
+
+	user, err := service.Register(ctx, "  ", "password123")
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, ErrUsernameRequired)
+
+	user, err = service.Register(ctx, "user1", "short")
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, ErrPasswordTooShort)
+
+	// Caso: éxito
+	user, err = service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", user.Username)
+	assert.NotEqual(t, "password123", user.PasswordHash)
+
+	user, err = service.Register(ctx, "user1", "otherpassword")
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, ErrUsernameTaken)
+}
+
+func TestAuthService_VerifyCredentials(t *testing.T) {
+	service := NewAuthService(setupTestDB(t), newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New())
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+
+	testScenarios := []struct {
+		testName string
+		username string
+		password string
+		wantErr  error
+	}{
+		{
+			testName: "Credenciales válidas",
+			username: "user1",
+			password: "password123",
+			wantErr:  nil,
+		},
+		{
+			testName: "Contraseña incorrecta",
+			username: "user1",
+			password: "wrongpassword",
+			wantErr:  ErrInvalidCredentials,
+		},
+		{
+			testName: "Usuario inexistente",
+			username: "ghost",
+			password: "password123",
+			wantErr:  ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			user, err := service.VerifyCredentials(ctx, tt.username, tt.password)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, user)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.username, user.Username)
+			}
+		})
+	}
+}
+
 func TestAuthService_LoginAndValidateToken(t *testing.T) {
-	logger := logrus.New()
-	service := NewAuthService(logger)
+	service := NewAuthService(setupTestDB(t), newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New())
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
 
 	testScenarios := []struct {
 		testName   string
 		username   string
+		password   string
 		wantExists bool
 	}{
 		{
 			testName:   "Login and validate valid token",
 			username:   "user1",
+			password:   "password123",
 			wantExists: true,
 		},
 		{
@@ -31,22 +123,300 @@ func TestAuthService_LoginAndValidateToken(t *testing.T) {
 
 	for _, tt := range testScenarios {
 		t.Run(tt.testName, func(t *testing.T) {
-			ctx := context.Background()
 			var token string
 			if tt.username != "" {
-				token = service.Login(ctx, tt.username)
-				assert.NotEmpty(t, token)
+				pair, err := service.Login(ctx, tt.username, tt.password, testClient)
+				assert.NoError(t, err)
+				assert.NotEmpty(t, pair.AccessToken)
+				assert.NotEmpty(t, pair.RefreshToken)
+				token = pair.AccessToken
 			} else {
 				token = "invalidtoken"
 			}
-			username, exists := service.ValidateToken(token)
+			claims, err := service.ValidateToken(ctx, token)
 			if tt.wantExists {
-				assert.True(t, exists)
-				assert.Equal(t, tt.username, username)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.username, claims.Subject)
 			} else {
-				assert.False(t, exists)
-				assert.Empty(t, username)
+				assert.ErrorIs(t, err, ErrTokenMalformed)
+				assert.Nil(t, claims)
 			}
 		})
 	}
 }
+
+func TestAuthService_LoginInvalidCredentials(t *testing.T) {
+	service := NewAuthService(setupTestDB(t), newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New())
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+
+	pair, err := service.Login(ctx, "user1", "wrongpassword", testClient)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Nil(t, pair)
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db, newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New())
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+	login, err := service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+
+	// Caso: rotación exitosa
+	rotated, err := service.Refresh(ctx, login.RefreshToken, testClient)
+	assert.NoError(t, err)
+	assert.NotEqual(t, login.RefreshToken, rotated.RefreshToken)
+	claims, err := service.ValidateToken(ctx, rotated.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", claims.Subject)
+
+	// Caso: token desconocido
+	_, err = service.Refresh(ctx, "unknown", testClient)
+	assert.ErrorIs(t, err, ErrRefreshTokenInvalid)
+
+	// Caso: reutilizar el token viejo revoca toda la familia
+	_, err = service.Refresh(ctx, login.RefreshToken, testClient)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	_, err = service.Refresh(ctx, rotated.RefreshToken, testClient)
+	assert.ErrorIs(t, err, ErrRefreshTokenInvalid)
+}
+
+func TestAuthService_RefreshExpired(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db, newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New())
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+	login, err := service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+
+	assert.NoError(t, db.Model(&models.RefreshToken{}).Where("1 = 1").Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	_, err = service.Refresh(ctx, login.RefreshToken, testClient)
+	assert.ErrorIs(t, err, ErrRefreshTokenExpired)
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db, newTestTokenManager(t), NewSQLiteRevocationStore(db), logrus.New())
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+
+	current, err := service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+	other, err := service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+
+	claims, err := service.ValidateToken(ctx, current.AccessToken)
+	assert.NoError(t, err)
+	assert.NoError(t, service.Logout(ctx, claims))
+
+	_, err = service.ValidateToken(ctx, current.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	_, err = service.Refresh(ctx, current.RefreshToken, testClient)
+	assert.ErrorIs(t, err, ErrRefreshTokenInvalid)
+
+	// la otra sesión sigue activa
+	_, err = service.ValidateToken(ctx, other.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestAuthService_LogoutAll(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db, newTestTokenManager(t), NewSQLiteRevocationStore(db), logrus.New())
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+	_, err = service.Register(ctx, "user2", "password123")
+	assert.NoError(t, err)
+
+	first, err := service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+	second, err := service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+	rotated, err := service.Refresh(ctx, second.RefreshToken, testClient)
+	assert.NoError(t, err)
+	foreign, err := service.Login(ctx, "user2", "password123", testClient)
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.LogoutAll(ctx, "user1"))
+
+	for _, token := range []string{first.AccessToken, second.AccessToken, rotated.AccessToken} {
+		_, err = service.ValidateToken(ctx, token)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	}
+	_, err = service.Refresh(ctx, rotated.RefreshToken, testClient)
+	assert.ErrorIs(t, err, ErrRefreshTokenInvalid)
+
+	_, err = service.ValidateToken(ctx, foreign.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestAuthService_RefreshReuseRevokesAccessTokens(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db, newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New())
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+	login, err := service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+	rotated, err := service.Refresh(ctx, login.RefreshToken, testClient)
+	assert.NoError(t, err)
+
+	_, err = service.Refresh(ctx, login.RefreshToken, testClient)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	_, err = service.ValidateToken(ctx, rotated.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestAuthService_Sessions(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db, newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New())
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+
+	laptop, err := service.Login(ctx, "user1", "password123", ClientInfo{IP: "10.0.0.1", UserAgent: "laptop"})
+	assert.NoError(t, err)
+	phone, err := service.Login(ctx, "user1", "password123", ClientInfo{IP: "10.0.0.2", UserAgent: "phone"})
+	assert.NoError(t, err)
+
+	sessions, err := service.ListSessions(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	phoneClaims, err := service.ValidateToken(ctx, phone.AccessToken)
+	assert.NoError(t, err)
+	assert.NoError(t, service.TouchSession(ctx, phoneClaims.SessionID, ClientInfo{IP: "10.0.0.3", UserAgent: "phone"}))
+
+	sessions, err = service.ListSessions(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, phoneClaims.SessionID, sessions[0].ID)
+	assert.Equal(t, "10.0.0.3", sessions[0].ClientIP)
+
+	// Caso: otro usuario no puede cerrar la sesión
+	err = service.RevokeSession(ctx, "user2", phoneClaims.SessionID)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	assert.NoError(t, service.RevokeSession(ctx, "user1", phoneClaims.SessionID))
+	_, err = service.ValidateToken(ctx, phone.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	_, err = service.ValidateToken(ctx, laptop.AccessToken)
+	assert.NoError(t, err)
+
+	sessions, err = service.ListSessions(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, "laptop", sessions[0].UserAgent)
+
+	err = service.RevokeSession(ctx, "user1", phoneClaims.SessionID)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestAuthService_RolesAndDisabledUsers(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db, newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New())
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+
+	login, err := service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+	claims, err := service.ValidateToken(ctx, login.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleUser, claims.Role)
+
+	_, err = service.SetUserRole(ctx, "user1", "root")
+	assert.ErrorIs(t, err, ErrInvalidRole)
+	_, err = service.SetUserRole(ctx, "ghost", models.RoleAdmin)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	// Caso: el cambio de rol cierra las sesiones anteriores
+	user, err := service.SetUserRole(ctx, "user1", models.RoleAdmin)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, user.Role)
+	_, err = service.ValidateToken(ctx, login.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	login, err = service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+	claims, err = service.ValidateToken(ctx, login.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, claims.Role)
+
+	// Caso: deshabilitar revoca sesiones e impide login y refresh
+	user, err = service.SetUserDisabled(ctx, "user1", true)
+	assert.NoError(t, err)
+	assert.True(t, user.Disabled)
+	_, err = service.ValidateToken(ctx, login.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	_, err = service.Login(ctx, "user1", "password123", testClient)
+	assert.ErrorIs(t, err, ErrUserDisabled)
+
+	_, err = service.SetUserDisabled(ctx, "user1", false)
+	assert.NoError(t, err)
+	_, err = service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+
+	users, err := service.ListUsers(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+}
+
+func TestAuthService_LoginExternal(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db, newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New())
+	ctx := context.Background()
+	_, err := service.Register(ctx, "ana@example.com", "password123")
+	assert.NoError(t, err)
+
+	// Caso: sin email verificado no se puede crear la cuenta
+	_, err = service.LoginExternal(ctx, ExternalIdentity{Issuer: "https://idp", Subject: "sub-1", Email: "ana@example.com"}, testClient)
+	assert.ErrorIs(t, err, ErrExternalEmailRequired)
+
+	// Caso: el email de una cuenta existente no la enlaza; hay que hacerlo desde una sesión iniciada
+	identity := ExternalIdentity{Issuer: "https://idp", Subject: "sub-1", Email: "ana@example.com", EmailVerified: true}
+	_, err = service.LoginExternal(ctx, identity, testClient)
+	assert.ErrorIs(t, err, ErrExternalAccountExists)
+	assert.NoError(t, service.LinkExternalIdentity(ctx, "ana@example.com", identity))
+	assert.NoError(t, service.LinkExternalIdentity(ctx, "ana@example.com", identity))
+	result, err := service.LoginExternal(ctx, identity, testClient)
+	assert.NoError(t, err)
+	claims, err := service.ValidateToken(ctx, result.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "ana@example.com", claims.Subject)
+
+	// Caso: los siguientes ingresos usan el enlace aunque cambie el email
+	identity.Email = "ana.maria@example.com"
+	result, err = service.LoginExternal(ctx, identity, testClient)
+	assert.NoError(t, err)
+	claims, err = service.ValidateToken(ctx, result.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "ana@example.com", claims.Subject)
+
+	// Caso: una identidad nueva crea un usuario sin contraseña
+	luis := ExternalIdentity{Issuer: "https://idp", Subject: "sub-2", Email: "luis@example.com", EmailVerified: true}
+	result, err = service.LoginExternal(ctx, luis, testClient)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.RefreshToken)
+	_, err = service.VerifyCredentials(ctx, "luis@example.com", "")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// Caso: una identidad enlazada no se puede pasar a otro usuario
+	assert.ErrorIs(t, service.LinkExternalIdentity(ctx, "ana@example.com", luis), ErrExternalIdentityLinked)
+	assert.ErrorIs(t, service.LinkExternalIdentity(ctx, "nadie", ExternalIdentity{Issuer: "https://idp", Subject: "sub-3"}), ErrUserNotFound)
+
+	_, err = service.SetUserDisabled(ctx, "luis@example.com", true)
+	assert.NoError(t, err)
+	_, err = service.LoginExternal(ctx, ExternalIdentity{Issuer: "https://idp", Subject: "sub-2"}, testClient)
+	assert.ErrorIs(t, err, ErrUserDisabled)
+
+	var links int64
+	assert.NoError(t, db.Model(&models.ExternalIdentity{}).Count(&links).Error)
+	assert.Equal(t, int64(2), links)
+}
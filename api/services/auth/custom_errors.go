@@ -0,0 +1,35 @@
+package services
+
+import "errors"
+
+var (
+	ErrUsernameRequired   = errors.New("username is required")
+	ErrPasswordTooShort   = errors.New("password must be at least 8 characters long")
+	ErrUsernameTaken      = errors.New("username already registered")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUserDisabled       = errors.New("user account is disabled")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrTokenExpired       = errors.New("token has expired")
+	ErrTokenMalformed     = errors.New("token is malformed")
+	ErrTokenInvalid       = errors.New("token is invalid")
+	ErrTokenRevoked       = errors.New("token has been revoked")
+
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or revoked")
+	ErrRefreshTokenExpired = errors.New("refresh token has expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, token family revoked")
+
+	ErrSessionNotFound = errors.New("session not found or not owned by user")
+
+	ErrExternalEmailRequired  = errors.New("external identity has no verified email")
+	ErrExternalAccountExists  = errors.New("an account with the external email already exists, link the identity from it")
+	ErrExternalIdentityLinked = errors.New("external identity is already linked to another user")
+
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPSetupRequired  = errors.New("two-factor setup has not been started")
+	ErrTOTPInvalidCode    = errors.New("invalid two-factor code")
+	ErrChallengeInvalid   = errors.New("two-factor challenge is invalid or already used")
+	ErrChallengeExpired   = errors.New("two-factor challenge has expired")
+
+	ErrTooManyAttempts = errors.New("too many failed login attempts")
+)
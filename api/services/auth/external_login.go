@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"prueba_tecnica_go_guarapo/api/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ExternalIdentity es la identidad ya verificada por un proveedor externo (por ejemplo OIDC).
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// LoginExternal busca el usuario enlazado a la identidad externa; en el primer ingreso crea una cuenta
+// sin contraseña con el email verificado como username. Nunca se enlaza sola con una cuenta que ya
+// existe (ErrExternalAccountExists): eso se hace con LinkExternalIdentity desde una sesión iniciada.
+// Si la cuenta tiene 2FA devuelve un desafío igual que Login.
+func (s *authService) LoginExternal(ctx context.Context, identity ExternalIdentity, client ClientInfo) (*LoginResult, error) {
+	var user models.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var link models.ExternalIdentity
+		err := tx.Where("issuer = ? AND subject = ?", identity.Issuer, identity.Subject).First(&link).Error
+		if err == nil {
+			if identity.Email != "" && identity.Email != link.Email {
+				if err := tx.Model(&link).Update("email", identity.Email).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Where("username = ?", link.Username).First(&user).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		email := strings.TrimSpace(identity.Email)
+		if email == "" || !identity.EmailVerified {
+			return ErrExternalEmailRequired
+		}
+		// una cuenta con ese nombre puede haberla registrado cualquiera: enlazarla daría acceso a una
+		// cuenta ajena, en un sentido o en el otro
+		var taken int64
+		if err := tx.Model(&models.User{}).Unscoped().Where("username = ?", email).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrExternalAccountExists
+		}
+		// sin contraseña: esta cuenta solo puede entrar por el proveedor externo
+		user = models.User{Username: email, Role: models.RoleUser}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ExternalIdentity{
+			Issuer:   identity.Issuer,
+			Subject:  identity.Subject,
+			Username: user.Username,
+			Email:    email,
+		}).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrExternalEmailRequired):
+			s.logger.Warnf("[Layer: auth_service] [Method: LoginExternal] Warning: Identity '%s' from '%s' has no verified email", identity.Subject, identity.Issuer)
+		case errors.Is(err, ErrExternalAccountExists):
+			s.logger.Warnf("[Layer: auth_service] [Method: LoginExternal] Warning: Identity '%s' from '%s' matches an existing unlinked account", identity.Subject, identity.Issuer)
+		default:
+			s.logger.Error("[Layer: auth_service] [Method: LoginExternal] Error: ", err)
+		}
+		return nil, err
+	}
+	if user.Disabled {
+		s.logger.Warnf("[Layer: auth_service] [Method: LoginExternal] Warning: Disabled user '%s' tried to log in", user.Username)
+		return nil, ErrUserDisabled
+	}
+	if user.TOTPEnabled {
+		result, err := s.twoFactorChallenge(&user)
+		if err != nil {
+			s.logger.Error("[Layer: auth_service] [Method: LoginExternal] Error: ", err)
+			return nil, err
+		}
+		s.logger.Infof("[Layer: auth_service] [Method: LoginExternal] Info: User '%s' must complete two-factor login", user.Username)
+		return result, nil
+	}
+
+	pair, familyID, err := s.startSession(ctx, &user, client)
+	if err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: LoginExternal] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: LoginExternal] Info: User '%s' logged in through '%s' with token family '%s'", user.Username, identity.Issuer, familyID)
+	return &LoginResult{TokenPair: pair}, nil
+}
+
+// LinkExternalIdentity enlaza la identidad externa con username, que ya inició sesión. Enlazarla de nuevo
+// con el mismo usuario no hace nada; si está enlazada con otro devuelve ErrExternalIdentityLinked.
+func (s *authService) LinkExternalIdentity(ctx context.Context, username string, identity ExternalIdentity) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var link models.ExternalIdentity
+		err := tx.Where("issuer = ? AND subject = ?", identity.Issuer, identity.Subject).First(&link).Error
+		if err == nil {
+			if link.Username != username {
+				return ErrExternalIdentityLinked
+			}
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := tx.Where("username = ?", username).First(&models.User{}).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		return tx.Create(&models.ExternalIdentity{
+			Issuer:   identity.Issuer,
+			Subject:  identity.Subject,
+			Username: username,
+			Email:    strings.TrimSpace(identity.Email),
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrExternalIdentityLinked) || errors.Is(err, ErrUserNotFound) {
+			s.logger.Warnf("[Layer: auth_service] [Method: LinkExternalIdentity] Warning: Identity '%s' from '%s' cannot be linked to user '%s': %v", identity.Subject, identity.Issuer, username, err)
+			return err
+		}
+		s.logger.Error("[Layer: auth_service] [Method: LinkExternalIdentity] Error: ", err)
+		return err
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: LinkExternalIdentity] Info: Identity '%s' from '%s' linked to user '%s'", identity.Subject, identity.Issuer, username)
+	return nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// LockoutPolicy define cuándo se frena el login. Por usuario los primeros
+// FreeAttempts fallos no tienen demora, luego la espera crece de forma exponencial
+// desde BaseDelay y al llegar a MaxFailures la cuenta se bloquea LockoutDuration.
+// Por IP solo se bloquea al llegar a MaxIPFailures, más alto porque varios
+// usuarios pueden compartir IP.
+type LockoutPolicy struct {
+	FreeAttempts    int
+	MaxFailures     int
+	MaxIPFailures   int
+	BaseDelay       time.Duration
+	LockoutDuration time.Duration
+	Window          time.Duration
+}
+
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		FreeAttempts:    3,
+		MaxFailures:     10,
+		MaxIPFailures:   50,
+		BaseDelay:       time.Second,
+		LockoutDuration: 15 * time.Minute,
+		Window:          15 * time.Minute,
+	}
+}
+
+// LockoutError indica cuánto falta para poder intentar de nuevo.
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockoutError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+type loginGuard struct {
+	store  AttemptStore
+	policy LockoutPolicy
+	now    func() time.Time
+}
+
+func newLoginGuard(store AttemptStore, policy LockoutPolicy) *loginGuard {
+	return &loginGuard{store: store, policy: policy, now: time.Now}
+}
+
+func userAttemptKey(username string) string {
+	return "user:" + username
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+func (g *loginGuard) keys(username, ip string) []string {
+	keys := []string{userAttemptKey(username)}
+	if ip != "" {
+		keys = append(keys, ipAttemptKey(ip))
+	}
+	return keys
+}
+
+// check devuelve un *LockoutError si el usuario o la IP siguen bloqueados.
+func (g *loginGuard) check(ctx context.Context, username, ip string) error {
+	now := g.now()
+	var wait time.Duration
+	for _, key := range g.keys(username, ip) {
+		until, err := g.store.LockedUntil(ctx, key)
+		if err != nil {
+			return err
+		}
+		if remaining := until.Sub(now); remaining > wait {
+			wait = remaining
+		}
+	}
+	if wait > 0 {
+		return &LockoutError{RetryAfter: wait}
+	}
+	return nil
+}
+
+func (g *loginGuard) fail(ctx context.Context, username, ip string) error {
+	now := g.now()
+	failures, err := g.store.RegisterFailure(ctx, userAttemptKey(username), now, g.policy.Window)
+	if err != nil {
+		return err
+	}
+	if delay := g.userDelay(failures); delay > 0 {
+		if err := g.store.LockUntil(ctx, userAttemptKey(username), now.Add(delay)); err != nil {
+			return err
+		}
+	}
+	if ip == "" {
+		return nil
+	}
+	failures, err = g.store.RegisterFailure(ctx, ipAttemptKey(ip), now, g.policy.Window)
+	if err != nil {
+		return err
+	}
+	if failures >= g.policy.MaxIPFailures {
+		return g.store.LockUntil(ctx, ipAttemptKey(ip), now.Add(g.policy.LockoutDuration))
+	}
+	return nil
+}
+
+func (g *loginGuard) userDelay(failures int) time.Duration {
+	if failures >= g.policy.MaxFailures {
+		return g.policy.LockoutDuration
+	}
+	if failures < g.policy.FreeAttempts {
+		return 0
+	}
+	delay := time.Duration(float64(g.policy.BaseDelay) * math.Pow(2, float64(failures-g.policy.FreeAttempts)))
+	if delay > g.policy.LockoutDuration {
+		return g.policy.LockoutDuration
+	}
+	return delay
+}
+
+// succeed limpia el contador del usuario; el de la IP se mantiene para que un
+// atacante no pueda reiniciarlo entrando con su propia cuenta.
+func (g *loginGuard) succeed(ctx context.Context, username string) error {
+	return g.store.Reset(ctx, userAttemptKey(username))
+}
+
+func (g *loginGuard) unlock(ctx context.Context, username string) error {
+	return g.store.Reset(ctx, userAttemptKey(username))
+}
+
+// Option configura dependencias opcionales de AuthService.
+type Option func(*authService)
+
+// WithLoginThrottle reemplaza el almacén en memoria y la política por defecto.
+func WithLoginThrottle(store AttemptStore, policy LockoutPolicy) Option {
+	return func(s *authService) {
+		s.guard = newLoginGuard(store, policy)
+	}
+}
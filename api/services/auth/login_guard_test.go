@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginGuard_UserDelay(t *testing.T) {
+	guard := newLoginGuard(NewMemoryAttemptStore(), DefaultLockoutPolicy())
+
+	testScenarios := []struct {
+		testName string
+		failures int
+		want     time.Duration
+	}{
+		{testName: "Primer fallo sin demora", failures: 1, want: 0},
+		{testName: "Último fallo gratis", failures: 2, want: 0},
+		{testName: "Inicia la demora", failures: 3, want: time.Second},
+		{testName: "Demora exponencial", failures: 6, want: 8 * time.Second},
+		{testName: "Bloqueo al llegar al máximo", failures: 10, want: 15 * time.Minute},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			assert.Equal(t, tt.want, guard.userDelay(tt.failures))
+		})
+	}
+}
+
+func TestAuthService_LoginThrottling(t *testing.T) {
+	policy := LockoutPolicy{FreeAttempts: 2, MaxFailures: 4, MaxIPFailures: 6, BaseDelay: time.Second, LockoutDuration: time.Hour, Window: time.Hour}
+	service := NewAuthService(setupTestDB(t), newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New(),
+		WithLoginThrottle(NewMemoryAttemptStore(), policy))
+	guard := service.(*authService).guard
+	now := time.Now()
+	guard.now = func() time.Time { return now }
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+
+	// Caso: los primeros fallos no tienen demora
+	for i := 0; i < policy.FreeAttempts-1; i++ {
+		_, err = service.Login(ctx, "user1", "wrong-password", testClient)
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err = service.Login(ctx, "user1", "wrong-password", testClient)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// Caso: durante la espera ni la contraseña correcta entra
+	_, err = service.Login(ctx, "user1", "password123", testClient)
+	var lockErr *LockoutError
+	assert.True(t, errors.As(err, &lockErr))
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	assert.Equal(t, time.Second, lockErr.RetryAfter)
+
+	// Caso: pasada la espera el login correcto reinicia el contador
+	now = now.Add(2 * time.Second)
+	_, err = service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+
+	// Caso: al llegar al máximo la cuenta queda bloqueada
+	for i := 0; i < policy.MaxFailures; i++ {
+		now = now.Add(time.Minute)
+		_, err = service.Login(ctx, "user1", "wrong-password", testClient)
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err = service.Login(ctx, "user1", "password123", testClient)
+	assert.True(t, errors.As(err, &lockErr))
+	assert.Equal(t, time.Hour, lockErr.RetryAfter)
+
+	// Caso: otro cliente tampoco puede entrar a la cuenta bloqueada
+	_, err = service.Login(ctx, "user1", "password123", ClientInfo{IP: "10.0.0.9"})
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+
+	// Caso: el admin desbloquea la cuenta
+	assert.ErrorIs(t, service.UnlockUser(ctx, "ghost"), ErrUserNotFound)
+	assert.NoError(t, service.UnlockUser(ctx, "user1"))
+	_, err = service.Login(ctx, "user1", "password123", ClientInfo{IP: "10.0.0.9"})
+	assert.NoError(t, err)
+
+	// Caso: la IP que acumuló fallos sigue bloqueada para cualquier usuario
+	_, err = service.Login(ctx, "another", "password123", testClient)
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevocationStore guarda los identificadores revocados hasta que el token
+// correspondiente expira por sí solo; después la entrada ya no es necesaria.
+type RevocationStore interface {
+	Revoke(ctx context.Context, id string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, id string) (bool, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type memoryRevocationStore struct {
+	entries map[string]time.Time
+	mutex   sync.RWMutex
+}
+
+func NewMemoryRevocationStore() RevocationStore {
+	return &memoryRevocationStore{
+		entries: make(map[string]time.Time),
+	}
+}
+
+func (s *memoryRevocationStore) Revoke(_ context.Context, id string, expiresAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if current, exists := s.entries[id]; !exists || expiresAt.After(current) {
+		s.entries[id] = expiresAt
+	}
+	return nil
+}
+
+func (s *memoryRevocationStore) IsRevoked(_ context.Context, id string) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	expiresAt, exists := s.entries[id]
+	return exists && time.Now().Before(expiresAt), nil
+}
+
+func (s *memoryRevocationStore) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var deleted int64
+	for id, expiresAt := range s.entries {
+		if !now.Before(expiresAt) {
+			delete(s.entries, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+type sqliteRevocationStore struct {
+	db *gorm.DB
+}
+
+func NewSQLiteRevocationStore(db *gorm.DB) RevocationStore {
+	return &sqliteRevocationStore{db: db}
+}
+
+func (s *sqliteRevocationStore) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	entry := &models.RevokedToken{ID: id, ExpiresAt: expiresAt}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.Set{{Column: clause.Column{Name: "expires_at"}, Value: gorm.Expr("MAX(expires_at, excluded.expires_at)")}},
+	}).Create(entry).Error
+}
+
+func (s *sqliteRevocationStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&models.RevokedToken{}).
+		Where("id = ? AND expires_at > ?", id, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (s *sqliteRevocationStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
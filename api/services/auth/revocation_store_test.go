@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevocationStores(t *testing.T) {
+	testScenarios := []struct {
+		testName string
+		newStore func(t *testing.T) RevocationStore
+	}{
+		{
+			testName: "En memoria",
+			newStore: func(*testing.T) RevocationStore { return NewMemoryRevocationStore() },
+		},
+		{
+			testName: "SQLite",
+			newStore: func(t *testing.T) RevocationStore { return NewSQLiteRevocationStore(setupTestDB(t)) },
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			store := tt.newStore(t)
+			ctx := context.Background()
+			now := time.Now()
+
+			revoked, err := store.IsRevoked(ctx, "jti1")
+			assert.NoError(t, err)
+			assert.False(t, revoked)
+
+			assert.NoError(t, store.Revoke(ctx, "jti1", now.Add(time.Hour)))
+			assert.NoError(t, store.Revoke(ctx, "jti2", now.Add(-time.Minute)))
+			// revocar de nuevo no acorta la expiración
+			assert.NoError(t, store.Revoke(ctx, "jti1", now.Add(time.Minute)))
+
+			revoked, err = store.IsRevoked(ctx, "jti1")
+			assert.NoError(t, err)
+			assert.True(t, revoked)
+
+			// una entrada vencida ya no cuenta: el token expiró por sí solo
+			revoked, err = store.IsRevoked(ctx, "jti2")
+			assert.NoError(t, err)
+			assert.False(t, revoked)
+
+			deleted, err := store.DeleteExpired(ctx, now.Add(30*time.Minute))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), deleted)
+
+			deleted, err = store.DeleteExpired(ctx, now.Add(2*time.Hour))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), deleted)
+		})
+	}
+}
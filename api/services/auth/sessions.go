@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+	"time"
+)
+
+// touchInterval evita escribir en la base de datos en cada petición de una misma sesión.
+const touchInterval = time.Minute
+
+func (s *authService) ListSessions(ctx context.Context, username string) ([]*models.Session, error) {
+	var sessions []*models.Session
+	if err := s.db.WithContext(ctx).
+		Where("username = ? AND revoked_at IS NULL AND expires_at > ?", username, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: ListSessions] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: ListSessions] Info: User '%s' has %d active sessions", username, len(sessions))
+	return sessions, nil
+}
+
+func (s *authService) RevokeSession(ctx context.Context, username, sessionID string) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND username = ? AND revoked_at IS NULL", sessionID, username).
+		Count(&count).Error; err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: RevokeSession] Error: ", err)
+		return err
+	}
+	if count == 0 {
+		s.logger.Warnf("[Layer: auth_service] [Method: RevokeSession] Warning: Session '%s' not found or not owned by user '%s'", sessionID, username)
+		return ErrSessionNotFound
+	}
+	if err := s.revokeFamily(ctx, sessionID); err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: RevokeSession] Error: ", err)
+		return err
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: RevokeSession] Info: Session '%s' of user '%s' revoked", sessionID, username)
+	return nil
+}
+
+func (s *authService) TouchSession(ctx context.Context, sessionID string, client ClientInfo) error {
+	now := time.Now()
+	err := s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND (last_used_at < ? OR client_ip <> ? OR user_agent <> ?)", sessionID, now.Add(-touchInterval), client.IP, client.UserAgent).
+		Updates(map[string]interface{}{
+			"last_used_at": now,
+			"client_ip":    client.IP,
+			"user_agent":   client.UserAgent,
+		}).Error
+	if err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: TouchSession] Error: ", err)
+	}
+	return err
+}
+
+// revokeFamily invalida la sesión, sus refresh tokens y, a través de la
+// lista de revocación, cualquier access token emitido con ese sid.
+func (s *authService) revokeFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	db := s.db.WithContext(ctx)
+	if err := db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	return s.revocations.Revoke(ctx, familyID, now.Add(s.tokens.AccessTokenTTL()))
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	// PurposeTwoFactor marca el token intermedio del login con 2FA; no sirve como access token.
+	PurposeTwoFactor = "2fa"
+
+	defaultIssuer          = "prueba_tecnica_go_guarapo"
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 7 * 24 * time.Hour
+	challengeTokenTTL      = 5 * time.Minute
+)
+
+// TokenConfig define cómo se firman y validan los access tokens.
+// Para HS256 basta con Secret; RS256 y EdDSA leen las llaves PEM de disco
+// (la llave pública es opcional y se deriva de la privada si no se indica).
+type TokenConfig struct {
+	Algorithm       string
+	Secret          string
+	PrivateKeyFile  string
+	PublicKeyFile   string
+	Issuer          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+type TokenClaims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid,omitempty"`
+	Role      string `json:"role,omitempty"`
+	Purpose   string `json:"pur,omitempty"`
+}
+
+type TokenManager struct {
+	method     jwt.SigningMethod
+	signKey    interface{}
+	verifyKey  interface{}
+	issuer     string
+	ttl        time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+func NewTokenManager(cfg TokenConfig) (*TokenManager, error) {
+	tm := &TokenManager{
+		issuer:     cfg.Issuer,
+		ttl:        cfg.AccessTokenTTL,
+		refreshTTL: cfg.RefreshTokenTTL,
+		now:        time.Now,
+	}
+	if tm.issuer == "" {
+		tm.issuer = defaultIssuer
+	}
+	if tm.ttl <= 0 {
+		tm.ttl = defaultAccessTokenTTL
+	}
+	if tm.refreshTTL <= 0 {
+		tm.refreshTTL = defaultRefreshTokenTTL
+	}
+
+	switch {
+	case cfg.Algorithm == "" || strings.EqualFold(cfg.Algorithm, AlgorithmHS256):
+		if cfg.Secret == "" {
+			return nil, errors.New("HS256 requires a non-empty secret")
+		}
+		tm.method = jwt.SigningMethodHS256
+		tm.signKey = []byte(cfg.Secret)
+		tm.verifyKey = []byte(cfg.Secret)
+	case strings.EqualFold(cfg.Algorithm, AlgorithmRS256):
+		privatePEM, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading RS256 private key: %w", err)
+		}
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return nil, fmt.Errorf("parsing RS256 private key: %w", err)
+		}
+		publicKey := &privateKey.PublicKey
+		if cfg.PublicKeyFile != "" {
+			publicPEM, err := os.ReadFile(cfg.PublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading RS256 public key: %w", err)
+			}
+			if publicKey, err = jwt.ParseRSAPublicKeyFromPEM(publicPEM); err != nil {
+				return nil, fmt.Errorf("parsing RS256 public key: %w", err)
+			}
+		}
+		tm.method = jwt.SigningMethodRS256
+		tm.signKey = privateKey
+		tm.verifyKey = publicKey
+	case strings.EqualFold(cfg.Algorithm, AlgorithmEdDSA):
+		privatePEM, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading EdDSA private key: %w", err)
+		}
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return nil, fmt.Errorf("parsing EdDSA private key: %w", err)
+		}
+		publicKey := privateKey.(ed25519.PrivateKey).Public()
+		if cfg.PublicKeyFile != "" {
+			publicPEM, err := os.ReadFile(cfg.PublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading EdDSA public key: %w", err)
+			}
+			if publicKey, err = jwt.ParseEdPublicKeyFromPEM(publicPEM); err != nil {
+				return nil, fmt.Errorf("parsing EdDSA public key: %w", err)
+			}
+		}
+		tm.method = jwt.SigningMethodEdDSA
+		tm.signKey = privateKey
+		tm.verifyKey = publicKey
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", cfg.Algorithm)
+	}
+	return tm, nil
+}
+
+func (tm *TokenManager) Issue(subject, sessionID, role string) (string, *TokenClaims, error) {
+	return tm.issue(subject, sessionID, role, "", tm.ttl)
+}
+
+// IssueChallenge emite el token de corta duración que se canjea junto al código TOTP.
+func (tm *TokenManager) IssueChallenge(subject string) (string, *TokenClaims, error) {
+	return tm.issue(subject, "", "", PurposeTwoFactor, challengeTokenTTL)
+}
+
+func (tm *TokenManager) issue(subject, sessionID, role, purpose string, ttl time.Duration) (string, *TokenClaims, error) {
+	id, err := newTokenID()
+	if err != nil {
+		return "", nil, err
+	}
+	now := tm.now()
+	claims := &TokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tm.issuer,
+			Subject:   subject,
+			ID:        id,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		SessionID: sessionID,
+		Role:      role,
+		Purpose:   purpose,
+	}
+	signed, err := jwt.NewWithClaims(tm.method, claims).SignedString(tm.signKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+// Parse valida un access token; los tokens emitidos para otro propósito se rechazan.
+func (tm *TokenManager) Parse(token string) (*TokenClaims, error) {
+	claims, err := tm.parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+func (tm *TokenManager) ParseChallenge(token string) (*TokenClaims, error) {
+	claims, err := tm.parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != PurposeTwoFactor {
+		return nil, ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+func (tm *TokenManager) parse(token string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return tm.verifyKey, nil
+	},
+		jwt.WithValidMethods([]string{tm.method.Alg()}),
+		jwt.WithIssuer(tm.issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(tm.now),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, jwt.ErrTokenExpired):
+			return nil, ErrTokenExpired
+		case errors.Is(err, jwt.ErrTokenMalformed):
+			return nil, ErrTokenMalformed
+		default:
+			return nil, fmt.Errorf("%w: %v", ErrTokenInvalid, err)
+		}
+	}
+	if claims.Subject == "" || claims.ID == "" {
+		return nil, ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+// NewRefreshToken genera un refresh token opaco; solo su hash se persiste.
+func (tm *TokenManager) NewRefreshToken() (raw string, hash string, expiresAt time.Time, err error) {
+	raw, err = newRandomToken(32)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return raw, hashToken(raw), tm.now().Add(tm.refreshTTL), nil
+}
+
+func (tm *TokenManager) AccessTokenTTL() time.Duration {
+	return tm.ttl
+}
+
+func (tm *TokenManager) Issuer() string {
+	return tm.issuer
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func newTokenID() (string, error) {
+	return newRandomToken(16)
+}
+
+func newRandomToken(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
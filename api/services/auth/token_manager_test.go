@@ -0,0 +1,171 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writePrivateKeyPEM(t *testing.T, key interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "private.pem")
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	return path
+}
+
+func TestTokenManager_IssueAndParse(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	testScenarios := []struct {
+		testName string
+		config   TokenConfig
+	}{
+		{
+			testName: "HS256 con secreto",
+			config:   TokenConfig{Algorithm: AlgorithmHS256, Secret: "test-secret"},
+		},
+		{
+			testName: "RS256 con llave en archivo",
+			config:   TokenConfig{Algorithm: AlgorithmRS256, PrivateKeyFile: writePrivateKeyPEM(t, rsaKey)},
+		},
+		{
+			testName: "EdDSA con llave en archivo",
+			config:   TokenConfig{Algorithm: AlgorithmEdDSA, PrivateKeyFile: writePrivateKeyPEM(t, edKey)},
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			tm, err := NewTokenManager(tt.config)
+			assert.NoError(t, err)
+
+			token, issued, err := tm.Issue("user1", "session1", "user")
+			assert.NoError(t, err)
+			assert.NotEmpty(t, issued.ID)
+
+			claims, err := tm.Parse(token)
+			assert.NoError(t, err)
+			assert.Equal(t, "user1", claims.Subject)
+			assert.Equal(t, issued.ID, claims.ID)
+			assert.Equal(t, "session1", claims.SessionID)
+			assert.Equal(t, "user", claims.Role)
+			assert.True(t, claims.ExpiresAt.After(claims.IssuedAt.Time))
+		})
+	}
+}
+
+func TestTokenManager_ParseErrors(t *testing.T) {
+	tm, err := NewTokenManager(TokenConfig{Secret: "test-secret", AccessTokenTTL: time.Minute})
+	assert.NoError(t, err)
+	token, _, err := tm.Issue("user1", "session1", "user")
+	assert.NoError(t, err)
+
+	other, err := NewTokenManager(TokenConfig{Secret: "other-secret"})
+	assert.NoError(t, err)
+	forged, _, err := other.Issue("user1", "session1", "user")
+	assert.NoError(t, err)
+
+	expired, err := NewTokenManager(TokenConfig{Secret: "test-secret", AccessTokenTTL: time.Minute})
+	assert.NoError(t, err)
+	expired.now = func() time.Time { return time.Now().Add(-time.Hour) }
+	expiredToken, _, err := expired.Issue("user1", "session1", "user")
+	assert.NoError(t, err)
+
+	testScenarios := []struct {
+		testName string
+		token    string
+		wantErr  error
+	}{
+		{
+			testName: "Token mal formado",
+			token:    "not-a-jwt",
+			wantErr:  ErrTokenMalformed,
+		},
+		{
+			testName: "Firma inválida",
+			token:    forged,
+			wantErr:  ErrTokenInvalid,
+		},
+		{
+			testName: "Token expirado",
+			token:    expiredToken,
+			wantErr:  ErrTokenExpired,
+		},
+		{
+			testName: "Token válido",
+			token:    token,
+			wantErr:  nil,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			claims, err := tm.Parse(tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, claims)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "user1", claims.Subject)
+			}
+		})
+	}
+}
+
+func TestTokenManager_NewRefreshToken(t *testing.T) {
+	tm, err := NewTokenManager(TokenConfig{Secret: "test-secret", RefreshTokenTTL: time.Hour})
+	assert.NoError(t, err)
+
+	raw, hash, expiresAt, err := tm.NewRefreshToken()
+	assert.NoError(t, err)
+	assert.NotEqual(t, raw, hash)
+	assert.Equal(t, hashToken(raw), hash)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+}
+
+func TestNewTokenManager_InvalidConfig(t *testing.T) {
+	_, err := NewTokenManager(TokenConfig{Algorithm: AlgorithmHS256})
+	assert.Error(t, err)
+
+	_, err = NewTokenManager(TokenConfig{Algorithm: AlgorithmRS256, PrivateKeyFile: "/does/not/exist.pem"})
+	assert.Error(t, err)
+
+	_, err = NewTokenManager(TokenConfig{Algorithm: "none", Secret: "test-secret"})
+	assert.Error(t, err)
+}
+
+func TestTokenManager_ChallengeTokens(t *testing.T) {
+	tm, err := NewTokenManager(TokenConfig{Secret: "test-secret"})
+	assert.NoError(t, err)
+
+	challenge, issued, err := tm.IssueChallenge("user1")
+	assert.NoError(t, err)
+	assert.Equal(t, PurposeTwoFactor, issued.Purpose)
+	assert.WithinDuration(t, time.Now().Add(challengeTokenTTL), issued.ExpiresAt.Time, time.Minute)
+
+	// Caso: el token de desafío no sirve como access token
+	_, err = tm.Parse(challenge)
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+
+	claims, err := tm.ParseChallenge(challenge)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", claims.Subject)
+
+	// Caso: un access token no sirve como desafío
+	access, _, err := tm.Issue("user1", "session1", "user")
+	assert.NoError(t, err)
+	_, err = tm.ParseChallenge(access)
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parámetros RFC 6238 compatibles con Google Authenticator, Authy, etc.
+const (
+	totpDigits = 6
+	totpPeriod = 30
+	// totpSkew acepta el código del intervalo anterior y del siguiente por desfase de reloj.
+	totpSkew       = 1
+	totpSecretSize = 20
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func newTOTPSecret() (string, error) {
+	bytes := make([]byte, totpSecretSize)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base32NoPadding.EncodeToString(bytes), nil
+}
+
+// totpURI arma la URI otpauth:// que las apps de autenticación leen desde un QR.
+func totpURI(issuer, username, secret string) string {
+	label := url.PathEscape(issuer + ":" + username)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// hotp implementa RFC 4226 con truncamiento dinámico.
+func hotp(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+func totpCounter(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// validateTOTP devuelve el contador que coincidió para que el llamador pueda
+// rechazar la reutilización del mismo código.
+func validateTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	current := totpCounter(now)
+	for delta := int64(-totpSkew); delta <= totpSkew; delta++ {
+		counter := current + delta
+		if counter < 0 {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hotp(key, uint64(counter))), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
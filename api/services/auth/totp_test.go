@@ -0,0 +1,76 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHOTP_RFC6238Vectors(t *testing.T) {
+	// Vectores SHA1 del apéndice B de la RFC 6238 (últimos 6 dígitos)
+	key := []byte("12345678901234567890")
+
+	testScenarios := []struct {
+		testName string
+		unix     int64
+		want     string
+	}{
+		{testName: "T=59", unix: 59, want: "287082"},
+		{testName: "T=1111111109", unix: 1111111109, want: "081804"},
+		{testName: "T=1111111111", unix: 1111111111, want: "050471"},
+		{testName: "T=1234567890", unix: 1234567890, want: "005924"},
+		{testName: "T=2000000000", unix: 2000000000, want: "279037"},
+		{testName: "T=20000000000", unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			assert.Equal(t, tt.want, hotp(key, uint64(totpCounter(time.Unix(tt.unix, 0)))))
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := newTOTPSecret()
+	assert.NoError(t, err)
+	key, err := base32NoPadding.DecodeString(secret)
+	assert.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	current := totpCounter(now)
+
+	testScenarios := []struct {
+		testName string
+		code     string
+		wantOK   bool
+		wantCtr  int64
+	}{
+		{testName: "Código actual", code: hotp(key, uint64(current)), wantOK: true, wantCtr: current},
+		{testName: "Intervalo anterior", code: hotp(key, uint64(current-1)), wantOK: true, wantCtr: current - 1},
+		{testName: "Intervalo siguiente", code: hotp(key, uint64(current+1)), wantOK: true, wantCtr: current + 1},
+		{testName: "Fuera de la ventana", code: hotp(key, uint64(current-3)), wantOK: false},
+		{testName: "Formato inválido", code: "12345", wantOK: false},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			counter, ok := validateTOTP(secret, tt.code, now)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.wantCtr, counter)
+			}
+		})
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := totpURI("Guarapo", "ana@example.com", "JBSWY3DPEHPK3PXP")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Guarapo:ana@example.com?"))
+	parsed, err := url.Parse(uri)
+	assert.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", parsed.Query().Get("secret"))
+	assert.Equal(t, "Guarapo", parsed.Query().Get("issuer"))
+	assert.Equal(t, "6", parsed.Query().Get("digits"))
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"prueba_tecnica_go_guarapo/api/models"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount = 10
+	// maxChallengeAttempts invalida el desafío tras varios códigos erróneos.
+	maxChallengeAttempts = 5
+)
+
+type TOTPSetup struct {
+	Secret string
+	URI    string
+}
+
+// SetupTOTP genera un secreto nuevo; el segundo factor no se exige hasta confirmarlo con EnableTOTP.
+func (s *authService) SetupTOTP(ctx context.Context, username string) (*TOTPSetup, error) {
+	user, err := s.findUser(ctx, username)
+	if err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: SetupTOTP] Error: ", err)
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		s.logger.Warnf("[Layer: auth_service] [Method: SetupTOTP] Warning: User '%s' already has two-factor enabled", username)
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	secret, err := newTOTPSecret()
+	if err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: SetupTOTP] Error: ", err)
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Model(user).Updates(map[string]interface{}{
+		"totp_secret":       secret,
+		"totp_last_counter": 0,
+	}).Error; err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: SetupTOTP] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: SetupTOTP] Info: Two-factor enrollment started for user '%s'", username)
+	return &TOTPSetup{
+		Secret: secret,
+		URI:    totpURI(s.tokens.Issuer(), username, secret),
+	}, nil
+}
+
+// EnableTOTP confirma el enrolamiento con un código válido y devuelve los códigos de recuperación,
+// que solo se muestran esta vez.
+func (s *authService) EnableTOTP(ctx context.Context, username, code string) ([]string, error) {
+	user, err := s.findUser(ctx, username)
+	if err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: EnableTOTP] Error: ", err)
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		s.logger.Warnf("[Layer: auth_service] [Method: EnableTOTP] Warning: User '%s' already has two-factor enabled", username)
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		s.logger.Warnf("[Layer: auth_service] [Method: EnableTOTP] Warning: User '%s' has not started two-factor setup", username)
+		return nil, ErrTOTPSetupRequired
+	}
+	counter, ok := validateTOTP(user.TOTPSecret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		s.logger.Warnf("[Layer: auth_service] [Method: EnableTOTP] Warning: Invalid code for user '%s'", username)
+		return nil, ErrTOTPInvalidCode
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			s.logger.Error("[Layer: auth_service] [Method: EnableTOTP] Error: ", err)
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{Username: username, CodeHash: hashToken(normalizeRecoveryCode(code))})
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("username = ?", username).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&records).Error; err != nil {
+			return err
+		}
+		return tx.Model(user).Updates(map[string]interface{}{
+			"totp_enabled":      true,
+			"totp_last_counter": counter,
+		}).Error
+	})
+	if err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: EnableTOTP] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: EnableTOTP] Info: Two-factor enabled for user '%s'", username)
+	return codes, nil
+}
+
+// CompleteTwoFactorLogin canjea el desafío emitido por Login junto a un código TOTP
+// o un código de recuperación. Cada desafío sirve para un solo login.
+func (s *authService) CompleteTwoFactorLogin(ctx context.Context, challengeToken, code string, client ClientInfo) (*TokenPair, error) {
+	claims, err := s.tokens.ParseChallenge(challengeToken)
+	if err != nil {
+		s.logger.Warnf("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Warning: Rejected challenge: %v", err)
+		if errors.Is(err, ErrTokenExpired) {
+			return nil, ErrChallengeExpired
+		}
+		return nil, ErrChallengeInvalid
+	}
+	revoked, err := s.revocations.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Error: ", err)
+		return nil, err
+	}
+	if revoked {
+		s.logger.Warnf("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Warning: Challenge '%s' already used", claims.ID)
+		return nil, ErrChallengeInvalid
+	}
+
+	user, err := s.findUser(ctx, claims.Subject)
+	if err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Error: ", err)
+		return nil, err
+	}
+	if user.Disabled {
+		s.logger.Warnf("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Warning: Disabled user '%s' tried to log in", user.Username)
+		return nil, ErrUserDisabled
+	}
+	if !user.TOTPEnabled {
+		s.logger.Warnf("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Warning: User '%s' has no two-factor enabled", user.Username)
+		return nil, ErrChallengeInvalid
+	}
+	if err := s.guard.check(ctx, user.Username, client.IP); err != nil {
+		s.logger.Warnf("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Warning: Login for user '%s' from '%s' throttled: %v", user.Username, client.IP, err)
+		return nil, err
+	}
+
+	ok, err := s.consumeSecondFactor(ctx, user, strings.TrimSpace(code))
+	if err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Error: ", err)
+		return nil, err
+	}
+	if !ok {
+		s.logger.Warnf("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Warning: Invalid code for user '%s'", user.Username)
+		if err := s.guard.fail(ctx, user.Username, client.IP); err != nil {
+			s.logger.Error("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Error: ", err)
+		}
+		if s.challenges.fail(claims.ID, claims.ExpiresAt.Time) >= maxChallengeAttempts {
+			s.logger.Warnf("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Warning: Too many attempts, challenge '%s' revoked", claims.ID)
+			if err := s.revocations.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+				s.logger.Error("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Error: ", err)
+			}
+		}
+		return nil, ErrTOTPInvalidCode
+	}
+
+	s.challenges.forget(claims.ID)
+	if err := s.guard.succeed(ctx, user.Username); err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Error: ", err)
+	}
+	if err := s.revocations.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Error: ", err)
+		return nil, err
+	}
+	pair, familyID, err := s.startSession(ctx, user, client)
+	if err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: CompleteTwoFactorLogin] Info: User '%s' logged in with two-factor, token family '%s'", user.Username, familyID)
+	return pair, nil
+}
+
+// consumeSecondFactor acepta un código TOTP no usado antes o un código de recuperación.
+// Las actualizaciones condicionales evitan que dos peticiones usen el mismo código.
+func (s *authService) consumeSecondFactor(ctx context.Context, user *models.User, code string) (bool, error) {
+	db := s.db.WithContext(ctx)
+	if counter, ok := validateTOTP(user.TOTPSecret, code, time.Now()); ok {
+		result := db.Model(&models.User{}).
+			Where("id = ? AND totp_last_counter < ?", user.ID, counter).
+			Update("totp_last_counter", counter)
+		return result.RowsAffected == 1, result.Error
+	}
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return false, nil
+	}
+	result := db.Model(&models.RecoveryCode{}).
+		Where("username = ? AND code_hash = ? AND used_at IS NULL", user.Username, hashToken(normalized)).
+		Update("used_at", time.Now())
+	if result.RowsAffected == 1 {
+		s.logger.Infof("[Layer: auth_service] [Method: consumeSecondFactor] Info: User '%s' used a recovery code", user.Username)
+	}
+	return result.RowsAffected == 1, result.Error
+}
+
+// newRecoveryCode genera 80 bits en base32 agrupados como XXXX-XXXX-XXXX-XXXX.
+func newRecoveryCode() (string, error) {
+	bytes := make([]byte, 10)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	raw := base32NoPadding.EncodeToString(bytes)
+	return raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+type challengeAttempt struct {
+	failures  int
+	expiresAt time.Time
+}
+
+// challengeAttempts cuenta códigos erróneos por desafío; vive en memoria porque
+// el desafío dura pocos minutos.
+type challengeAttempts struct {
+	mu       sync.Mutex
+	attempts map[string]challengeAttempt
+}
+
+func newChallengeAttempts() *challengeAttempts {
+	return &challengeAttempts{attempts: make(map[string]challengeAttempt)}
+}
+
+func (c *challengeAttempts) fail(id string, expiresAt time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for key, attempt := range c.attempts {
+		if now.After(attempt.expiresAt) {
+			delete(c.attempts, key)
+		}
+	}
+	attempt := c.attempts[id]
+	attempt.failures++
+	attempt.expiresAt = expiresAt
+	c.attempts[id] = attempt
+	return attempt.failures
+}
+
+func (c *challengeAttempts) forget(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.attempts, id)
+}
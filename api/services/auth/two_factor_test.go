@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// currentCode calcula el código vigente como lo haría la app de autenticación.
+func currentCode(t *testing.T, secret string, offset int64) string {
+	key, err := base32NoPadding.DecodeString(secret)
+	assert.NoError(t, err)
+	return hotp(key, uint64(totpCounter(time.Now())+offset))
+}
+
+func TestAuthService_TOTPEnrollment(t *testing.T) {
+	service := NewAuthService(setupTestDB(t), newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New())
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+
+	_, err = service.EnableTOTP(ctx, "user1", "123456")
+	assert.ErrorIs(t, err, ErrTOTPSetupRequired)
+
+	setup, err := service.SetupTOTP(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Contains(t, setup.URI, "secret="+setup.Secret)
+
+	// Caso: mientras no se confirme, el login no pide segundo factor
+	login, err := service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+	assert.Empty(t, login.ChallengeToken)
+	assert.NotEmpty(t, login.AccessToken)
+
+	_, err = service.EnableTOTP(ctx, "user1", "000000")
+	assert.ErrorIs(t, err, ErrTOTPInvalidCode)
+
+	codes, err := service.EnableTOTP(ctx, "user1", currentCode(t, setup.Secret, 0))
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+
+	_, err = service.SetupTOTP(ctx, "user1")
+	assert.ErrorIs(t, err, ErrTOTPAlreadyEnabled)
+	_, err = service.EnableTOTP(ctx, "user1", currentCode(t, setup.Secret, 0))
+	assert.ErrorIs(t, err, ErrTOTPAlreadyEnabled)
+}
+
+func TestAuthService_TwoFactorLogin(t *testing.T) {
+	service := NewAuthService(setupTestDB(t), newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New())
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+	setup, err := service.SetupTOTP(ctx, "user1")
+	assert.NoError(t, err)
+	// el código del intervalo anterior habilita; el actual queda libre para el login
+	enableCode := currentCode(t, setup.Secret, -1)
+	codes, err := service.EnableTOTP(ctx, "user1", enableCode)
+	assert.NoError(t, err)
+
+	login, err := service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+	assert.Nil(t, login.TokenPair)
+	assert.NotEmpty(t, login.ChallengeToken)
+	assert.Equal(t, challengeTokenTTL, login.ChallengeExpiresIn)
+
+	// Caso: el desafío no sirve como access token
+	_, err = service.ValidateToken(ctx, login.ChallengeToken)
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+
+	_, err = service.CompleteTwoFactorLogin(ctx, "garbage", currentCode(t, setup.Secret, 0), testClient)
+	assert.ErrorIs(t, err, ErrChallengeInvalid)
+	_, err = service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, "000000", testClient)
+	assert.ErrorIs(t, err, ErrTOTPInvalidCode)
+	// Caso: un código ya usado (el de la habilitación) se rechaza
+	_, err = service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, enableCode, testClient)
+	assert.ErrorIs(t, err, ErrTOTPInvalidCode)
+
+	pair, err := service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, currentCode(t, setup.Secret, 0), testClient)
+	assert.NoError(t, err)
+	claims, err := service.ValidateToken(ctx, pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", claims.Subject)
+
+	// Caso: cada desafío sirve una sola vez
+	_, err = service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, currentCode(t, setup.Secret, 1), testClient)
+	assert.ErrorIs(t, err, ErrChallengeInvalid)
+
+	// Caso: códigos de recuperación de un solo uso, sin importar mayúsculas ni guiones
+	login, err = service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+	_, err = service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, codes[0], testClient)
+	assert.NoError(t, err)
+	login, err = service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+	_, err = service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, codes[0], testClient)
+	assert.ErrorIs(t, err, ErrTOTPInvalidCode)
+	_, err = service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, normalizeRecoveryCode(codes[1]), testClient)
+	assert.NoError(t, err)
+}
+
+func TestAuthService_TwoFactorChallengeAttempts(t *testing.T) {
+	// política permisiva para probar solo el límite propio del desafío
+	lenient := LockoutPolicy{FreeAttempts: 100, MaxFailures: 100, MaxIPFailures: 100, BaseDelay: time.Second, LockoutDuration: time.Minute, Window: time.Minute}
+	service := NewAuthService(setupTestDB(t), newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New(),
+		WithLoginThrottle(NewMemoryAttemptStore(), lenient))
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+	setup, err := service.SetupTOTP(ctx, "user1")
+	assert.NoError(t, err)
+	_, err = service.EnableTOTP(ctx, "user1", currentCode(t, setup.Secret, -1))
+	assert.NoError(t, err)
+
+	login, err := service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+	for i := 0; i < maxChallengeAttempts; i++ {
+		_, err = service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, "000000", testClient)
+		assert.ErrorIs(t, err, ErrTOTPInvalidCode)
+	}
+	// Caso: tras demasiados intentos ni el código correcto sirve con ese desafío
+	_, err = service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, currentCode(t, setup.Secret, 0), testClient)
+	assert.ErrorIs(t, err, ErrChallengeInvalid)
+
+	// Caso: desafío expirado
+	expired, err := NewTokenManager(TokenConfig{Secret: "test-secret"})
+	assert.NoError(t, err)
+	expired.now = func() time.Time { return time.Now().Add(-time.Hour) }
+	challenge, _, err := expired.IssueChallenge("user1")
+	assert.NoError(t, err)
+	_, err = service.CompleteTwoFactorLogin(ctx, challenge, currentCode(t, setup.Secret, 0), testClient)
+	assert.ErrorIs(t, err, ErrChallengeExpired)
+}
+
+func TestAuthService_TwoFactorFailuresSurvivePasswordLogin(t *testing.T) {
+	policy := LockoutPolicy{FreeAttempts: 4, MaxFailures: 4, MaxIPFailures: 100, BaseDelay: time.Second, LockoutDuration: time.Hour, Window: time.Hour}
+	service := NewAuthService(setupTestDB(t), newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New(),
+		WithLoginThrottle(NewMemoryAttemptStore(), policy))
+	ctx := context.Background()
+	_, err := service.Register(ctx, "user1", "password123")
+	assert.NoError(t, err)
+	setup, err := service.SetupTOTP(ctx, "user1")
+	assert.NoError(t, err)
+	_, err = service.EnableTOTP(ctx, "user1", currentCode(t, setup.Secret, -1))
+	assert.NoError(t, err)
+
+	// Caso: volver a poner la contraseña no reinicia los fallos del segundo factor
+	for round := 0; round < 2; round++ {
+		login, err := service.Login(ctx, "user1", "password123", testClient)
+		assert.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			_, err = service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, "000000", testClient)
+			assert.ErrorIs(t, err, ErrTOTPInvalidCode)
+		}
+	}
+	_, err = service.Login(ctx, "user1", "password123", testClient)
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+
+	// Caso: el código correcto sí reinicia el contador
+	assert.NoError(t, service.UnlockUser(ctx, "user1"))
+	login, err := service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+	_, err = service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, "000000", testClient)
+	assert.ErrorIs(t, err, ErrTOTPInvalidCode)
+	_, err = service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, currentCode(t, setup.Secret, 0), testClient)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		login, err = service.Login(ctx, "user1", "password123", testClient)
+		assert.NoError(t, err)
+		_, err = service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, "000000", testClient)
+		assert.ErrorIs(t, err, ErrTOTPInvalidCode)
+	}
+	_, err = service.Login(ctx, "user1", "password123", testClient)
+	assert.NoError(t, err)
+}
+
+func TestAuthService_TwoFactorExternalLogin(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db, newTestTokenManager(t), NewMemoryRevocationStore(), logrus.New())
+	ctx := context.Background()
+	identity := ExternalIdentity{Issuer: "https://idp", Subject: "sub-1", Email: "ana@example.com", EmailVerified: true}
+	_, err := service.LoginExternal(ctx, identity, testClient)
+	assert.NoError(t, err)
+	setup, err := service.SetupTOTP(ctx, "ana@example.com")
+	assert.NoError(t, err)
+	_, err = service.EnableTOTP(ctx, "ana@example.com", currentCode(t, setup.Secret, -1))
+	assert.NoError(t, err)
+
+	// Caso: con 2FA el proveedor externo tampoco entrega tokens sin el segundo factor
+	login, err := service.LoginExternal(ctx, identity, testClient)
+	assert.NoError(t, err)
+	assert.Nil(t, login.TokenPair)
+	assert.NotEmpty(t, login.ChallengeToken)
+	pair, err := service.CompleteTwoFactorLogin(ctx, login.ChallengeToken, currentCode(t, setup.Secret, 0), testClient)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, pair.AccessToken)
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"prueba_tecnica_go_guarapo/api/models"
+
+	"gorm.io/gorm"
+)
+
+func (s *authService) ListUsers(ctx context.Context) ([]*models.User, error) {
+	var users []*models.User
+	if err := s.db.WithContext(ctx).Order("username").Find(&users).Error; err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: ListUsers] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: ListUsers] Info: Listed %d users", len(users))
+	return users, nil
+}
+
+func (s *authService) SetUserDisabled(ctx context.Context, username string, disabled bool) (*models.User, error) {
+	user, err := s.findUser(ctx, username)
+	if err != nil {
+		s.logger.Warnf("[Layer: auth_service] [Method: SetUserDisabled] Warning: %v '%s'", err, username)
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Model(user).Update("disabled", disabled).Error; err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: SetUserDisabled] Error: ", err)
+		return nil, err
+	}
+	if disabled {
+		if err := s.LogoutAll(ctx, username); err != nil {
+			return nil, err
+		}
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: SetUserDisabled] Info: User '%s' disabled=%t", username, disabled)
+	return user, nil
+}
+
+// SetUserRole cambia el rol y cierra las sesiones del usuario para que el
+// nuevo rol no conviva con tokens emitidos con el anterior.
+func (s *authService) SetUserRole(ctx context.Context, username, role string) (*models.User, error) {
+	if role != models.RoleUser && role != models.RoleAdmin {
+		s.logger.Warnf("[Layer: auth_service] [Method: SetUserRole] Warning: Invalid role '%s'", role)
+		return nil, ErrInvalidRole
+	}
+	user, err := s.findUser(ctx, username)
+	if err != nil {
+		s.logger.Warnf("[Layer: auth_service] [Method: SetUserRole] Warning: %v '%s'", err, username)
+		return nil, err
+	}
+	if user.Role == role {
+		return user, nil
+	}
+	if err := s.db.WithContext(ctx).Model(user).Update("role", role).Error; err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: SetUserRole] Error: ", err)
+		return nil, err
+	}
+	if err := s.LogoutAll(ctx, username); err != nil {
+		return nil, err
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: SetUserRole] Info: User '%s' now has role '%s'", username, role)
+	return user, nil
+}
+
+// UnlockUser borra los intentos fallidos del usuario; los bloqueos por IP no se tocan.
+func (s *authService) UnlockUser(ctx context.Context, username string) error {
+	if _, err := s.findUser(ctx, username); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			s.logger.Warnf("[Layer: auth_service] [Method: UnlockUser] Warning: User '%s' not found", username)
+			return err
+		}
+		s.logger.Error("[Layer: auth_service] [Method: UnlockUser] Error: ", err)
+		return err
+	}
+	if err := s.guard.unlock(ctx, username); err != nil {
+		s.logger.Error("[Layer: auth_service] [Method: UnlockUser] Error: ", err)
+		return err
+	}
+	s.logger.Infof("[Layer: auth_service] [Method: UnlockUser] Info: User '%s' unlocked", username)
+	return nil
+}
+
+func (s *authService) findUser(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
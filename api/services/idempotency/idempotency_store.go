@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyStore guarda por usuario las claves Idempotency-Key con la respuesta de la petición que las
+// usó primero, hasta que vencen.
+type IdempotencyStore interface {
+	// Reserve guarda entry si la clave está libre o vencida y devuelve nil; si está ocupada devuelve
+	// la entrada existente sin modificarla.
+	Reserve(ctx context.Context, entry models.IdempotencyKey) (*models.IdempotencyKey, error)
+	// Complete guarda la respuesta de una clave reservada.
+	Complete(ctx context.Context, owner, key string, status int, contentType, etag string, body []byte) error
+	// Release libera una clave reservada para que un reintento vuelva a ejecutar la petición.
+	Release(ctx context.Context, owner, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type memoryIdempotencyStore struct {
+	entries map[[2]string]models.IdempotencyKey
+	mutex   sync.Mutex
+}
+
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &memoryIdempotencyStore{
+		entries: make(map[[2]string]models.IdempotencyKey),
+	}
+}
+
+func (s *memoryIdempotencyStore) Reserve(_ context.Context, entry models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := [2]string{entry.Owner, entry.Key}
+	if existing, exists := s.entries[id]; exists && existing.ExpiresAt.After(entry.CreatedAt) {
+		return &existing, nil
+	}
+	s.entries[id] = entry
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, owner, key string, status int, contentType, etag string, body []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := [2]string{owner, key}
+	if entry, exists := s.entries[id]; exists {
+		entry.Status, entry.ContentType, entry.ETag, entry.Body = status, contentType, etag, body
+		s.entries[id] = entry
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, owner, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.entries, [2]string{owner, key})
+	return nil
+}
+
+func (s *memoryIdempotencyStore) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var deleted int64
+	for id, entry := range s.entries {
+		if !now.Before(entry.ExpiresAt) {
+			delete(s.entries, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+type sqliteIdempotencyStore struct {
+	db *gorm.DB
+}
+
+func NewSQLiteIdempotencyStore(db *gorm.DB) IdempotencyStore {
+	return &sqliteIdempotencyStore{db: db}
+}
+
+func (s *sqliteIdempotencyStore) Reserve(ctx context.Context, entry models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	var existing *models.IdempotencyKey
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("owner = ? AND key = ? AND expires_at <= ?", entry.Owner, entry.Key, entry.CreatedAt).
+			Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry)
+		if result.Error != nil || result.RowsAffected == 1 {
+			return result.Error
+		}
+		existing = &models.IdempotencyKey{}
+		return tx.Where("owner = ? AND key = ?", entry.Owner, entry.Key).First(existing).Error
+	})
+	return existing, err
+}
+
+func (s *sqliteIdempotencyStore) Complete(ctx context.Context, owner, key string, status int, contentType, etag string, body []byte) error {
+	return s.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("owner = ? AND key = ?", owner, key).
+		Updates(map[string]interface{}{"status": status, "content_type": contentType, "e_tag": etag, "body": body}).Error
+}
+
+func (s *sqliteIdempotencyStore) Release(ctx context.Context, owner, key string) error {
+	return s.db.WithContext(ctx).Where("owner = ? AND key = ?", owner, key).Delete(&models.IdempotencyKey{}).Error
+}
+
+func (s *sqliteIdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
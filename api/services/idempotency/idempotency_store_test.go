@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&models.IdempotencyKey{}))
+	return db
+}
+
+func TestIdempotencyStores(t *testing.T) {
+	testScenarios := []struct {
+		testName string
+		newStore func(t *testing.T) IdempotencyStore
+	}{
+		{
+			testName: "En memoria",
+			newStore: func(*testing.T) IdempotencyStore { return NewMemoryIdempotencyStore() },
+		},
+		{
+			testName: "SQLite",
+			newStore: func(t *testing.T) IdempotencyStore { return NewSQLiteIdempotencyStore(setupTestDB(t)) },
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			store := tt.newStore(t)
+			ctx := context.Background()
+			now := time.Now()
+			entry := func(owner, key, fingerprint string, at time.Time) models.IdempotencyKey {
+				return models.IdempotencyKey{Owner: owner, Key: key, Fingerprint: fingerprint, CreatedAt: at, ExpiresAt: at.Add(time.Hour)}
+			}
+
+			existing, err := store.Reserve(ctx, entry("ana", "k1", "f1", now))
+			assert.NoError(t, err)
+			assert.Nil(t, existing)
+
+			// mientras la petición original sigue en curso la clave está ocupada sin respuesta
+			existing, err = store.Reserve(ctx, entry("ana", "k1", "f2", now))
+			assert.NoError(t, err)
+			assert.Equal(t, "f1", existing.Fingerprint)
+			assert.Zero(t, existing.Status)
+
+			assert.NoError(t, store.Complete(ctx, "ana", "k1", 201, "application/json", `"1"`, []byte(`{"id":1}`)))
+			existing, err = store.Reserve(ctx, entry("ana", "k1", "f1", now.Add(time.Minute)))
+			assert.NoError(t, err)
+			assert.Equal(t, 201, existing.Status)
+			assert.Equal(t, `"1"`, existing.ETag)
+			assert.Equal(t, `{"id":1}`, string(existing.Body))
+
+			// las claves son por usuario
+			existing, err = store.Reserve(ctx, entry("beto", "k1", "f1", now))
+			assert.NoError(t, err)
+			assert.Nil(t, existing)
+
+			// una clave liberada o vencida se puede volver a usar
+			assert.NoError(t, store.Release(ctx, "beto", "k1"))
+			existing, err = store.Reserve(ctx, entry("beto", "k1", "f3", now))
+			assert.NoError(t, err)
+			assert.Nil(t, existing)
+			existing, err = store.Reserve(ctx, entry("ana", "k1", "f4", now.Add(2*time.Hour)))
+			assert.NoError(t, err)
+			assert.Nil(t, existing)
+
+			deleted, err := store.DeleteExpired(ctx, now.Add(90*time.Minute))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), deleted)
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package services
+
+import "errors"
+
+var (
+	ErrStateInvalid    = errors.New("oidc state is unknown or expired")
+	ErrCodeExchange    = errors.New("oidc authorization code exchange failed")
+	ErrIDTokenMissing  = errors.New("oidc token response has no id_token")
+	ErrIDTokenInvalid  = errors.New("oidc id_token is invalid")
+	ErrNonceMismatch   = errors.New("oidc id_token nonce does not match")
+	ErrProviderOffline = errors.New("oidc provider discovery failed")
+)
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+const defaultStateTTL = 10 * time.Minute
+
+// OIDCConfig describe el proveedor de identidad; cualquier IdP que publique
+// /.well-known/openid-configuration sirve.
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	StateTTL     time.Duration
+	HTTPClient   *http.Client
+}
+
+// Identity son los datos del id_token ya verificado.
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	// LinkUsername es el usuario que inició el flujo con LinkCodeURL; vacío en un login.
+	LinkUsername string
+}
+
+type OIDCService interface {
+	AuthCodeURL(ctx context.Context) (authURL string, state string, err error)
+	// LinkCodeURL inicia el flujo para enlazar la identidad externa con un usuario ya autenticado.
+	LinkCodeURL(ctx context.Context, username string) (authURL string, state string, err error)
+	Exchange(ctx context.Context, state, code string) (*Identity, error)
+	StateTTL() time.Duration
+}
+
+type oidcService struct {
+	cfg    OIDCConfig
+	states *stateStore
+	logger *logrus.Logger
+
+	// el discovery se hace en el primer uso para no impedir el arranque si el IdP no responde
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+func NewOIDCService(cfg OIDCConfig, logger *logrus.Logger) OIDCService {
+	if cfg.StateTTL <= 0 {
+		cfg.StateTTL = defaultStateTTL
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"email", "profile"}
+	}
+	return &oidcService{
+		cfg:    cfg,
+		states: newStateStore(),
+		logger: logger,
+	}
+}
+
+func (s *oidcService) AuthCodeURL(ctx context.Context) (string, string, error) {
+	return s.authCodeURL(ctx, "AuthCodeURL", "")
+}
+
+func (s *oidcService) LinkCodeURL(ctx context.Context, username string) (string, string, error) {
+	return s.authCodeURL(ctx, "LinkCodeURL", username)
+}
+
+func (s *oidcService) authCodeURL(ctx context.Context, method, linkUsername string) (string, string, error) {
+	oauth, _, err := s.discover(ctx)
+	if err != nil {
+		s.logger.Errorf("[Layer: oidc_service] [Method: %s] Error: %v", method, err)
+		return "", "", err
+	}
+	state, err := randomString()
+	if err != nil {
+		s.logger.Errorf("[Layer: oidc_service] [Method: %s] Error: %v", method, err)
+		return "", "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		s.logger.Errorf("[Layer: oidc_service] [Method: %s] Error: %v", method, err)
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+	s.states.put(state, pendingLogin{
+		nonce:     nonce,
+		verifier:  verifier,
+		username:  linkUsername,
+		expiresAt: time.Now().Add(s.cfg.StateTTL),
+	})
+
+	authURL := oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+	s.logger.Infof("[Layer: oidc_service] [Method: %s] Info: Authorization request created", method)
+	return authURL, state, nil
+}
+
+func (s *oidcService) Exchange(ctx context.Context, state, code string) (*Identity, error) {
+	pending, ok := s.states.take(state)
+	if !ok {
+		s.logger.Warnln("[Layer: oidc_service] [Method: Exchange] Warning: Unknown or expired state")
+		return nil, ErrStateInvalid
+	}
+	oauth, verifier, err := s.discover(ctx)
+	if err != nil {
+		s.logger.Error("[Layer: oidc_service] [Method: Exchange] Error: ", err)
+		return nil, err
+	}
+
+	ctx = oidc.ClientContext(ctx, s.cfg.HTTPClient)
+	token, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(pending.verifier))
+	if err != nil {
+		s.logger.Warn("[Layer: oidc_service] [Method: Exchange] Warning: Code exchange failed: ", err)
+		return nil, fmt.Errorf("%w: %v", ErrCodeExchange, err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		s.logger.Warnln("[Layer: oidc_service] [Method: Exchange] Warning: Token response without id_token")
+		return nil, ErrIDTokenMissing
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		s.logger.Warn("[Layer: oidc_service] [Method: Exchange] Warning: Invalid id_token: ", err)
+		return nil, fmt.Errorf("%w: %v", ErrIDTokenInvalid, err)
+	}
+	if idToken.Nonce != pending.nonce {
+		s.logger.Warnf("[Layer: oidc_service] [Method: Exchange] Warning: Nonce mismatch for subject '%s'", idToken.Subject)
+		return nil, ErrNonceMismatch
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		s.logger.Warn("[Layer: oidc_service] [Method: Exchange] Warning: Invalid id_token claims: ", err)
+		return nil, fmt.Errorf("%w: %v", ErrIDTokenInvalid, err)
+	}
+	s.logger.Infof("[Layer: oidc_service] [Method: Exchange] Info: Subject '%s' authenticated by '%s'", idToken.Subject, idToken.Issuer)
+	return &Identity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		LinkUsername:  pending.username,
+	}, nil
+}
+
+func (s *oidcService) StateTTL() time.Duration {
+	return s.cfg.StateTTL
+}
+
+// discover consulta el documento de discovery una sola vez; el verificador
+// resultante cachea las llaves del JWKS y solo lo vuelve a pedir ante un kid desconocido.
+func (s *oidcService) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.oauth != nil {
+		return s.oauth, s.verifier, nil
+	}
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, s.cfg.HTTPClient), s.cfg.IssuerURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrProviderOffline, err)
+	}
+	s.oauth = &oauth2.Config{
+		ClientID:     s.cfg.ClientID,
+		ClientSecret: s.cfg.ClientSecret,
+		RedirectURL:  s.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       append([]string{oidc.ScopeOpenID}, s.cfg.Scopes...),
+	}
+	s.verifier = provider.Verifier(&oidc.Config{ClientID: s.cfg.ClientID})
+	return s.oauth, s.verifier, nil
+}
+
+func randomString() (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubIdP es un proveedor OIDC mínimo: discovery, JWKS y token endpoint con PKCE.
+type stubIdP struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	mu        sync.Mutex
+	codes     map[string]stubGrant
+	jwksHits  atomic.Int32
+	signWith  *rsa.PrivateKey
+	clientID  string
+	subject   string
+	email     string
+	overrides func(claims jwt.MapClaims)
+}
+
+type stubGrant struct {
+	nonce     string
+	challenge string
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	idp := &stubIdP{key: key, codes: map[string]stubGrant{}, clientID: "guarapo", subject: "sub-1", email: "ana@example.com"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                idp.server.URL,
+			"authorization_endpoint":                idp.server.URL + "/authorize",
+			"token_endpoint":                        idp.server.URL + "/token",
+			"jwks_uri":                              idp.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.jwksHits.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		idp.mu.Lock()
+		grant, ok := idp.codes[r.PostForm.Get("code")]
+		delete(idp.codes, r.PostForm.Get("code"))
+		idp.mu.Unlock()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		claims := jwt.MapClaims{
+			"iss":            idp.server.URL,
+			"sub":            idp.subject,
+			"aud":            idp.clientID,
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"nonce":          grant.nonce,
+			"email":          idp.email,
+			"email_verified": true,
+		}
+		if idp.overrides != nil {
+			idp.overrides(claims)
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "key-1"
+		signKey := idp.key
+		if idp.signWith != nil {
+			signKey = idp.signWith
+		}
+		idToken, err := token.SignedString(signKey)
+		assert.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "idp-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   60,
+			"id_token":     idToken,
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// authorize simula que el usuario aprueba el login en el IdP y devuelve el state y el code del callback.
+func (idp *stubIdP) authorize(t *testing.T, authURL string) (string, string) {
+	parsed, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, idp.clientID, query.Get("client_id"))
+	assert.Contains(t, query.Get("scope"), "openid")
+
+	code := "code-" + query.Get("state")
+	idp.mu.Lock()
+	idp.codes[code] = stubGrant{nonce: query.Get("nonce"), challenge: query.Get("code_challenge")}
+	idp.mu.Unlock()
+	return query.Get("state"), code
+}
+
+func newTestOIDCService(idp *stubIdP) OIDCService {
+	return NewOIDCService(OIDCConfig{
+		IssuerURL:    idp.server.URL,
+		ClientID:     idp.clientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/api/auth/oidc/callback",
+	}, logrus.New())
+}
+
+func TestOIDCService_Exchange(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	testScenarios := []struct {
+		testName  string
+		setup     func(idp *stubIdP)
+		tamper    func(state, code string) (string, string)
+		wantErr   error
+		wantEmail string
+	}{
+		{
+			testName:  "Login exitoso",
+			wantEmail: "ana@example.com",
+		},
+		{
+			testName: "State desconocido",
+			tamper: func(state, code string) (string, string) {
+				return "forged-state", code
+			},
+			wantErr: ErrStateInvalid,
+		},
+		{
+			testName: "Code inválido",
+			tamper: func(state, code string) (string, string) {
+				return state, "unknown-code"
+			},
+			wantErr: ErrCodeExchange,
+		},
+		{
+			testName: "Nonce distinto",
+			setup: func(idp *stubIdP) {
+				idp.overrides = func(claims jwt.MapClaims) { claims["nonce"] = "replayed" }
+			},
+			wantErr: ErrNonceMismatch,
+		},
+		{
+			testName: "Audiencia de otro cliente",
+			setup: func(idp *stubIdP) {
+				idp.overrides = func(claims jwt.MapClaims) { claims["aud"] = "other-client" }
+			},
+			wantErr: ErrIDTokenInvalid,
+		},
+		{
+			testName: "Firma con llave desconocida",
+			setup: func(idp *stubIdP) {
+				idp.signWith = otherKey
+			},
+			wantErr: ErrIDTokenInvalid,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			idp := newStubIdP(t)
+			if tt.setup != nil {
+				tt.setup(idp)
+			}
+			service := newTestOIDCService(idp)
+			ctx := context.Background()
+
+			authURL, state, err := service.AuthCodeURL(ctx)
+			assert.NoError(t, err)
+			callbackState, code := idp.authorize(t, authURL)
+			assert.Equal(t, state, callbackState)
+			if tt.tamper != nil {
+				callbackState, code = tt.tamper(callbackState, code)
+			}
+
+			identity, err := service.Exchange(ctx, callbackState, code)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, identity)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, idp.server.URL, identity.Issuer)
+			assert.Equal(t, "sub-1", identity.Subject)
+			assert.Equal(t, tt.wantEmail, identity.Email)
+			assert.True(t, identity.EmailVerified)
+		})
+	}
+}
+
+func TestOIDCService_StateIsSingleUse(t *testing.T) {
+	idp := newStubIdP(t)
+	service := newTestOIDCService(idp)
+	ctx := context.Background()
+
+	authURL, _, err := service.AuthCodeURL(ctx)
+	assert.NoError(t, err)
+	state, code := idp.authorize(t, authURL)
+	_, err = service.Exchange(ctx, state, code)
+	assert.NoError(t, err)
+
+	_, err = service.Exchange(ctx, state, code)
+	assert.ErrorIs(t, err, ErrStateInvalid)
+}
+
+func TestOIDCService_LinkCarriesUsername(t *testing.T) {
+	idp := newStubIdP(t)
+	service := newTestOIDCService(idp)
+	ctx := context.Background()
+
+	authURL, _, err := service.LinkCodeURL(ctx, "ana")
+	assert.NoError(t, err)
+	state, code := idp.authorize(t, authURL)
+	identity, err := service.Exchange(ctx, state, code)
+	assert.NoError(t, err)
+	assert.Equal(t, "ana", identity.LinkUsername)
+
+	authURL, _, err = service.AuthCodeURL(ctx)
+	assert.NoError(t, err)
+	state, code = idp.authorize(t, authURL)
+	identity, err = service.Exchange(ctx, state, code)
+	assert.NoError(t, err)
+	assert.Empty(t, identity.LinkUsername)
+}
+
+func TestOIDCService_StateExpires(t *testing.T) {
+	idp := newStubIdP(t)
+	service := newTestOIDCService(idp)
+	ctx := context.Background()
+
+	authURL, _, err := service.AuthCodeURL(ctx)
+	assert.NoError(t, err)
+	state, code := idp.authorize(t, authURL)
+	service.(*oidcService).states.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	_, err = service.Exchange(ctx, state, code)
+	assert.ErrorIs(t, err, ErrStateInvalid)
+}
+
+func TestOIDCService_CachesJWKS(t *testing.T) {
+	idp := newStubIdP(t)
+	service := newTestOIDCService(idp)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		authURL, _, err := service.AuthCodeURL(ctx)
+		assert.NoError(t, err)
+		state, code := idp.authorize(t, authURL)
+		_, err = service.Exchange(ctx, state, code)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), idp.jwksHits.Load())
+}
+
+func TestOIDCService_ProviderOffline(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	service := NewOIDCService(OIDCConfig{IssuerURL: server.URL, ClientID: "guarapo"}, logrus.New())
+
+	_, _, err := service.AuthCodeURL(context.Background())
+	assert.ErrorIs(t, err, ErrProviderOffline)
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// pendingLogin guarda lo necesario para completar un login iniciado: el nonce
+// esperado en el id_token, el code_verifier de PKCE y, al enlazar, el usuario autenticado.
+type pendingLogin struct {
+	nonce     string
+	verifier  string
+	username  string
+	expiresAt time.Time
+}
+
+type stateStore struct {
+	mu      sync.Mutex
+	pending map[string]pendingLogin
+	now     func() time.Time
+}
+
+func newStateStore() *stateStore {
+	return &stateStore{
+		pending: make(map[string]pendingLogin),
+		now:     time.Now,
+	}
+}
+
+func (s *stateStore) put(state string, login pendingLogin) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for key, value := range s.pending {
+		if now.After(value.expiresAt) {
+			delete(s.pending, key)
+		}
+	}
+	s.pending[state] = login
+}
+
+// take consume el state: cada uno solo sirve para un callback.
+func (s *stateStore) take(state string) (pendingLogin, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	login, ok := s.pending[state]
+	if !ok {
+		return pendingLogin{}, false
+	}
+	delete(s.pending, state)
+	if s.now().After(login.expiresAt) {
+		return pendingLogin{}, false
+	}
+	return login, true
+}
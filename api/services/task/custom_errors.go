@@ -3,7 +3,42 @@ package services
 import "errors"
 
 var (
-	ErrTaskNotFound  = errors.New("task not found or not owned by user")
-	ErrTitleRequired = errors.New("title is required")
-	ErrUserRequired  = errors.New("UserName is required")
+	ErrTaskNotFound         = errors.New("task not found or not owned by user")
+	ErrTitleRequired        = errors.New("title is required")
+	ErrUserRequired         = errors.New("UserName is required")
+	ErrInvalidSort          = errors.New("invalid sort field")
+	ErrInvalidCursor        = errors.New("invalid pagination cursor")
+	ErrUnknownField         = errors.New("unknown or read-only task field")
+	ErrInvalidPriority      = errors.New("priority must be low, medium, high or urgent")
+	ErrDescriptionTooLong   = errors.New("description is too long")
+	ErrTagNotFound          = errors.New("tag not found or not owned by user")
+	ErrTagExists            = errors.New("tag name already in use")
+	ErrInvalidTagName       = errors.New("tag name must be between 1 and 50 characters")
+	ErrTooManyTags          = errors.New("too many tags for a task")
+	ErrInvalidTagMode       = errors.New("tag mode must be all or any")
+	ErrProjectNotFound      = errors.New("project not found or not owned by user")
+	ErrProjectArchived      = errors.New("project is archived")
+	ErrInvalidProjectName   = errors.New("project name must be between 1 and 100 characters")
+	ErrInvalidColor         = errors.New("color must be a #RRGGBB hex value")
+	ErrInvalidCascade       = errors.New("invalid cascade mode for project tasks")
+	ErrParentNotFound       = errors.New("parent task not found or not owned by user")
+	ErrTaskCycle            = errors.New("a task cannot be its own ancestor")
+	ErrMaxDepthExceeded     = errors.New("task hierarchy is too deep")
+	ErrBlockerNotFound      = errors.New("blocker task not found or not owned by user")
+	ErrDependencyExists     = errors.New("task is already blocked by that task")
+	ErrDependencyNotFound   = errors.New("task is not blocked by that task")
+	ErrDependencyCycle      = errors.New("dependency would create a cycle")
+	ErrTaskBlocked          = errors.New("task has open blockers")
+	ErrInvalidRecurrence    = errors.New("invalid recurrence rule")
+	ErrRecurrenceNeedsDueAt = errors.New("a recurring task needs a due date")
+	ErrParentTrashed        = errors.New("parent task is in the trash")
+	ErrRevisionNotFound     = errors.New("task version not available in history")
+	ErrInvalidBulkMode      = errors.New("bulk mode must be atomic or best_effort")
+	ErrInvalidBulkSize      = errors.New("invalid number of bulk operations")
+	ErrInvalidBulkOperation = errors.New("unknown bulk operation")
+	ErrInvalidSearchQuery   = errors.New("search query has no valid terms")
+	// ErrBulkAborted marca las operaciones que no se aplicaron porque otra del lote atómico falló
+	ErrBulkAborted = errors.New("operation rolled back because another operation failed")
+	// ErrVersionConflict indica que la tarea cambió desde la versión que envió el cliente
+	ErrVersionConflict = errors.New("task version does not match")
 )
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"prueba_tecnica_go_guarapo/api/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	MaxProjectNameLength = 100
+
+	// qué pasa con las tareas al archivar o eliminar un proyecto
+	ProjectTasksKeep   = "keep"
+	ProjectTasksMove   = "move"
+	ProjectTasksDelete = "delete"
+)
+
+var projectColor = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+type ProjectFields struct {
+	Name  string
+	Color string
+}
+
+type ProjectService interface {
+	ListProjects(ctx context.Context, username string, includeArchived bool) ([]*models.Project, error)
+	GetProject(ctx context.Context, id int, username string) (*models.Project, error)
+	CreateProject(ctx context.Context, username string, fields ProjectFields) (*models.Project, error)
+	UpdateProject(ctx context.Context, id int, username string, fields ProjectFields) (*models.Project, error)
+	// ArchiveProject acepta keep (por defecto), move (a la bandeja de entrada) o delete para sus tareas
+	ArchiveProject(ctx context.Context, id int, username, cascade string) (*models.Project, error)
+	UnarchiveProject(ctx context.Context, id int, username string) (*models.Project, error)
+	// DeleteProject acepta move (por defecto) o delete para sus tareas
+	DeleteProject(ctx context.Context, id int, username, cascade string) error
+}
+
+type projectService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewProjectService(db *gorm.DB, logger *logrus.Logger) ProjectService {
+	return &projectService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (f ProjectFields) normalize() (ProjectFields, error) {
+	f.Name = strings.TrimSpace(f.Name)
+	if f.Name == "" || utf8.RuneCountInString(f.Name) > MaxProjectNameLength {
+		return f, ErrInvalidProjectName
+	}
+	if f.Color != "" && !projectColor.MatchString(f.Color) {
+		return f, ErrInvalidColor
+	}
+	f.Color = strings.ToUpper(f.Color)
+	return f, nil
+}
+
+func (s *projectService) ListProjects(ctx context.Context, username string, includeArchived bool) ([]*models.Project, error) {
+	query := s.db.WithContext(ctx).Where("owner = ?", username)
+	if !includeArchived {
+		query = query.Where("archived = ?", false)
+	}
+	var projects []*models.Project
+	if err := query.Order("name").Find(&projects).Error; err != nil {
+		s.logger.Error("[Layer: project_service] [Method: ListProjects] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: project_service] [Method: ListProjects] Info: User '%s' listed %d projects", username, len(projects))
+	return projects, nil
+}
+
+func (s *projectService) GetProject(ctx context.Context, id int, username string) (*models.Project, error) {
+	project, err := findProject(s.db.WithContext(ctx), uint(id), username)
+	if err != nil {
+		if errors.Is(err, ErrProjectNotFound) {
+			s.logger.Warnf("[Layer: project_service] [Method: GetProject] Warning: Project '%d' not found or not owned by user '%s'", id, username)
+			return nil, err
+		}
+		s.logger.Error("[Layer: project_service] [Method: GetProject] Error: ", err)
+		return nil, err
+	}
+	return project, nil
+}
+
+func (s *projectService) CreateProject(ctx context.Context, username string, fields ProjectFields) (*models.Project, error) {
+	fields, err := fields.normalize()
+	if err != nil {
+		s.logger.Warnf("[Layer: project_service] [Method: CreateProject] Warning: Invalid project for user '%s': %v", username, err)
+		return nil, err
+	}
+	project := &models.Project{Name: fields.Name, Color: fields.Color, Owner: username}
+	if err := s.db.WithContext(ctx).Create(project).Error; err != nil {
+		s.logger.Error("[Layer: project_service] [Method: CreateProject] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: project_service] [Method: CreateProject] Info: Project '%d' created for user '%s'", project.ID, username)
+	return project, nil
+}
+
+func (s *projectService) UpdateProject(ctx context.Context, id int, username string, fields ProjectFields) (*models.Project, error) {
+	fields, err := fields.normalize()
+	if err != nil {
+		s.logger.Warnf("[Layer: project_service] [Method: UpdateProject] Warning: Invalid project '%d': %v", id, err)
+		return nil, err
+	}
+	project, err := s.GetProject(ctx, id, username)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Model(project).Updates(map[string]interface{}{"name": fields.Name, "color": fields.Color}).Error; err != nil {
+		s.logger.Error("[Layer: project_service] [Method: UpdateProject] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: project_service] [Method: UpdateProject] Info: Project '%d' updated for user '%s'", id, username)
+	return project, nil
+}
+
+func (s *projectService) ArchiveProject(ctx context.Context, id int, username, cascade string) (*models.Project, error) {
+	if cascade == "" {
+		cascade = ProjectTasksKeep
+	}
+	if cascade != ProjectTasksKeep && cascade != ProjectTasksMove && cascade != ProjectTasksDelete {
+		s.logger.Warnf("[Layer: project_service] [Method: ArchiveProject] Warning: Invalid cascade '%s'", cascade)
+		return nil, ErrInvalidCascade
+	}
+	project, err := s.GetProject(ctx, id, username)
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := cascadeProjectTasks(tx, project, cascade); err != nil {
+			return err
+		}
+		return tx.Model(project).Update("archived", true).Error
+	})
+	if err != nil {
+		s.logger.Error("[Layer: project_service] [Method: ArchiveProject] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: project_service] [Method: ArchiveProject] Info: Project '%d' archived (%s tasks) for user '%s'", id, cascade, username)
+	return project, nil
+}
+
+func (s *projectService) UnarchiveProject(ctx context.Context, id int, username string) (*models.Project, error) {
+	project, err := s.GetProject(ctx, id, username)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Model(project).Update("archived", false).Error; err != nil {
+		s.logger.Error("[Layer: project_service] [Method: UnarchiveProject] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: project_service] [Method: UnarchiveProject] Info: Project '%d' unarchived for user '%s'", id, username)
+	return project, nil
+}
+
+func (s *projectService) DeleteProject(ctx context.Context, id int, username, cascade string) error {
+	if cascade == "" {
+		cascade = ProjectTasksMove
+	}
+	if cascade != ProjectTasksMove && cascade != ProjectTasksDelete {
+		s.logger.Warnf("[Layer: project_service] [Method: DeleteProject] Warning: Invalid cascade '%s'", cascade)
+		return ErrInvalidCascade
+	}
+	project, err := s.GetProject(ctx, id, username)
+	if err != nil {
+		return err
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := cascadeProjectTasks(tx, project, cascade); err != nil {
+			return err
+		}
+		return tx.Delete(project).Error
+	})
+	if err != nil {
+		s.logger.Error("[Layer: project_service] [Method: DeleteProject] Error: ", err)
+		return err
+	}
+	s.logger.Infof("[Layer: project_service] [Method: DeleteProject] Info: Project '%d' deleted (%s tasks) for user '%s'", id, cascade, username)
+	return nil
+}
+
+// cascadeProjectTasks mueve las tareas a la bandeja de entrada (subiendo su versión) o las elimina,
+// y lo registra en el historial de cada una.
+func cascadeProjectTasks(tx *gorm.DB, project *models.Project, cascade string) error {
+	var ids []uint
+	if err := tx.Model(&models.Task{}).Where("project_id = ? AND owner = ?", project.ID, project.Owner).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	switch cascade {
+	case ProjectTasksMove:
+		if err := tx.Model(&models.Task{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			FieldProjectID: nil,
+			"version":      gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		return recordTaskEvents(tx, ids, models.TaskEvent{
+			Action:   models.TaskEventUpdated,
+			Field:    FieldProjectID,
+			OldValue: strconv.FormatUint(uint64(project.ID), 10),
+			NewValue: "null",
+		})
+	case ProjectTasksDelete:
+		dependents, err := snapshotDependents(tx, ids)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Task{}).Where("id IN ?", ids).Updates(trashUpdates(tx)).Error; err != nil {
+			return err
+		}
+		if err := recordTaskEvents(tx, ids, models.TaskEvent{Action: models.TaskEventDeleted}); err != nil {
+			return err
+		}
+		return dependents.sync(tx)
+	}
+	return nil
+}
+
+func findProject(db *gorm.DB, id uint, username string) (*models.Project, error) {
+	var project models.Project
+	if err := db.Where("id = ? AND owner = ?", id, username).First(&project).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, err
+	}
+	return &project, nil
+}
+
+// checkProjectAssignable valida que una tarea pueda pasar al proyecto indicado; nil es la bandeja de entrada.
+func checkProjectAssignable(db *gorm.DB, projectID *uint, username string) error {
+	if projectID == nil {
+		return nil
+	}
+	project, err := findProject(db, *projectID, username)
+	if err != nil {
+		return err
+	}
+	if project.Archived {
+		return ErrProjectArchived
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectService(t *testing.T) {
+	db := setupTestDB(t)
+	projects := NewProjectService(db, logrus.New())
+	ctx := context.Background()
+
+	_, err := projects.CreateProject(ctx, "user1", ProjectFields{Name: " "})
+	assert.ErrorIs(t, err, ErrInvalidProjectName)
+	_, err = projects.CreateProject(ctx, "user1", ProjectFields{Name: strings.Repeat("a", MaxProjectNameLength+1)})
+	assert.ErrorIs(t, err, ErrInvalidProjectName)
+	_, err = projects.CreateProject(ctx, "user1", ProjectFields{Name: "Trabajo", Color: "azul"})
+	assert.ErrorIs(t, err, ErrInvalidColor)
+
+	work, err := projects.CreateProject(ctx, "user1", ProjectFields{Name: " Trabajo ", Color: "#1e88e5"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Trabajo", work.Name)
+	assert.Equal(t, "#1E88E5", work.Color)
+	home, _ := projects.CreateProject(ctx, "user1", ProjectFields{Name: "Casa"})
+	_, _ = projects.CreateProject(ctx, "user2", ProjectFields{Name: "Ajeno"})
+
+	_, err = projects.GetProject(ctx, int(work.ID), "user2")
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+
+	updated, err := projects.UpdateProject(ctx, int(home.ID), "user1", ProjectFields{Name: "Hogar", Color: "#00AA00"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hogar", updated.Name)
+
+	// Caso: archivar oculta el proyecto del listado por defecto
+	archived, err := projects.ArchiveProject(ctx, int(home.ID), "user1", "")
+	assert.NoError(t, err)
+	assert.True(t, archived.Archived)
+	list, _ := projects.ListProjects(ctx, "user1", false)
+	assert.Len(t, list, 1)
+	list, _ = projects.ListProjects(ctx, "user1", true)
+	assert.Len(t, list, 2)
+	unarchived, err := projects.UnarchiveProject(ctx, int(home.ID), "user1")
+	assert.NoError(t, err)
+	assert.False(t, unarchived.Archived)
+
+	_, err = projects.ArchiveProject(ctx, int(home.ID), "user1", "purge")
+	assert.ErrorIs(t, err, ErrInvalidCascade)
+	assert.ErrorIs(t, projects.DeleteProject(ctx, int(home.ID), "user1", ProjectTasksKeep), ErrInvalidCascade)
+	assert.ErrorIs(t, projects.DeleteProject(ctx, 999, "user1", ""), ErrProjectNotFound)
+}
+
+func TestProjectCascades(t *testing.T) {
+	testScenarios := []struct {
+		testName         string
+		archive          bool
+		cascade          string
+		expectedInbox    int
+		expectedInProj   int
+		expectedDeleted  int
+		expectedArchived bool
+	}{
+		{testName: "Archivar conservando tareas", archive: true, cascade: ProjectTasksKeep, expectedInProj: 2, expectedArchived: true},
+		{testName: "Archivar moviendo a la bandeja", archive: true, cascade: ProjectTasksMove, expectedInbox: 2, expectedArchived: true},
+		{testName: "Archivar eliminando tareas", archive: true, cascade: ProjectTasksDelete, expectedDeleted: 2, expectedArchived: true},
+		{testName: "Eliminar moviendo a la bandeja", cascade: "", expectedInbox: 2},
+		{testName: "Eliminar con sus tareas", cascade: ProjectTasksDelete, expectedDeleted: 2},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			db := setupTestDB(t)
+			projects := NewProjectService(db, logrus.New())
+			tasks := NewTaskService(db, logrus.New())
+			ctx := context.Background()
+
+			project, _ := projects.CreateProject(ctx, "user1", ProjectFields{Name: "Trabajo"})
+			first, _ := tasks.CreateTask(ctx, "user1", TaskFields{Title: "Informe", ProjectID: &project.ID})
+			_, _ = tasks.CreateTask(ctx, "user1", TaskFields{Title: "Reunión", ProjectID: &project.ID})
+			_, _ = tasks.CreateTask(ctx, "user1", TaskFields{Title: "Otra"})
+
+			if tt.archive {
+				archived, err := projects.ArchiveProject(ctx, int(project.ID), "user1", tt.cascade)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedArchived, archived.Archived)
+			} else {
+				assert.NoError(t, projects.DeleteProject(ctx, int(project.ID), "user1", tt.cascade))
+				_, err := projects.GetProject(ctx, int(project.ID), "user1")
+				assert.ErrorIs(t, err, ErrProjectNotFound)
+			}
+
+			var inbox, inProject, deleted int64
+			db.Model(&models.Task{}).Where("project_id IS NULL AND title <> ?", "Otra").Count(&inbox)
+			db.Model(&models.Task{}).Where("project_id = ?", project.ID).Count(&inProject)
+			db.Unscoped().Model(&models.Task{}).Where("deleted_at IS NOT NULL").Count(&deleted)
+			assert.Equal(t, int64(tt.expectedInbox), inbox)
+			assert.Equal(t, int64(tt.expectedInProj), inProject)
+			assert.Equal(t, int64(tt.expectedDeleted), deleted)
+
+			if tt.expectedInbox > 0 {
+				moved, err := tasks.GetTaskByID(ctx, int(first.ID), "user1")
+				assert.NoError(t, err)
+				assert.Nil(t, moved.ProjectID)
+				assert.Equal(t, first.Version+1, moved.Version)
+
+				history, err := tasks.ListTaskHistory(ctx, int(first.ID), "user1", 1, "")
+				assert.NoError(t, err)
+				assert.Equal(t, FieldProjectID, history.Events[0].Field)
+				assert.Equal(t, "null", history.Events[0].NewValue)
+				assert.Equal(t, moved.Version, history.Events[0].Version)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+
+	// maxRecurrencePeriods acota la búsqueda de ocurrencias (por ejemplo un 29 de febrero anual).
+	maxRecurrencePeriods = 10000
+	untilLayout          = "20060102T150405Z"
+	untilDateLayout      = "20060102"
+)
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// RRule es el subconjunto de RFC 5545 que admiten las tareas: FREQ, INTERVAL, BYDAY (sin ordinales),
+// COUNT y UNTIL. La primera ocurrencia es la fecha de inicio, coincida o no con BYDAY.
+type RRule struct {
+	Freq     string
+	Interval int
+	ByDay    []time.Weekday
+	// Count son las ocurrencias que quedan contando la de inicio; 0 es sin límite
+	Count int
+	Until *time.Time
+}
+
+// ParseRRule acepta la regla con o sin el prefijo "RRULE:" y sin distinguir mayúsculas.
+func ParseRRule(raw string) (*RRule, error) {
+	raw = strings.ToUpper(strings.TrimSpace(raw))
+	raw = strings.TrimPrefix(raw, "RRULE:")
+	if raw == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRecurrence)
+	}
+	rule := &RRule{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: malformed part '%s'", ErrInvalidRecurrence, part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicated %s", ErrInvalidRecurrence, key)
+		}
+		seen[key] = true
+		switch key {
+		case "FREQ":
+			if value != FreqDaily && value != FreqWeekly && value != FreqMonthly && value != FreqYearly {
+				return nil, fmt.Errorf("%w: unsupported FREQ '%s'", ErrInvalidRecurrence, value)
+			}
+			rule.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRecurrence)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: COUNT must be a positive integer", ErrInvalidRecurrence)
+			}
+			rule.Count = n
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day := slices.Index(weekdayCodes, code)
+				if day < 0 {
+					return nil, fmt.Errorf("%w: unsupported BYDAY '%s'", ErrInvalidRecurrence, code)
+				}
+				if !slices.Contains(rule.ByDay, time.Weekday(day)) {
+					rule.ByDay = append(rule.ByDay, time.Weekday(day))
+				}
+			}
+		case "UNTIL":
+			until, err := time.Parse(untilLayout, value)
+			if err != nil {
+				// una fecha sin hora incluye todo ese día
+				date, dateErr := time.Parse(untilDateLayout, value)
+				if dateErr != nil {
+					return nil, fmt.Errorf("%w: UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ", ErrInvalidRecurrence)
+				}
+				until = date.Add(24*time.Hour - time.Second)
+			}
+			rule.Until = &until
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %s", ErrInvalidRecurrence, key)
+		}
+	}
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRecurrence)
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRecurrence)
+	}
+	return rule, nil
+}
+
+// String devuelve la regla en forma canónica, que es como se guarda.
+func (r *RRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := slices.Clone(r.ByDay)
+		// la semana empieza el lunes, como WKST por defecto
+		slices.SortFunc(days, func(a, b time.Weekday) int { return (int(a)+6)%7 - (int(b)+6)%7 })
+		codes := make([]string, 0, len(days))
+		for _, day := range days {
+			codes = append(codes, weekdayCodes[day])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences devuelve hasta n ocurrencias empezando por start.
+func (r *RRule) Occurrences(start time.Time, n int) []time.Time {
+	if r.Count > 0 && r.Count < n {
+		n = r.Count
+	}
+	if n <= 0 || (r.Until != nil && start.After(*r.Until)) {
+		return nil
+	}
+	occurrences := []time.Time{start}
+	if len(occurrences) < n {
+		r.after(start, func(t time.Time) bool {
+			occurrences = append(occurrences, t)
+			return len(occurrences) < n
+		})
+	}
+	return occurrences
+}
+
+// Next devuelve la ocurrencia siguiente a start y la regla que le corresponde (COUNT descuenta la
+// actual); false si la serie terminó.
+func (r *RRule) Next(start time.Time) (*RRule, time.Time, bool) {
+	if r.Count == 1 {
+		return nil, time.Time{}, false
+	}
+	var next time.Time
+	r.after(start, func(t time.Time) bool {
+		next = t
+		return false
+	})
+	if next.IsZero() {
+		return nil, time.Time{}, false
+	}
+	rest := *r
+	if rest.Count > 0 {
+		rest.Count--
+	}
+	return &rest, next, true
+}
+
+// after recorre en orden las ocurrencias posteriores a start hasta UNTIL o hasta que fn devuelva false.
+func (r *RRule) after(start time.Time, fn func(time.Time) bool) {
+	for k := 0; k < maxRecurrencePeriods; k++ {
+		for _, candidate := range r.period(start, k) {
+			if !candidate.After(start) {
+				continue
+			}
+			if r.Until != nil && candidate.After(*r.Until) {
+				return
+			}
+			if !fn(candidate) {
+				return
+			}
+		}
+	}
+}
+
+// period devuelve, ordenadas, las fechas del k-ésimo período (día, semana, mes o año) contado desde start.
+func (r *RRule) period(start time.Time, k int) []time.Time {
+	step := k * r.Interval
+	hour, minute, sec := start.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, sec, start.Nanosecond(), start.Location())
+	}
+
+	var first, end time.Time
+	switch r.Freq {
+	case FreqDaily:
+		day := start.AddDate(0, 0, step)
+		if len(r.ByDay) > 0 && !slices.Contains(r.ByDay, day.Weekday()) {
+			return nil
+		}
+		return []time.Time{day}
+	case FreqWeekly:
+		if len(r.ByDay) == 0 {
+			return []time.Time{start.AddDate(0, 0, 7*step)}
+		}
+		first = start.AddDate(0, 0, 7*step-(int(start.Weekday())+6)%7)
+		end = first.AddDate(0, 0, 7)
+	case FreqMonthly:
+		first = at(start.Year(), start.Month()+time.Month(step), 1)
+		end = first.AddDate(0, 1, 0)
+		if len(r.ByDay) == 0 {
+			// los meses sin ese día (31, 30 o 29 de febrero) se saltan
+			day := at(first.Year(), first.Month(), start.Day())
+			if day.Month() != first.Month() {
+				return nil
+			}
+			return []time.Time{day}
+		}
+	case FreqYearly:
+		first = at(start.Year()+step, time.January, 1)
+		end = first.AddDate(1, 0, 0)
+		if len(r.ByDay) == 0 {
+			day := at(first.Year(), start.Month(), start.Day())
+			if day.Month() != start.Month() {
+				return nil
+			}
+			return []time.Time{day}
+		}
+	}
+
+	var days []time.Time
+	for day := first; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if slices.Contains(r.ByDay, day.Weekday()) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
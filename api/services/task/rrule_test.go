@@ -0,0 +1,98 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRRule(t *testing.T) {
+	testScenarios := []struct {
+		testName    string
+		raw         string
+		expected    string
+		expectedErr error
+	}{
+		{testName: "Semanal", raw: "FREQ=WEEKLY;BYDAY=MO", expected: "FREQ=WEEKLY;BYDAY=MO"},
+		{testName: "Forma canónica", raw: "rrule:byday=fr,mo,fr;interval=2;freq=weekly", expected: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"},
+		{testName: "INTERVAL=1 se omite", raw: "FREQ=DAILY;INTERVAL=1;COUNT=3", expected: "FREQ=DAILY;COUNT=3"},
+		{testName: "UNTIL con fecha", raw: "FREQ=MONTHLY;UNTIL=20261231", expected: "FREQ=MONTHLY;UNTIL=20261231T235959Z"},
+		{testName: "Sin FREQ", raw: "INTERVAL=2", expectedErr: ErrInvalidRecurrence},
+		{testName: "FREQ no soportada", raw: "FREQ=HOURLY", expectedErr: ErrInvalidRecurrence},
+		{testName: "BYDAY con ordinal", raw: "FREQ=MONTHLY;BYDAY=1MO", expectedErr: ErrInvalidRecurrence},
+		{testName: "COUNT y UNTIL", raw: "FREQ=DAILY;COUNT=2;UNTIL=20261231", expectedErr: ErrInvalidRecurrence},
+		{testName: "Parte desconocida", raw: "FREQ=DAILY;BYMONTH=1", expectedErr: ErrInvalidRecurrence},
+		{testName: "INTERVAL inválido", raw: "FREQ=DAILY;INTERVAL=0", expectedErr: ErrInvalidRecurrence},
+		{testName: "Parte repetida", raw: "FREQ=DAILY;FREQ=WEEKLY", expectedErr: ErrInvalidRecurrence},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			rule, err := ParseRRule(tt.raw)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, rule.String())
+		})
+	}
+}
+
+func TestRRuleOccurrences(t *testing.T) {
+	// 2026-01-05 es lunes
+	start := time.Date(2026, time.January, 5, 9, 0, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2026, month, d, 9, 0, 0, 0, time.UTC)
+	}
+
+	testScenarios := []struct {
+		testName string
+		rule     string
+		start    time.Time
+		n        int
+		expected []time.Time
+	}{
+		{testName: "Diaria cada 2 días", rule: "FREQ=DAILY;INTERVAL=2", start: start, n: 3,
+			expected: []time.Time{day(1, 5), day(1, 7), day(1, 9)}},
+		{testName: "Días hábiles", rule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", start: day(1, 8), n: 4,
+			expected: []time.Time{day(1, 8), day(1, 9), day(1, 12), day(1, 13)}},
+		{testName: "Lunes y miércoles", rule: "FREQ=WEEKLY;BYDAY=MO,WE", start: start, n: 4,
+			expected: []time.Time{day(1, 5), day(1, 7), day(1, 12), day(1, 14)}},
+		{testName: "Cada dos semanas el viernes", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", start: start, n: 3,
+			expected: []time.Time{day(1, 5), day(1, 9), day(1, 23)}},
+		{testName: "Mensual el 31 salta meses cortos", rule: "FREQ=MONTHLY", start: day(1, 31), n: 3,
+			expected: []time.Time{day(1, 31), day(3, 31), day(5, 31)}},
+		{testName: "COUNT limita la serie", rule: "FREQ=WEEKLY;COUNT=2", start: start, n: 5,
+			expected: []time.Time{day(1, 5), day(1, 12)}},
+		{testName: "UNTIL incluye el día", rule: "FREQ=DAILY;UNTIL=20260107", start: start, n: 5,
+			expected: []time.Time{day(1, 5), day(1, 6), day(1, 7)}},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			rule, err := ParseRRule(tt.rule)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, rule.Occurrences(tt.start, tt.n))
+		})
+	}
+}
+
+func TestRRuleNext(t *testing.T) {
+	start := time.Date(2026, time.January, 5, 9, 0, 0, 0, time.UTC)
+
+	rule, _ := ParseRRule("FREQ=WEEKLY;COUNT=2")
+	rest, next, ok := rule.Next(start)
+	assert.True(t, ok)
+	assert.Equal(t, start.AddDate(0, 0, 7), next)
+	assert.Equal(t, "FREQ=WEEKLY;COUNT=1", rest.String())
+
+	// Caso: la última ocurrencia no tiene siguiente
+	_, _, ok = rest.Next(next)
+	assert.False(t, ok)
+
+	until, _ := ParseRRule("FREQ=DAILY;UNTIL=20260105T235959Z")
+	_, _, ok = until.Next(start)
+	assert.False(t, ok)
+}
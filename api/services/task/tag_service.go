@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"prueba_tecnica_go_guarapo/api/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type TagService interface {
+	ListTags(ctx context.Context, username string) ([]*models.Tag, error)
+	GetTag(ctx context.Context, id int, username string) (*models.Tag, error)
+	CreateTag(ctx context.Context, username, name string) (*models.Tag, error)
+	// RenameTag y DeleteTag cambian las etiquetas de las tareas que la tienen: suben su versión y lo
+	// registran en su historial
+	RenameTag(ctx context.Context, id int, username, name string) (*models.Tag, error)
+	// DeleteTag quita la etiqueta de todas las tareas que la tenían
+	DeleteTag(ctx context.Context, id int, username string) error
+}
+
+type tagService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewTagService(db *gorm.DB, logger *logrus.Logger) TagService {
+	return &tagService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *tagService) ListTags(ctx context.Context, username string) ([]*models.Tag, error) {
+	var tags []*models.Tag
+	if err := s.db.WithContext(ctx).Where("owner = ?", username).Order("name").Find(&tags).Error; err != nil {
+		s.logger.Error("[Layer: tag_service] [Method: ListTags] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: tag_service] [Method: ListTags] Info: User '%s' listed %d tags", username, len(tags))
+	return tags, nil
+}
+
+func (s *tagService) GetTag(ctx context.Context, id int, username string) (*models.Tag, error) {
+	var tag models.Tag
+	if err := s.db.WithContext(ctx).Where("id = ? AND owner = ?", id, username).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Warnf("[Layer: tag_service] [Method: GetTag] Warning: Tag '%d' not found or not owned by user '%s'", id, username)
+			return nil, ErrTagNotFound
+		}
+		s.logger.Error("[Layer: tag_service] [Method: GetTag] Error: ", err)
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (s *tagService) CreateTag(ctx context.Context, username, name string) (*models.Tag, error) {
+	name, err := normalizeTagName(name)
+	if err != nil {
+		s.logger.Warnf("[Layer: tag_service] [Method: CreateTag] Warning: Invalid tag name for user '%s'", username)
+		return nil, err
+	}
+	if err := s.ensureNameFree(ctx, username, name, 0); err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{Owner: username, Name: name}
+	if err := s.db.WithContext(ctx).Create(tag).Error; err != nil {
+		s.logger.Error("[Layer: tag_service] [Method: CreateTag] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: tag_service] [Method: CreateTag] Info: Tag '%d' created for user '%s'", tag.ID, username)
+	return tag, nil
+}
+
+func (s *tagService) RenameTag(ctx context.Context, id int, username, name string) (*models.Tag, error) {
+	name, err := normalizeTagName(name)
+	if err != nil {
+		s.logger.Warnf("[Layer: tag_service] [Method: RenameTag] Warning: Invalid tag name for user '%s'", username)
+		return nil, err
+	}
+	tag, err := s.GetTag(ctx, id, username)
+	if err != nil {
+		return nil, err
+	}
+	if tag.Name == name {
+		return tag, nil
+	}
+	if err := s.ensureNameFree(ctx, username, name, tag.ID); err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return changeTaggedTasks(tx, tag.ID, func() error {
+			return tx.Model(tag).Update("name", name).Error
+		})
+	})
+	if err != nil {
+		s.logger.Error("[Layer: tag_service] [Method: RenameTag] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: tag_service] [Method: RenameTag] Info: Tag '%d' renamed for user '%s'", id, username)
+	return tag, nil
+}
+
+func (s *tagService) DeleteTag(ctx context.Context, id int, username string) error {
+	tag, err := s.GetTag(ctx, id, username)
+	if err != nil {
+		return err
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := changeTaggedTasks(tx, tag.ID, func() error {
+			return tx.Exec("DELETE FROM task_tags WHERE tag_id = ?", tag.ID).Error
+		}); err != nil {
+			return err
+		}
+		return tx.Delete(tag).Error
+	})
+	if err != nil {
+		s.logger.Error("[Layer: tag_service] [Method: DeleteTag] Error: ", err)
+		return err
+	}
+	s.logger.Infof("[Layer: tag_service] [Method: DeleteTag] Info: Tag '%d' deleted for user '%s'", id, username)
+	return nil
+}
+
+func (s *tagService) ensureNameFree(ctx context.Context, username, name string, exceptID uint) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Tag{}).
+		Where("owner = ? AND name = ? AND id <> ?", username, name, exceptID).
+		Count(&count).Error; err != nil {
+		s.logger.Error("[Layer: tag_service] [Method: ensureNameFree] Error: ", err)
+		return err
+	}
+	if count > 0 {
+		s.logger.Warnf("[Layer: tag_service] [Method: ensureNameFree] Warning: Tag '%s' already exists for user '%s'", name, username)
+		return ErrTagExists
+	}
+	return nil
+}
+
+// changeTaggedTasks ejecuta change, que cambia la etiqueta tagID, y lo trata como una escritura sobre
+// cada tarea que la tiene (también las de la papelera): sube su versión y registra el cambio de tags.
+func changeTaggedTasks(tx *gorm.DB, tagID uint, change func() error) error {
+	var ids []uint
+	if err := tx.Raw("SELECT task_id FROM task_tags WHERE tag_id = ?", tagID).Scan(&ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return change()
+	}
+	var before []*models.Task
+	if err := withTags(tx.Unscoped()).Where("id IN ?", ids).Order("id").Find(&before).Error; err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.Task{}).Where("id IN ?", ids).Update("version", gorm.Expr("version + 1")).Error; err != nil {
+		return err
+	}
+	var after []*models.Task
+	if err := withTags(tx.Unscoped()).Where("id IN ?", ids).Order("id").Find(&after).Error; err != nil {
+		return err
+	}
+	for i := range after {
+		if err := recordTaskChanges(tx, before[i], after[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagService(t *testing.T) {
+	db := setupTestDB(t)
+	tags := NewTagService(db, logrus.New())
+	tasks := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+
+	_, err := tags.CreateTag(ctx, "user1", " ")
+	assert.ErrorIs(t, err, ErrInvalidTagName)
+
+	work, err := tags.CreateTag(ctx, "user1", " trabajo ")
+	assert.NoError(t, err)
+	assert.Equal(t, "trabajo", work.Name)
+	_, err = tags.CreateTag(ctx, "user1", "trabajo")
+	assert.ErrorIs(t, err, ErrTagExists)
+	home, _ := tags.CreateTag(ctx, "user1", "casa")
+	_, err = tags.CreateTag(ctx, "user2", "trabajo")
+	assert.NoError(t, err)
+
+	list, err := tags.ListTags(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, "casa", list[0].Name)
+
+	_, err = tags.GetTag(ctx, int(work.ID), "user2")
+	assert.ErrorIs(t, err, ErrTagNotFound)
+
+	// Caso: renombrar se refleja en las tareas que ya la tenían
+	task, _ := tasks.CreateTask(ctx, "user1", TaskFields{Title: "Informe", Tags: []string{"trabajo", "casa"}})
+	_, err = tags.RenameTag(ctx, int(work.ID), "user1", "casa")
+	assert.ErrorIs(t, err, ErrTagExists)
+	renamed, err := tags.RenameTag(ctx, int(work.ID), "user1", "oficina")
+	assert.NoError(t, err)
+	assert.Equal(t, "oficina", renamed.Name)
+	got, _ := tasks.GetTaskByID(ctx, int(task.ID), "user1")
+	assert.Equal(t, []string{"casa", "oficina"}, tagNamesOf(got))
+
+	// Caso: eliminar la quita de las tareas y libera el nombre
+	assert.NoError(t, tags.DeleteTag(ctx, int(home.ID), "user1"))
+	assert.ErrorIs(t, tags.DeleteTag(ctx, int(home.ID), "user1"), ErrTagNotFound)
+	got, _ = tasks.GetTaskByID(ctx, int(task.ID), "user1")
+	assert.Equal(t, []string{"oficina"}, tagNamesOf(got))
+	var links int64
+	db.Table("task_tags").Where("tag_id = ?", home.ID).Count(&links)
+	assert.Equal(t, int64(0), links)
+	_, err = tags.CreateTag(ctx, "user1", "casa")
+	assert.NoError(t, err)
+
+	var count int64
+	db.Model(&models.Tag{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestTagService_Versions(t *testing.T) {
+	db := setupTestDB(t)
+	tags := NewTagService(db, logrus.New())
+	tasks := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+
+	work, _ := tags.CreateTag(ctx, "user1", "trabajo")
+	home, _ := tags.CreateTag(ctx, "user1", "casa")
+	task, _ := tasks.CreateTask(ctx, "user1", TaskFields{Title: "Informe", Tags: []string{"trabajo", "casa"}})
+	other, _ := tasks.CreateTask(ctx, "user1", TaskFields{Title: "Compras"})
+
+	// Caso: renombrar o eliminar una etiqueta es una escritura sobre sus tareas
+	_, err := tags.RenameTag(ctx, int(work.ID), "user1", "oficina")
+	assert.NoError(t, err)
+	got, _ := tasks.GetTaskByID(ctx, int(task.ID), "user1")
+	assert.Equal(t, uint(2), got.Version)
+	assert.NoError(t, tags.DeleteTag(ctx, int(home.ID), "user1"))
+	got, _ = tasks.GetTaskByID(ctx, int(task.ID), "user1")
+	assert.Equal(t, uint(3), got.Version)
+	untouched, _ := tasks.GetTaskByID(ctx, int(other.ID), "user1")
+	assert.Equal(t, uint(1), untouched.Version)
+
+	page, err := tasks.ListTaskHistory(ctx, int(task.ID), "user1", 0, "")
+	assert.NoError(t, err)
+	var changes []string
+	for _, event := range page.Events {
+		if event.Field == FieldTags {
+			changes = append(changes, event.OldValue+"→"+event.NewValue)
+		}
+	}
+	assert.Equal(t, []string{
+		`["casa","oficina"]→["oficina"]`,
+		`["casa","trabajo"]→["casa","oficina"]`,
+	}, changes)
+
+	// Caso: revertir a la versión previa al borrado recupera la etiqueta
+	reverted, err := tasks.RevertTask(ctx, int(task.ID), "user1", 2, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"casa", "oficina"}, tagNamesOf(reverted))
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// BulkModeAtomic aplica todas las operaciones o ninguna; BulkModeBestEffort aplica las que puede
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "best_effort"
+
+	BulkOpCreate   = "create"
+	BulkOpUpdate   = "update"
+	BulkOpDelete   = "delete"
+	BulkOpComplete = "complete"
+
+	MaxBulkOperations = 100
+)
+
+// BulkOperation es una escritura del lote. Update reemplaza la tarea como UpdateTask; delete y complete
+// solo usan ID. ExpectedVersion funciona igual que en las escrituras individuales.
+type BulkOperation struct {
+	Op              string
+	ID              int
+	Fields          TaskFields
+	ExpectedVersion uint
+}
+
+// BulkResult es el resultado de una operación: la tarea escrita (nil en delete) o el error.
+type BulkResult struct {
+	Task *models.Task
+	Err  error
+}
+
+func (s *taskService) BulkTasks(ctx context.Context, username, mode string, ops []BulkOperation) ([]BulkResult, error) {
+	if mode == "" {
+		mode = BulkModeAtomic
+	}
+	if mode != BulkModeAtomic && mode != BulkModeBestEffort {
+		s.logger.Warnf("[Layer: task_service] [Method: BulkTasks] Warning: Invalid mode '%s'", mode)
+		return nil, ErrInvalidBulkMode
+	}
+	if len(ops) == 0 || len(ops) > MaxBulkOperations {
+		s.logger.Warnf("[Layer: task_service] [Method: BulkTasks] Warning: %d operations requested by user '%s'", len(ops), username)
+		return nil, ErrInvalidBulkSize
+	}
+
+	results := make([]BulkResult, len(ops))
+	failed := -1
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, op := range ops {
+			// cada operación va en su propio savepoint: si falla solo se deshace ella
+			err := tx.Transaction(func(savepoint *gorm.DB) error {
+				task, err := s.withDB(savepoint).applyBulkOperation(ctx, username, op)
+				results[i].Task = task
+				return err
+			})
+			if err != nil {
+				results[i] = BulkResult{Err: err}
+				if mode == BulkModeAtomic {
+					failed = i
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if failed >= 0 {
+		for i := range results {
+			if i != failed {
+				results[i] = BulkResult{Err: ErrBulkAborted}
+			}
+		}
+		s.logger.Warnf("[Layer: task_service] [Method: BulkTasks] Warning: Operation %d failed, batch of user '%s' rolled back: %v", failed, username, results[failed].Err)
+		return results, nil
+	}
+	if err != nil {
+		s.logger.Error("[Layer: task_service] [Method: BulkTasks] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: BulkTasks] Info: User '%s' ran %d operations (%s)", username, len(ops), mode)
+	return results, nil
+}
+
+func (s *taskService) applyBulkOperation(ctx context.Context, username string, op BulkOperation) (*models.Task, error) {
+	switch op.Op {
+	case BulkOpCreate:
+		return s.CreateTask(ctx, username, op.Fields)
+	case BulkOpUpdate:
+		return s.UpdateTask(ctx, op.ID, username, op.Fields, op.ExpectedVersion)
+	case BulkOpComplete:
+		return s.PatchTask(ctx, op.ID, username, TaskFields{Completed: true}, []string{FieldCompleted}, op.ExpectedVersion)
+	case BulkOpDelete:
+		return nil, s.DeleteTask(ctx, op.ID, username, op.ExpectedVersion)
+	}
+	return nil, ErrInvalidBulkOperation
+}
+
+// withDB es una copia del servicio que lee y escribe en db, por ejemplo una transacción ya abierta.
+func (s *taskService) withDB(db *gorm.DB) *taskService {
+	scoped := *s
+	scoped.db = db
+	return &scoped
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"prueba_tecnica_go_guarapo/api/models"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WithBlockedCompletion permite completar tareas que todavía tienen bloqueos pendientes.
+func WithBlockedCompletion() Option {
+	return func(s *taskService) {
+		s.allowBlockedCompletion = true
+	}
+}
+
+// openBlockersSQL es la condición "la tarea tiene al menos un bloqueo sin completar"; los
+// bloqueos eliminados no cuentan.
+const openBlockersSQL = `EXISTS (SELECT 1 FROM task_dependencies AS dep
+	JOIN tasks AS blocker ON blocker.id = dep.blocker_id
+	WHERE dep.task_id = tasks.id AND blocker.completed = false AND blocker.deleted_at IS NULL)`
+
+// campos del historial que describen los bloqueos; no se revierten porque tienen sus propios endpoints
+const (
+	FieldBlockers = "blockers"
+	FieldBlocked  = "blocked"
+)
+
+// withBlocked agrega la columna calculada blocked.
+func withBlocked(db *gorm.DB) *gorm.DB {
+	return db.Select("tasks.*, " + openBlockersSQL + " AS blocked")
+}
+
+// withDetails completa lo que se devuelve de una tarea: etiquetas y blocked.
+func withDetails(db *gorm.DB) *gorm.DB {
+	return withBlocked(withTags(db))
+}
+
+func hasOpenBlockers(db *gorm.DB, id uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.Task{}).Where("tasks.id = ? AND "+openBlockersSQL, id).Count(&count).Error
+	return count > 0, err
+}
+
+// createsCycle indica si taskID ya es bloqueo (directo o indirecto) de blockerID; en ese caso la
+// arista nueva cerraría un ciclo. Recorre también las aristas de tareas eliminadas.
+func createsCycle(db *gorm.DB, taskID, blockerID uint) (bool, error) {
+	if taskID == blockerID {
+		return true, nil
+	}
+	visited := map[uint]bool{blockerID: true}
+	frontier := []uint{blockerID}
+	for len(frontier) > 0 {
+		var next []uint
+		if err := db.Model(&models.TaskDependency{}).Where("task_id IN ?", frontier).Pluck("blocker_id", &next).Error; err != nil {
+			return false, err
+		}
+		frontier = frontier[:0]
+		for _, id := range next {
+			if id == taskID {
+				return true, nil
+			}
+			if !visited[id] {
+				visited[id] = true
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return false, nil
+}
+
+func (s *taskService) ListBlockers(ctx context.Context, id int, username string) ([]*models.Task, error) {
+	task, err := s.GetTaskByID(ctx, id, username)
+	if err != nil {
+		return nil, err
+	}
+	var blockers []*models.Task
+	if err := withDetails(s.db.WithContext(ctx)).
+		Where("id IN (?)", s.db.Model(&models.TaskDependency{}).Select("blocker_id").Where("task_id = ?", task.ID)).
+		Order("id").
+		Find(&blockers).Error; err != nil {
+		s.logger.Error("[Layer: task_service] [Method: ListBlockers] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: ListBlockers] Info: Task '%d' has %d blockers", id, len(blockers))
+	return blockers, nil
+}
+
+func (s *taskService) AddBlocker(ctx context.Context, id int, username string, blockerID uint) (*models.Task, error) {
+	task, err := s.GetTaskByID(ctx, id, username)
+	if err != nil {
+		return nil, err
+	}
+	var blockers int64
+	if err := s.db.WithContext(ctx).Model(&models.Task{}).Where("id = ? AND owner = ?", blockerID, username).Count(&blockers).Error; err != nil {
+		s.logger.Error("[Layer: task_service] [Method: AddBlocker] Error: ", err)
+		return nil, err
+	}
+	if blockers == 0 {
+		s.logger.Warnf("[Layer: task_service] [Method: AddBlocker] Warning: Blocker '%d' not found for user '%s'", blockerID, username)
+		return nil, ErrBlockerNotFound
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		cycle, err := createsCycle(tx, task.ID, blockerID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return ErrDependencyCycle
+		}
+		before, err := blockerIDs(tx, task.ID)
+		if err != nil {
+			return err
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.TaskDependency{TaskID: task.ID, BlockerID: blockerID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrDependencyExists
+		}
+		if err := recordBlockersChange(tx, task.ID, before); err != nil {
+			return err
+		}
+		return withDetails(tx).First(task, task.ID).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrDependencyCycle) || errors.Is(err, ErrDependencyExists) {
+			s.logger.Warnf("[Layer: task_service] [Method: AddBlocker] Warning: Task '%d' cannot be blocked by '%d': %v", id, blockerID, err)
+			return nil, err
+		}
+		s.logger.Error("[Layer: task_service] [Method: AddBlocker] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: AddBlocker] Info: Task '%d' is now blocked by '%d'", id, blockerID)
+	return task, nil
+}
+
+func (s *taskService) RemoveBlocker(ctx context.Context, id int, username string, blockerID uint) (*models.Task, error) {
+	task, err := s.GetTaskByID(ctx, id, username)
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := blockerIDs(tx, task.ID)
+		if err != nil {
+			return err
+		}
+		result := tx.Where("task_id = ? AND blocker_id = ?", task.ID, blockerID).Delete(&models.TaskDependency{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrDependencyNotFound
+		}
+		if err := recordBlockersChange(tx, task.ID, before); err != nil {
+			return err
+		}
+		return withDetails(tx).First(task, task.ID).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrDependencyNotFound) {
+			s.logger.Warnf("[Layer: task_service] [Method: RemoveBlocker] Warning: Task '%d' is not blocked by '%d'", id, blockerID)
+			return nil, err
+		}
+		s.logger.Error("[Layer: task_service] [Method: RemoveBlocker] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: RemoveBlocker] Info: Task '%d' is no longer blocked by '%d'", id, blockerID)
+	return task, nil
+}
+
+// checkCompletable rechaza completar una tarea con bloqueos pendientes, salvo WithBlockedCompletion.
+func (s *taskService) checkCompletable(tx *gorm.DB, task *models.Task, updates map[string]interface{}) error {
+	if s.allowBlockedCompletion || task.Completed {
+		return nil
+	}
+	if completed, ok := updates[FieldCompleted].(bool); !ok || !completed {
+		return nil
+	}
+	blocked, err := hasOpenBlockers(tx, task.ID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrTaskBlocked
+	}
+	return nil
+}
+
+func blockerIDs(tx *gorm.DB, taskID uint) ([]uint, error) {
+	ids := []uint{}
+	err := tx.Model(&models.TaskDependency{}).Where("task_id = ?", taskID).Order("blocker_id").Pluck("blocker_id", &ids).Error
+	return ids, err
+}
+
+// recordBlockersChange sube la versión de la tarea, porque blocked puede cambiar, y registra su lista de
+// bloqueos antes y después del cambio.
+func recordBlockersChange(tx *gorm.DB, taskID uint, before []uint) error {
+	after, err := blockerIDs(tx, taskID)
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Task{}).Where("id = ?", taskID).Update("version", gorm.Expr("version + 1")).Error; err != nil {
+		return err
+	}
+	old, _ := json.Marshal(before)
+	current, _ := json.Marshal(after)
+	return recordTaskEvents(tx, []uint{taskID}, models.TaskEvent{
+		Action:   models.TaskEventUpdated,
+		Field:    FieldBlockers,
+		OldValue: string(old),
+		NewValue: string(current),
+	})
+}
+
+// blockedSnapshot es el valor de blocked de las tareas que dependen de otras que se van a escribir.
+// Después de la escritura, sync sube la versión de las que cambiaron para que su ETag deje de valer.
+type blockedSnapshot map[uint]bool
+
+type blockedRow struct {
+	ID      uint
+	Blocked bool
+}
+
+// snapshotDependents toma blocked de las tareas bloqueadas por alguna de ids, incluidas las de la papelera.
+func snapshotDependents(tx *gorm.DB, ids []uint) (blockedSnapshot, error) {
+	snapshot := blockedSnapshot{}
+	if len(ids) == 0 {
+		return snapshot, nil
+	}
+	var rows []blockedRow
+	if err := tx.Raw(`SELECT tasks.id, `+openBlockersSQL+` AS blocked FROM tasks
+		WHERE tasks.id IN (SELECT task_id FROM task_dependencies WHERE blocker_id IN ?)`, ids).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		snapshot[row.ID] = row.Blocked
+	}
+	return snapshot, nil
+}
+
+func (b blockedSnapshot) sync(tx *gorm.DB) error {
+	if len(b) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(b))
+	for id := range b {
+		ids = append(ids, id)
+	}
+	var rows []blockedRow
+	if err := tx.Raw(`SELECT tasks.id, `+openBlockersSQL+` AS blocked FROM tasks WHERE tasks.id IN ?`, ids).Scan(&rows).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if b[row.ID] == row.Blocked {
+			continue
+		}
+		if err := tx.Exec("UPDATE tasks SET version = version + 1, updated_at = ? WHERE id = ?", time.Now(), row.ID).Error; err != nil {
+			return err
+		}
+		if err := recordTaskEvents(tx, []uint{row.ID}, models.TaskEvent{
+			Action:   models.TaskEventUpdated,
+			Field:    FieldBlocked,
+			OldValue: strconv.FormatBool(b[row.ID]),
+			NewValue: strconv.FormatBool(row.Blocked),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"prueba_tecnica_go_guarapo/api/models"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldCompleted   = "completed"
+	FieldPriority    = "priority"
+	FieldDueAt       = "due_at"
+	FieldRecurrence  = "recurrence"
+	FieldTags        = "tags"
+	FieldProjectID   = "project_id"
+	FieldParentID    = "parent_id"
+
+	MaxDescriptionLength = 10000
+)
+
+var taskPriorities = map[string]bool{
+	models.TaskPriorityLow:    true,
+	models.TaskPriorityMedium: true,
+	models.TaskPriorityHigh:   true,
+	models.TaskPriorityUrgent: true,
+}
+
+// TaskFields son los campos editables de una tarea; cuáles se escriben lo decide la máscara.
+type TaskFields struct {
+	Title       string
+	Description string
+	Completed   bool
+	Priority    string
+	DueAt       *time.Time
+	// Recurrence es una RRULE; vacía si la tarea no se repite
+	Recurrence string
+	// ProjectID nil deja la tarea en la bandeja de entrada
+	ProjectID *uint
+	// ParentID nil deja la tarea como raíz
+	ParentID *uint
+	// Tags son nombres de etiquetas; las que no existen se crean al asignarlas
+	Tags []string
+}
+
+// replaceMask es la máscara de un reemplazo completo (PUT); las etiquetas solo se reemplazan si vienen.
+var replaceMask = []string{FieldTitle, FieldDescription, FieldCompleted, FieldPriority, FieldDueAt, FieldRecurrence, FieldProjectID, FieldParentID}
+
+func (f TaskFields) replaceMask() []string {
+	if f.Tags == nil {
+		return replaceMask
+	}
+	return append(append([]string{}, replaceMask...), FieldTags)
+}
+
+// withDefaults completa la prioridad en altas y reemplazos; en PATCH una prioridad vacía es un error.
+func (f TaskFields) withDefaults() TaskFields {
+	if f.Priority == "" {
+		f.Priority = models.TaskPriorityMedium
+	}
+	return f
+}
+
+// columns valida la máscara y devuelve las columnas a actualizar.
+func (f TaskFields) columns(mask []string) ([]string, error) {
+	columns := make([]string, 0, len(mask))
+	for _, field := range mask {
+		switch field {
+		case FieldTitle:
+			if f.Title == "" {
+				return nil, ErrTitleRequired
+			}
+		case FieldDescription:
+			if utf8.RuneCountInString(f.Description) > MaxDescriptionLength {
+				return nil, ErrDescriptionTooLong
+			}
+		case FieldPriority:
+			if !taskPriorities[f.Priority] {
+				return nil, ErrInvalidPriority
+			}
+		case FieldRecurrence:
+			if _, err := normalizeRecurrence(f.Recurrence); err != nil {
+				return nil, err
+			}
+		case FieldTags:
+			if _, err := normalizeTagNames(f.Tags); err != nil {
+				return nil, err
+			}
+		case FieldCompleted, FieldDueAt, FieldProjectID, FieldParentID:
+		default:
+			return nil, ErrUnknownField
+		}
+		columns = append(columns, field)
+	}
+	return columns, nil
+}
+
+func (f TaskFields) updates(mask []string) map[string]interface{} {
+	updates := make(map[string]interface{}, len(mask))
+	for _, field := range mask {
+		switch field {
+		case FieldTitle:
+			updates[FieldTitle] = f.Title
+		case FieldDescription:
+			updates[FieldDescription] = f.Description
+		case FieldCompleted:
+			updates[FieldCompleted] = f.Completed
+		case FieldPriority:
+			updates[FieldPriority] = f.Priority
+		case FieldDueAt:
+			updates[FieldDueAt] = utcOrNil(f.DueAt)
+		case FieldRecurrence:
+			// columns ya validó la regla
+			updates[FieldRecurrence], _ = normalizeRecurrence(f.Recurrence)
+		case FieldProjectID:
+			updates[FieldProjectID] = f.ProjectID
+		case FieldParentID:
+			updates[FieldParentID] = f.ParentID
+		}
+	}
+	return updates
+}
+
+func utcOrNil(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// sameID compara referencias opcionales (project_id, parent_id).
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"prueba_tecnica_go_guarapo/api/models"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// historyCursorSort marca los cursores del historial para no confundirlos con los del listado de tareas.
+	historyCursorSort = "history"
+
+	// SystemActor es el actor de los cambios que hacen los procesos programados, como la purga de la papelera.
+	SystemActor = "system"
+)
+
+// historyFields son los campos de la tarea que se registran en el historial, en el orden en que se guardan.
+var historyFields = []string{FieldTitle, FieldDescription, FieldCompleted, FieldPriority, FieldDueAt, FieldRecurrence, FieldProjectID, FieldParentID, FieldTags}
+
+type auditContextKey struct{}
+
+// Audit es quién hace un cambio y en qué petición; se guarda con cada evento del historial.
+type Audit struct {
+	Actor     string
+	RequestID string
+}
+
+// WithAudit deja audit en el contexto de las escrituras. Sin Actor se registra al dueño de la tarea.
+func WithAudit(ctx context.Context, audit Audit) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, audit)
+}
+
+func auditFrom(ctx context.Context) Audit {
+	if ctx == nil {
+		return Audit{}
+	}
+	audit, _ := ctx.Value(auditContextKey{}).(Audit)
+	return audit
+}
+
+type TaskHistoryPage struct {
+	Events     []*models.TaskEvent
+	NextCursor string
+}
+
+func (s *taskService) ListTaskHistory(ctx context.Context, id int, username string, limit int, after string) (*TaskHistoryPage, error) {
+	// el historial de una tarea en la papelera también se puede consultar
+	var tasks int64
+	if err := s.db.WithContext(ctx).Unscoped().Model(&models.Task{}).Where("id = ? AND owner = ?", id, username).Count(&tasks).Error; err != nil {
+		s.logger.Error("[Layer: task_service] [Method: ListTaskHistory] Error: ", err)
+		return nil, err
+	}
+	if tasks == 0 {
+		s.logger.Warnf("[Layer: task_service] [Method: ListTaskHistory] Warning: Task '%d' not found or not owned by user '%s'", id, username)
+		return nil, ErrTaskNotFound
+	}
+	if limit <= 0 {
+		limit = DefaultTaskPageSize
+	}
+	if limit > MaxTaskPageSize {
+		limit = MaxTaskPageSize
+	}
+
+	query := s.db.WithContext(ctx).Where("task_id = ?", id)
+	if after != "" {
+		cursor, err := decodeTaskCursor(after)
+		if err != nil || cursor.Sort != historyCursorSort {
+			s.logger.Warnf("[Layer: task_service] [Method: ListTaskHistory] Warning: Invalid cursor for task '%d'", id)
+			return nil, ErrInvalidCursor
+		}
+		query = query.Where("id < ?", cursor.ID)
+	}
+	var events []*models.TaskEvent
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&events).Error; err != nil {
+		s.logger.Error("[Layer: task_service] [Method: ListTaskHistory] Error: ", err)
+		return nil, err
+	}
+
+	page := &TaskHistoryPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		raw, _ := json.Marshal(taskCursor{Sort: historyCursorSort, ID: page.Events[limit-1].ID})
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	s.logger.Infof("[Layer: task_service] [Method: ListTaskHistory] Info: Listed %d events of task '%d' for user '%s'", len(page.Events), id, username)
+	return page, nil
+}
+
+// recordTaskEvents agrega event a cada tarea de ids con la versión que tenga en ese momento; actor y
+// request ID salen del contexto de tx. Las tareas pueden estar en la papelera.
+func recordTaskEvents(tx *gorm.DB, ids []uint, event models.TaskEvent) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	audit := auditFrom(tx.Statement.Context)
+	return tx.Exec(`INSERT INTO task_events (task_id, version, actor, action, field, old_value, new_value, request_id, created_at)
+		SELECT id, version, COALESCE(NULLIF(?, ''), owner), ?, ?, ?, ?, ?, ? FROM tasks WHERE id IN ?`,
+		audit.Actor, event.Action, event.Field, event.OldValue, event.NewValue, audit.RequestID, time.Now().UTC(), ids).Error
+}
+
+// recordTaskChanges agrega un evento updated por cada campo que difiere entre before y after.
+func recordTaskChanges(tx *gorm.DB, before, after *models.Task) error {
+	old, current := historyValues(before), historyValues(after)
+	for _, field := range historyFields {
+		if old[field] == current[field] {
+			continue
+		}
+		if err := recordTaskEvents(tx, []uint{after.ID}, models.TaskEvent{
+			Action:   models.TaskEventUpdated,
+			Field:    field,
+			OldValue: old[field],
+			NewValue: current[field],
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// historyValues serializa en JSON cada campo registrado; las etiquetas van ordenadas por nombre.
+func historyValues(task *models.Task) map[string]string {
+	tags := make([]string, 0, len(task.Tags))
+	for _, tag := range task.Tags {
+		tags = append(tags, tag.Name)
+	}
+	slices.Sort(tags)
+	values := map[string]interface{}{
+		FieldTitle:       task.Title,
+		FieldDescription: task.Description,
+		FieldCompleted:   task.Completed,
+		FieldPriority:    task.Priority,
+		FieldDueAt:       utcOrNil(task.DueAt),
+		FieldRecurrence:  task.Recurrence,
+		FieldProjectID:   task.ProjectID,
+		FieldParentID:    task.ParentID,
+		FieldTags:        tags,
+	}
+	encoded := make(map[string]string, len(values))
+	for field, value := range values {
+		raw, _ := json.Marshal(value)
+		encoded[field] = string(raw)
+	}
+	return encoded
+}
+
+// snapshotTask copia la tarea con sus etiquetas para compararla después de escribirla.
+func snapshotTask(task *models.Task) *models.Task {
+	before := *task
+	before.Tags = slices.Clone(task.Tags)
+	return &before
+}
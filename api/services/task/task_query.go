@@ -0,0 +1,131 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"prueba_tecnica_go_guarapo/api/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultTaskPageSize = 20
+	MaxTaskPageSize     = 100
+	DefaultTaskSort     = "created_at"
+)
+
+// columnas por las que se puede ordenar; el prefijo "-" invierte el orden
+var taskSortColumns = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"title":      true,
+}
+
+// TaskQuery describe un listado de tareas. Owner es obligatorio; el resto es opcional.
+type TaskQuery struct {
+	Owner     string
+	Completed *bool
+	Search    string
+	Priority  string
+	DueBefore *time.Time
+	// Overdue filtra las pendientes con due_at vencido (true) o las demás (false)
+	Overdue *bool
+	// ProjectID filtra por proyecto; 0 es la bandeja de entrada (tareas sin proyecto)
+	ProjectID *uint
+	// ParentID lista las subtareas directas de esa tarea; 0 lista solo tareas raíz
+	ParentID *uint
+	// Tags filtra por nombre de etiqueta; TagMode es all (todas, por defecto) o any (alguna)
+	Tags    []string
+	TagMode string
+	Sort    string
+	Limit   int
+	After   string
+}
+
+type TaskPage struct {
+	Tasks      []*models.Task
+	NextCursor string
+	Total      int64
+}
+
+// taskCursor es la última fila entregada; se serializa en base64 para que el cliente lo trate como opaco.
+type taskCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+type taskSort struct {
+	raw    string
+	column string
+	desc   bool
+}
+
+func parseTaskSort(raw string) (taskSort, error) {
+	if raw == "" {
+		raw = DefaultTaskSort
+	}
+	sort := taskSort{raw: raw, column: strings.TrimPrefix(raw, "-"), desc: strings.HasPrefix(raw, "-")}
+	if !taskSortColumns[sort.column] {
+		return taskSort{}, ErrInvalidSort
+	}
+	return sort, nil
+}
+
+func (s taskSort) orderClause() string {
+	dir := "ASC"
+	if s.desc {
+		dir = "DESC"
+	}
+	return fmt.Sprintf("%s %s, id %s", s.column, dir, dir)
+}
+
+// after filtra las filas posteriores al cursor según el orden (keyset), por eso no se ve afectado por inserciones.
+func (s taskSort) after(db *gorm.DB, cursor taskCursor) (*gorm.DB, error) {
+	op := ">"
+	if s.desc {
+		op = "<"
+	}
+	var value interface{} = cursor.Value
+	if s.column != "title" {
+		t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		value = t
+	}
+	return db.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", s.column, op, s.column, op), value, value, cursor.ID), nil
+}
+
+func (s taskSort) cursorFor(task *models.Task) string {
+	cursor := taskCursor{Sort: s.raw, ID: task.ID}
+	switch s.column {
+	case "title":
+		cursor.Value = task.Title
+	case "updated_at":
+		cursor.Value = task.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		cursor.Value = task.CreatedAt.Format(time.RFC3339Nano)
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeTaskCursor(encoded string) (taskCursor, error) {
+	var cursor taskCursor
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == 0 {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
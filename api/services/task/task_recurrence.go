@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPreviewOccurrences = 10
+	MaxPreviewOccurrences     = 50
+)
+
+// RecurrencePreview son las próximas fechas de una regla, con la regla en forma canónica.
+type RecurrencePreview struct {
+	Rule        string
+	Occurrences []time.Time
+}
+
+// normalizeRecurrence valida la regla y la devuelve en forma canónica; vacía significa sin recurrencia.
+func normalizeRecurrence(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	rule, err := ParseRRule(raw)
+	if err != nil {
+		return "", err
+	}
+	return rule.String(), nil
+}
+
+// checkRecurrenceDue exige due_at en las tareas recurrentes, porque es la fecha de la ocurrencia actual.
+// Con una máscara (PATCH) los campos que no vienen se toman de la tarea guardada.
+func checkRecurrenceDue(task *models.Task, fields TaskFields, mask []string) error {
+	recurrence, dueAt := fields.Recurrence, fields.DueAt
+	if task != nil && !slices.Contains(mask, FieldRecurrence) {
+		recurrence = task.Recurrence
+	}
+	if task != nil && !slices.Contains(mask, FieldDueAt) {
+		dueAt = task.DueAt
+	}
+	if recurrence != "" && dueAt == nil {
+		return ErrRecurrenceNeedsDueAt
+	}
+	return nil
+}
+
+// scheduleNextOccurrence crea la siguiente ocurrencia de una tarea recurrente recién completada, con
+// los mismos datos y etiquetas, y la enlaza en NextOccurrenceID. No hace nada si la serie terminó.
+func scheduleNextOccurrence(tx *gorm.DB, task *models.Task) error {
+	if task.Recurrence == "" || task.DueAt == nil || task.NextOccurrenceID != nil {
+		return nil
+	}
+	rule, err := ParseRRule(task.Recurrence)
+	if err != nil {
+		return err
+	}
+	rest, dueAt, ok := rule.Next(*task.DueAt)
+	if !ok {
+		return nil
+	}
+	occurrence := &models.Task{
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		DueAt:       &dueAt,
+		Recurrence:  rest.String(),
+		ProjectID:   task.ProjectID,
+		ParentID:    task.ParentID,
+		Owner:       task.Owner,
+		Version:     1,
+	}
+	if err := tx.Create(occurrence).Error; err != nil {
+		return err
+	}
+	names := make([]string, 0, len(task.Tags))
+	for _, tag := range task.Tags {
+		names = append(names, tag.Name)
+	}
+	if err := replaceTaskTags(tx, occurrence, names); err != nil {
+		return err
+	}
+	if err := recordTaskEvents(tx, []uint{occurrence.ID}, models.TaskEvent{Action: models.TaskEventCreated}); err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Task{}).Where("id = ?", task.ID).Update("next_occurrence_id", occurrence.ID).Error; err != nil {
+		return err
+	}
+	task.NextOccurrenceID = &occurrence.ID
+	return nil
+}
+
+func (s *taskService) PreviewRecurrence(ctx context.Context, rule string, start time.Time, count int) (*RecurrencePreview, error) {
+	parsed, err := ParseRRule(rule)
+	if err != nil {
+		s.logger.Warnf("[Layer: task_service] [Method: PreviewRecurrence] Warning: Invalid rule '%s': %v", rule, err)
+		return nil, err
+	}
+	if count <= 0 {
+		count = DefaultPreviewOccurrences
+	}
+	if count > MaxPreviewOccurrences {
+		count = MaxPreviewOccurrences
+	}
+	occurrences := parsed.Occurrences(start.UTC(), count)
+	if occurrences == nil {
+		occurrences = []time.Time{}
+	}
+	return &RecurrencePreview{Rule: parsed.String(), Occurrences: occurrences}, nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"prueba_tecnica_go_guarapo/api/models"
+	"slices"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// RevertTask reconstruye la tarea en version deshaciendo, del más reciente al más antiguo, los cambios de
+// campo posteriores. Es una escritura más: sube la versión, registra reverted y un evento por campo que
+// cambia, y pasa por las mismas validaciones que una actualización.
+func (s *taskService) RevertTask(ctx context.Context, id int, username string, version uint, expectedVersion uint) (*models.Task, error) {
+	var task models.Task
+	if err := withDetails(s.db.WithContext(ctx)).Where("id = ? AND owner = ?", id, username).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Warnf("[Layer: task_service] [Method: RevertTask] Warning: Task '%d' not found or not owned by user '%s'", id, username)
+			return nil, ErrTaskNotFound
+		}
+		s.logger.Error("[Layer: task_service] [Method: RevertTask] Error: ", err)
+		return nil, err
+	}
+	if expectedVersion != 0 && task.Version != expectedVersion {
+		s.logger.Warnf("[Layer: task_service] [Method: RevertTask] Warning: Task '%d' is no longer at version %d", id, expectedVersion)
+		return nil, ErrVersionConflict
+	}
+	if version == 0 || version >= task.Version {
+		s.logger.Warnf("[Layer: task_service] [Method: RevertTask] Warning: Task '%d' has no earlier version %d", id, version)
+		return nil, ErrRevisionNotFound
+	}
+
+	values, err := valuesAtVersion(s.db.WithContext(ctx), &task, version)
+	if err != nil {
+		if errors.Is(err, ErrRevisionNotFound) {
+			s.logger.Warnf("[Layer: task_service] [Method: RevertTask] Warning: Version %d of task '%d' predates its history", version, id)
+			return nil, err
+		}
+		s.logger.Error("[Layer: task_service] [Method: RevertTask] Error: ", err)
+		return nil, err
+	}
+	fields, err := fieldsFromHistory(values)
+	if err != nil {
+		s.logger.Error("[Layer: task_service] [Method: RevertTask] Error: ", err)
+		return nil, err
+	}
+	// solo se escriben los campos que difieren de la versión actual
+	current := historyValues(&task)
+	var mask []string
+	for _, field := range historyFields {
+		if values[field] != current[field] {
+			mask = append(mask, field)
+		}
+	}
+
+	// la versión vieja puede apuntar a un proyecto o un padre que ya no sirven
+	if _, err := fields.columns(mask); err != nil {
+		s.logger.Warnf("[Layer: task_service] [Method: RevertTask] Warning: Version %d of task '%d' is not valid anymore: %v", version, id, err)
+		return nil, err
+	}
+	if slices.Contains(mask, FieldProjectID) {
+		if err := checkProjectAssignable(s.db.WithContext(ctx), fields.ProjectID, username); err != nil {
+			s.logger.Warnf("[Layer: task_service] [Method: RevertTask] Warning: Project not assignable to task '%d': %v", id, err)
+			return nil, err
+		}
+	}
+	if slices.Contains(mask, FieldParentID) {
+		if err := validateParent(s.db.WithContext(ctx), &task, fields.ParentID, username); err != nil {
+			s.logger.Warnf("[Layer: task_service] [Method: RevertTask] Warning: Invalid parent for task '%d': %v", id, err)
+			return nil, err
+		}
+	}
+	if err := checkRecurrenceDue(&task, fields, mask); err != nil {
+		s.logger.Warnf("[Layer: task_service] [Method: RevertTask] Warning: Task '%d': %v", id, err)
+		return nil, err
+	}
+
+	// se guarda contra la versión que se usó para reconstruir, así una edición concurrente no se pierde
+	reverted := models.TaskEvent{Action: models.TaskEventReverted, NewValue: strconv.FormatUint(uint64(version), 10)}
+	if err := s.saveVersioned(ctx, &task, fields, mask, task.Version, reverted); err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			s.logger.Warnf("[Layer: task_service] [Method: RevertTask] Warning: Task '%d' changed while reverting", id)
+			return nil, err
+		}
+		if errors.Is(err, ErrTaskBlocked) {
+			s.logger.Warnf("[Layer: task_service] [Method: RevertTask] Warning: Task '%d' has open blockers", id)
+			return nil, err
+		}
+		s.logger.Error("[Layer: task_service] [Method: RevertTask] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: RevertTask] Info: Task '%d' reverted to version %d (%v) for user '%s'", id, version, mask, username)
+	return &task, nil
+}
+
+// valuesAtVersion devuelve los valores registrados de la tarea en version. Necesita algún evento en esa
+// versión o antes: las tareas anteriores al historial no se pueden reconstruir más atrás de su primer evento.
+func valuesAtVersion(db *gorm.DB, task *models.Task, version uint) (map[string]string, error) {
+	var known int64
+	if err := db.Model(&models.TaskEvent{}).Where("task_id = ? AND version <= ?", task.ID, version).Count(&known).Error; err != nil {
+		return nil, err
+	}
+	if known == 0 {
+		return nil, ErrRevisionNotFound
+	}
+	var events []models.TaskEvent
+	if err := db.Where("task_id = ? AND version > ? AND field <> ''", task.ID, version).Order("id DESC").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	values := historyValues(task)
+	for _, event := range events {
+		values[event.Field] = event.OldValue
+	}
+	return values, nil
+}
+
+func fieldsFromHistory(values map[string]string) (TaskFields, error) {
+	var fields TaskFields
+	targets := map[string]interface{}{
+		FieldTitle:       &fields.Title,
+		FieldDescription: &fields.Description,
+		FieldCompleted:   &fields.Completed,
+		FieldPriority:    &fields.Priority,
+		FieldDueAt:       &fields.DueAt,
+		FieldRecurrence:  &fields.Recurrence,
+		FieldProjectID:   &fields.ProjectID,
+		FieldParentID:    &fields.ParentID,
+		FieldTags:        &fields.Tags,
+	}
+	for field, target := range targets {
+		if err := json.Unmarshal([]byte(values[field]), target); err != nil {
+			return fields, err
+		}
+	}
+	return fields, nil
+}
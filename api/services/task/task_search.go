@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"html"
+	"prueba_tecnica_go_guarapo/api/models"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxSearchTerms acota los términos (palabras o frases) de una búsqueda.
+	MaxSearchTerms = 10
+
+	// las marcas se insertan con caracteres de uso privado y se cambian por <mark> después de escapar
+	// el texto, para que el contenido de la tarea nunca se interprete como HTML
+	highlightOpen  = "\uE000"
+	highlightClose = "\uE001"
+	// snippetRunes es el contexto aproximado que se muestra a cada lado de la coincidencia sin FTS5
+	snippetRunes = 60
+	// searchLikeCandidates multiplica el límite para acotar las filas que se cargan sin FTS5; el SQL
+	// adelanta las que coinciden en el título, así el orden final sale casi siempre de este recorte
+	searchLikeCandidates = 5
+)
+
+// taskSearchSchema crea el índice FTS5 sobre título y descripción. Es una tabla de contenido externo:
+// los triggers la mantienen al día con cualquier escritura sobre tasks, también las masivas.
+var taskSearchSchema = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(title, description, content='tasks', content_rowid='id', tokenize='unicode61 remove_diacritics 2')`,
+	`CREATE TRIGGER IF NOT EXISTS tasks_fts_insert AFTER INSERT ON tasks BEGIN
+		INSERT INTO tasks_fts(rowid, title, description) VALUES (new.id, new.title, new.description);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS tasks_fts_delete AFTER DELETE ON tasks BEGIN
+		INSERT INTO tasks_fts(tasks_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS tasks_fts_update AFTER UPDATE OF title, description ON tasks BEGIN
+		INSERT INTO tasks_fts(tasks_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+		INSERT INTO tasks_fts(rowid, title, description) VALUES (new.id, new.title, new.description);
+	END`,
+}
+
+// TaskSearchResult es una tarea encontrada con el título resaltado y un fragmento de la descripción.
+// Los resaltados son HTML escapado con las coincidencias entre <mark> y </mark>.
+type TaskSearchResult struct {
+	Task           *models.Task
+	Score          float64
+	TitleHighlight string
+	Snippet        string
+}
+
+// searchTerm es una palabra o una frase entre comillas; Prefix busca también las palabras que empiezan así.
+type searchTerm struct {
+	Text   string
+	Prefix bool
+}
+
+// WithFullTextSearch usa el índice FTS5 creado por SetupTaskSearch; sin esta opción la búsqueda recorre
+// las tareas con LIKE.
+func WithFullTextSearch() Option {
+	return func(s *taskService) {
+		s.fullTextSearch = true
+	}
+}
+
+// SetupTaskSearch crea el índice FTS5 de las tareas (e indexa las existentes la primera vez). Devuelve
+// false si el driver de SQLite se compiló sin FTS5 (falta el build tag sqlite_fts5).
+func SetupTaskSearch(db *gorm.DB) (bool, error) {
+	var available bool
+	if err := db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&available).Error; err != nil || !available {
+		return false, err
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'tasks_fts'").Scan(&existing).Error; err != nil {
+			return err
+		}
+		for _, stmt := range taskSearchSchema {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		if existing > 0 {
+			return nil
+		}
+		return tx.Exec("INSERT INTO tasks_fts(tasks_fts) VALUES ('rebuild')").Error
+	})
+	return err == nil, err
+}
+
+func (s *taskService) SearchTasks(ctx context.Context, username, query string, limit int) ([]*TaskSearchResult, error) {
+	terms, err := parseSearchQuery(query)
+	if err != nil {
+		s.logger.Warnf("[Layer: task_service] [Method: SearchTasks] Warning: Invalid query '%s': %v", query, err)
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultTaskPageSize
+	}
+	if limit > MaxTaskPageSize {
+		limit = MaxTaskPageSize
+	}
+
+	var results []*TaskSearchResult
+	if s.fullTextSearch {
+		results, err = s.searchFullText(ctx, username, terms, limit)
+	} else {
+		results, err = s.searchLike(ctx, username, terms, limit)
+	}
+	if err != nil {
+		s.logger.Error("[Layer: task_service] [Method: SearchTasks] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: SearchTasks] Info: Search for user '%s' returned %d tasks", username, len(results))
+	return results, nil
+}
+
+func (s *taskService) searchFullText(ctx context.Context, username string, terms []searchTerm, limit int) ([]*TaskSearchResult, error) {
+	var rows []struct {
+		ID             uint
+		Rank           float64
+		TitleHighlight string
+		Snippet        string
+	}
+	// bm25 da más peso al título que a la descripción; cuanto más negativo, más relevante
+	err := s.db.WithContext(ctx).Raw(`SELECT tasks.id, bm25(tasks_fts, 10.0, 1.0) AS rank,
+			highlight(tasks_fts, 0, ?, ?) AS title_highlight,
+			snippet(tasks_fts, 1, ?, ?, '…', 16) AS snippet
+		FROM tasks_fts JOIN tasks ON tasks.id = tasks_fts.rowid
+		WHERE tasks_fts MATCH ? AND tasks.owner = ? AND tasks.deleted_at IS NULL
+		ORDER BY rank, tasks.id
+		LIMIT ?`,
+		highlightOpen, highlightClose, highlightOpen, highlightClose, ftsMatchExpression(terms), username, limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	tasks, err := s.tasksByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*TaskSearchResult, 0, len(rows))
+	for _, row := range rows {
+		if task, ok := tasks[row.ID]; ok {
+			results = append(results, &TaskSearchResult{
+				Task:           task,
+				Score:          -row.Rank,
+				TitleHighlight: renderHighlight(row.TitleHighlight),
+				Snippet:        renderHighlight(row.Snippet),
+			})
+		}
+	}
+	return results, nil
+}
+
+// searchLike es la búsqueda sin FTS5: cada término tiene que aparecer en el título o la descripción y
+// el orden cuenta más las coincidencias en el título.
+func (s *taskService) searchLike(ctx context.Context, username string, terms []searchTerm, limit int) ([]*TaskSearchResult, error) {
+	query := withDetails(s.db.WithContext(ctx)).Where("owner = ?", username)
+	for _, term := range terms {
+		pattern := "%" + escapeLike(term.Text) + "%"
+		query = query.Where(`(title LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+	// los términos que aparecen en el título puntúan más, así que se cargan primero
+	titleHits := make([]string, 0, len(terms))
+	vars := make([]interface{}, 0, len(terms))
+	for _, term := range terms {
+		titleHits = append(titleHits, `(CASE WHEN title LIKE ? ESCAPE '\' THEN 1 ELSE 0 END)`)
+		vars = append(vars, "%"+escapeLike(term.Text)+"%")
+	}
+	var tasks []*models.Task
+	if err := query.Clauses(clause.OrderBy{Expression: clause.Expr{
+		SQL:                strings.Join(titleHits, " + ") + " DESC, updated_at DESC, id DESC",
+		Vars:               vars,
+		WithoutParentheses: true,
+	}}).Limit(limit * searchLikeCandidates).Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	patterns := termPatterns(terms)
+	results := make([]*TaskSearchResult, 0, len(tasks))
+	for _, task := range tasks {
+		var score float64
+		for _, pattern := range patterns {
+			score += 10*float64(len(pattern.FindAllStringIndex(task.Title, -1))) + float64(len(pattern.FindAllStringIndex(task.Description, -1)))
+		}
+		results = append(results, &TaskSearchResult{
+			Task:           task,
+			Score:          score,
+			TitleHighlight: renderHighlight(markMatches(task.Title, patterns)),
+			Snippet:        renderHighlight(likeSnippet(task.Description, patterns)),
+		})
+	}
+	// estable para que los empates queden del más reciente al más antiguo
+	slices.SortStableFunc(results, func(a, b *TaskSearchResult) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (s *taskService) tasksByID(ctx context.Context, ids []uint) (map[uint]*models.Task, error) {
+	byID := make(map[uint]*models.Task, len(ids))
+	if len(ids) == 0 {
+		return byID, nil
+	}
+	var tasks []*models.Task
+	if err := withDetails(s.db.WithContext(ctx)).Where("tasks.id IN ?", ids).Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	return byID, nil
+}
+
+// parseSearchQuery separa la búsqueda en palabras y frases entre comillas. Un * al final pide las
+// palabras que empiezan así. El resto de la sintaxis de FTS5 (OR, NOT, columnas) no se interpreta.
+func parseSearchQuery(query string) ([]searchTerm, error) {
+	var terms []searchTerm
+	rest := strings.TrimSpace(query)
+	for rest != "" {
+		var text string
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				// una comilla sin cerrar toma el resto como frase
+				end = len(rest) - 1
+			}
+			text, rest = rest[1:end+1], rest[min(end+2, len(rest)):]
+		} else {
+			end := strings.IndexFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+			if end < 0 {
+				end = len(rest)
+			}
+			text, rest = rest[:end], rest[end:]
+		}
+		term := searchTerm{Text: strings.Join(strings.Fields(text), " ")}
+		if strings.HasPrefix(rest, "*") {
+			term.Prefix, rest = true, rest[1:]
+		}
+		if strings.HasSuffix(term.Text, "*") {
+			term.Prefix, term.Text = true, strings.TrimRight(term.Text, "*")
+		}
+		rest = strings.TrimSpace(rest)
+		if strings.IndexFunc(term.Text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 0 || len(terms) > MaxSearchTerms {
+		return nil, ErrInvalidSearchQuery
+	}
+	return terms, nil
+}
+
+// ftsMatchExpression arma la consulta MATCH con cada término entre comillas, así la entrada del usuario
+// nunca se interpreta como operadores de FTS5. Los términos se combinan con AND.
+func ftsMatchExpression(terms []searchTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		part := `"` + strings.ReplaceAll(term.Text, `"`, `""`) + `"`
+		if term.Prefix {
+			part += "*"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// termPatterns convierte los términos en expresiones sin distinguir mayúsculas para resaltar sin FTS5.
+func termPatterns(terms []searchTerm) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(terms))
+	for _, term := range terms {
+		words := strings.Fields(term.Text)
+		for i, word := range words {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		expr := strings.Join(words, `\s+`)
+		if term.Prefix {
+			expr += `[\p{L}\p{N}]*`
+		}
+		patterns = append(patterns, regexp.MustCompile(`(?i)`+expr))
+	}
+	return patterns
+}
+
+func markMatches(text string, patterns []*regexp.Regexp) string {
+	var spans [][]int
+	for _, pattern := range patterns {
+		spans = append(spans, pattern.FindAllStringIndex(text, -1)...)
+	}
+	slices.SortFunc(spans, func(a, b []int) int { return a[0] - b[0] })
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		if span[0] < last {
+			continue
+		}
+		b.WriteString(text[last:span[0]])
+		b.WriteString(highlightOpen + text[span[0]:span[1]] + highlightClose)
+		last = span[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// likeSnippet recorta la descripción alrededor de la primera coincidencia, como snippet() de FTS5.
+func likeSnippet(text string, patterns []*regexp.Regexp) string {
+	start := -1
+	for _, pattern := range patterns {
+		if loc := pattern.FindStringIndex(text); loc != nil && (start < 0 || loc[0] < start) {
+			start = loc[0]
+		}
+	}
+	runes := []rune(text)
+	from := 0
+	if start > 0 {
+		from = max(len([]rune(text[:start]))-snippetRunes, 0)
+	}
+	to := min(from+2*snippetRunes, len(runes))
+	snippet := markMatches(string(runes[from:to]), patterns)
+	if from > 0 {
+		snippet = "…" + snippet
+	}
+	if to < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// renderHighlight escapa el texto y cambia las marcas internas por <mark>.
+func renderHighlight(text string) string {
+	return strings.NewReplacer(highlightOpen, "<mark>", highlightClose, "</mark>").Replace(html.EscapeString(text))
+}
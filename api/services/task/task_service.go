@@ -4,44 +4,195 @@ import (
 	"context"
 	"errors"
 	"prueba_tecnica_go_guarapo/api/models"
+	"slices"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type TaskService interface {
-	GetTasksByUser(ctx context.Context, username string) ([]*models.Task, error)
+	ListTasks(ctx context.Context, query TaskQuery) (*TaskPage, error)
 	GetTaskByID(ctx context.Context, id int, username string) (*models.Task, error)
-	CreateTask(ctx context.Context, title string, username string) (*models.Task, error)
-	UpdateTask(ctx context.Context, id int, title string, completed bool, username string) (*models.Task, error)
-	DeleteTask(ctx context.Context, id int, username string) error
+	CreateTask(ctx context.Context, username string, fields TaskFields) (*models.Task, error)
+	// en las escrituras expectedVersion > 0 exige que la tarea siga en esa versión (ErrVersionConflict si no); 0 no verifica
+	UpdateTask(ctx context.Context, id int, username string, fields TaskFields, expectedVersion uint) (*models.Task, error)
+	// PatchTask solo escribe los campos listados en mask; el resto de fields se ignora
+	PatchTask(ctx context.Context, id int, username string, fields TaskFields, mask []string, expectedVersion uint) (*models.Task, error)
+	// DeleteTask elimina también todas las subtareas
+	DeleteTask(ctx context.Context, id int, username string, expectedVersion uint) error
+	// papelera: DeleteTask manda la tarea a la papelera; PurgeTask la borra de verdad, esté o no en ella
+	ListTrash(ctx context.Context, username string) ([]*models.Task, error)
+	RestoreTask(ctx context.Context, id int, username string) (*models.Task, error)
+	PurgeTask(ctx context.Context, id int, username string, expectedVersion uint) error
+	// PurgeTrash borra las tareas de todos los usuarios que están en la papelera desde antes de deletedBefore
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// ListTaskHistory pagina los eventos de la tarea, del más reciente al más antiguo; after es el cursor
+	ListTaskHistory(ctx context.Context, id int, username string, limit int, after string) (*TaskHistoryPage, error)
+	// RevertTask vuelve los campos de la tarea a como estaban en version, como una escritura nueva
+	RevertTask(ctx context.Context, id int, username string, version uint, expectedVersion uint) (*models.Task, error)
+	// BulkTasks ejecuta ops en una sola transacción; los errores de cada operación van en su resultado
+	BulkTasks(ctx context.Context, username, mode string, ops []BulkOperation) ([]BulkResult, error)
+	// SearchTasks busca en el título y la descripción de las tareas del usuario, de la más relevante a la menos
+	SearchTasks(ctx context.Context, username, query string, limit int) ([]*TaskSearchResult, error)
+	// SubtaskTree devuelve las subtareas de rootIDs (en todos los niveles) agrupadas por ID del padre
+	SubtaskTree(ctx context.Context, username string, rootIDs []uint) (map[uint][]*models.Task, error)
+	// bloqueos: la tarea id no puede completarse mientras blockerID siga pendiente
+	ListBlockers(ctx context.Context, id int, username string) ([]*models.Task, error)
+	AddBlocker(ctx context.Context, id int, username string, blockerID uint) (*models.Task, error)
+	RemoveBlocker(ctx context.Context, id int, username string, blockerID uint) (*models.Task, error)
+	// PreviewRecurrence lista hasta count ocurrencias de rule empezando por start
+	PreviewRecurrence(ctx context.Context, rule string, start time.Time, count int) (*RecurrencePreview, error)
+
+	// variantes para administradores: no filtran por owner
+	// GetAllTasks pagina las tareas de todos los usuarios; de query solo usa Sort, Limit y After
+	GetAllTasks(ctx context.Context, query TaskQuery) (*TaskPage, error)
+	GetTaskByIDAsAdmin(ctx context.Context, id int) (*models.Task, error)
+	DeleteTaskAsAdmin(ctx context.Context, id int) error
 }
 
 type taskService struct {
-	db     *gorm.DB
-	logger *logrus.Logger
+	db                     *gorm.DB
+	logger                 *logrus.Logger
+	autoCompleteParents    bool
+	allowBlockedCompletion bool
+	fullTextSearch         bool
 }
 
-func NewTaskService(db *gorm.DB, logger *logrus.Logger) TaskService {
-	return &taskService{
+func NewTaskService(db *gorm.DB, logger *logrus.Logger, opts ...Option) TaskService {
+	s := &taskService{
 		db:     db,
 		logger: logger,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *taskService) GetTasksByUser(ctx context.Context, username string) ([]*models.Task, error) {
+func (s *taskService) ListTasks(ctx context.Context, query TaskQuery) (*TaskPage, error) {
+	if query.Owner == "" {
+		s.logger.Errorln("[Layer: task_service] [Method: ListTasks] Error: UserName is required")
+		return nil, ErrUserRequired
+	}
+	var err error
+	filtered := s.db.WithContext(ctx).Model(&models.Task{}).Where("owner = ?", query.Owner)
+	if query.Completed != nil {
+		filtered = filtered.Where("completed = ?", *query.Completed)
+	}
+	if query.Search != "" {
+		filtered = filtered.Where(`title LIKE ? ESCAPE '\'`, "%"+escapeLike(query.Search)+"%")
+	}
+	if query.Priority != "" {
+		if !taskPriorities[query.Priority] {
+			return nil, ErrInvalidPriority
+		}
+		filtered = filtered.Where("priority = ?", query.Priority)
+	}
+	if query.DueBefore != nil {
+		filtered = filtered.Where("due_at IS NOT NULL AND due_at < ?", query.DueBefore.UTC())
+	}
+	if query.ProjectID != nil {
+		if *query.ProjectID == 0 {
+			filtered = filtered.Where("project_id IS NULL")
+		} else {
+			if _, err := findProject(s.db.WithContext(ctx), *query.ProjectID, query.Owner); err != nil {
+				s.logger.Warnf("[Layer: task_service] [Method: ListTasks] Warning: Project '%d' not available for user '%s': %v", *query.ProjectID, query.Owner, err)
+				return nil, err
+			}
+			filtered = filtered.Where("project_id = ?", *query.ProjectID)
+		}
+	}
+	if query.ParentID != nil {
+		if *query.ParentID == 0 {
+			filtered = filtered.Where("parent_id IS NULL")
+		} else {
+			var parents int64
+			if err := s.db.WithContext(ctx).Model(&models.Task{}).Where("id = ? AND owner = ?", *query.ParentID, query.Owner).Count(&parents).Error; err != nil {
+				s.logger.Error("[Layer: task_service] [Method: ListTasks] Error: ", err)
+				return nil, err
+			}
+			if parents == 0 {
+				s.logger.Warnf("[Layer: task_service] [Method: ListTasks] Warning: Parent task '%d' not found for user '%s'", *query.ParentID, query.Owner)
+				return nil, ErrTaskNotFound
+			}
+			filtered = filtered.Where("parent_id = ?", *query.ParentID)
+		}
+	}
+	if len(query.Tags) > 0 {
+		if filtered, err = filterByTags(s.db, filtered, query.Owner, query.Tags, query.TagMode); err != nil {
+			s.logger.Warnf("[Layer: task_service] [Method: ListTasks] Warning: Invalid tag filter: %v", err)
+			return nil, err
+		}
+	}
+	if query.Overdue != nil {
+		now := time.Now().UTC()
+		if *query.Overdue {
+			filtered = filtered.Where("completed = ? AND due_at IS NOT NULL AND due_at < ?", false, now)
+		} else {
+			filtered = filtered.Where("(completed = ? OR due_at IS NULL OR due_at >= ?)", true, now)
+		}
+	}
+
+	result, err := s.pageTasks(filtered, query, "ListTasks")
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: ListTasks] Info: User '%s' listed %d of %d tasks", query.Owner, len(result.Tasks), result.Total)
+	return result, nil
+}
+
+// pageTasks aplica a filtered el orden, el cursor y el límite de query y
+// devuelve una página con el total de filas que cumplen los filtros
+func (s *taskService) pageTasks(filtered *gorm.DB, query TaskQuery, method string) (*TaskPage, error) {
+	sort, err := parseTaskSort(query.Sort)
+	if err != nil {
+		s.logger.Warnf("[Layer: task_service] [Method: %s] Warning: Invalid sort '%s'", method, query.Sort)
+		return nil, err
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultTaskPageSize
+	}
+	if limit > MaxTaskPageSize {
+		limit = MaxTaskPageSize
+	}
+
+	var total int64
+	if err := filtered.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		s.logger.Errorf("[Layer: task_service] [Method: %s] Error: %v", method, err)
+		return nil, err
+	}
+
+	page := filtered.Session(&gorm.Session{})
+	if query.After != "" {
+		cursor, err := decodeTaskCursor(query.After)
+		if err != nil || cursor.Sort != sort.raw {
+			s.logger.Warnf("[Layer: task_service] [Method: %s] Warning: Invalid cursor '%s'", method, query.After)
+			return nil, ErrInvalidCursor
+		}
+		if page, err = sort.after(page, cursor); err != nil {
+			return nil, err
+		}
+	}
+
 	var tasks []*models.Task
-	if err := s.db.WithContext(ctx).Where("owner = ?", username).Find(&tasks).Error; err != nil {
-		s.logger.Error("[Layer: task_service] [Method: GetTasksByUser] Error: ", err)
+	if err := withDetails(page).Order(sort.orderClause()).Limit(limit + 1).Find(&tasks).Error; err != nil {
+		s.logger.Errorf("[Layer: task_service] [Method: %s] Error: %v", method, err)
 		return nil, err
 	}
-	s.logger.Infof("[Layer: task_service] [Method: GetTasksByUser] Info: User '%s' requested their tasks", username)
-	return tasks, nil
+
+	result := &TaskPage{Tasks: tasks, Total: total}
+	if len(tasks) > limit {
+		result.Tasks = tasks[:limit]
+		result.NextCursor = sort.cursorFor(result.Tasks[limit-1])
+	}
+	return result, nil
 }
 
 func (s *taskService) GetTaskByID(ctx context.Context, id int, username string) (*models.Task, error) {
 	var task models.Task
-	if err := s.db.WithContext(ctx).Where("id = ? AND owner = ?", id, username).First(&task).Error; err != nil {
+	if err := withDetails(s.db.WithContext(ctx)).Where("id = ? AND owner = ?", id, username).First(&task).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			s.logger.Warnf("[Layer: task_service] [Method: GetTaskByID] Warning: Task '%d' not found or not owned by user '%s'", id, username)
 			return nil, ErrTaskNotFound
@@ -53,22 +204,55 @@ func (s *taskService) GetTaskByID(ctx context.Context, id int, username string)
 	return &task, nil
 }
 
-func (s *taskService) CreateTask(ctx context.Context, title string, username string) (*models.Task, error) {
-	if title == "" {
-		s.logger.Errorln("[Layer: task_service] [Method: CreateTask] Error: Title is required")
-		return nil, ErrTitleRequired
+func (s *taskService) CreateTask(ctx context.Context, username string, fields TaskFields) (*models.Task, error) {
+	fields = fields.withDefaults()
+	if _, err := fields.columns([]string{FieldTitle, FieldDescription, FieldPriority, FieldRecurrence, FieldTags}); err != nil {
+		s.logger.Error("[Layer: task_service] [Method: CreateTask] Error: ", err)
+		return nil, err
+	}
+	if err := checkRecurrenceDue(nil, fields, nil); err != nil {
+		s.logger.Warnf("[Layer: task_service] [Method: CreateTask] Warning: %v", err)
+		return nil, err
 	}
 	if username == "" {
 		s.logger.Errorln("[Layer: task_service] [Method: CreateTask] Error: UserName is required")
 		return nil, ErrUserRequired
 	}
+	if err := checkProjectAssignable(s.db.WithContext(ctx), fields.ProjectID, username); err != nil {
+		s.logger.Warnf("[Layer: task_service] [Method: CreateTask] Warning: Project not assignable for user '%s': %v", username, err)
+		return nil, err
+	}
+	if err := validateParent(s.db.WithContext(ctx), nil, fields.ParentID, username); err != nil {
+		s.logger.Warnf("[Layer: task_service] [Method: CreateTask] Warning: Invalid parent for user '%s': %v", username, err)
+		return nil, err
+	}
 
+	recurrence, _ := normalizeRecurrence(fields.Recurrence)
 	task := &models.Task{
-		Title:     title,
-		Completed: false,
-		Owner:     username,
+		Title:       fields.Title,
+		Description: fields.Description,
+		Completed:   false,
+		Priority:    fields.Priority,
+		DueAt:       utcOrNil(fields.DueAt),
+		Recurrence:  recurrence,
+		ProjectID:   fields.ProjectID,
+		ParentID:    fields.ParentID,
+		Owner:       username,
+		Version:     1,
 	}
-	if err := s.db.WithContext(ctx).Create(task).Error; err != nil {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(task).Error; err != nil {
+			return err
+		}
+		if err := replaceTaskTags(tx, task, fields.Tags); err != nil {
+			return err
+		}
+		if err := recordTaskEvents(tx, []uint{task.ID}, models.TaskEvent{Action: models.TaskEventCreated}); err != nil {
+			return err
+		}
+		return withDetails(tx).First(task, task.ID).Error
+	})
+	if err != nil {
 		s.logger.Error("[Layer: task_service] [Method: CreateTask] Error: ", err)
 		return nil, err
 	}
@@ -76,9 +260,16 @@ func (s *taskService) CreateTask(ctx context.Context, title string, username str
 	return task, nil
 }
 
-func (s *taskService) UpdateTask(ctx context.Context, id int, title string, completed bool, username string) (*models.Task, error) {
+func (s *taskService) UpdateTask(ctx context.Context, id int, username string, fields TaskFields, expectedVersion uint) (*models.Task, error) {
+	fields = fields.withDefaults()
+	mask := fields.replaceMask()
+	if _, err := fields.columns(mask); err != nil {
+		s.logger.Warnf("[Layer: task_service] [Method: UpdateTask] Warning: Invalid fields for task '%d': %v", id, err)
+		return nil, err
+	}
+
 	var task models.Task
-	if err := s.db.WithContext(ctx).Where("id = ? AND owner = ?", id, username).First(&task).Error; err != nil {
+	if err := withDetails(s.db.WithContext(ctx)).Where("id = ? AND owner = ?", id, username).First(&task).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			s.logger.Warnf("[Layer: task_service] [Method: UpdateTask] Warning: Task '%d' not found or not owned by user '%s'", id, username)
 			return nil, ErrTaskNotFound
@@ -86,11 +277,32 @@ func (s *taskService) UpdateTask(ctx context.Context, id int, title string, comp
 		s.logger.Error("[Layer: task_service] [Method: UpdateTask] Error: ", err)
 		return nil, err
 	}
+	if !sameID(task.ProjectID, fields.ProjectID) {
+		if err := checkProjectAssignable(s.db.WithContext(ctx), fields.ProjectID, username); err != nil {
+			s.logger.Warnf("[Layer: task_service] [Method: UpdateTask] Warning: Project not assignable to task '%d': %v", id, err)
+			return nil, err
+		}
+	}
+	if !sameID(task.ParentID, fields.ParentID) {
+		if err := validateParent(s.db.WithContext(ctx), &task, fields.ParentID, username); err != nil {
+			s.logger.Warnf("[Layer: task_service] [Method: UpdateTask] Warning: Invalid parent for task '%d': %v", id, err)
+			return nil, err
+		}
+	}
+	if err := checkRecurrenceDue(&task, fields, mask); err != nil {
+		s.logger.Warnf("[Layer: task_service] [Method: UpdateTask] Warning: Task '%d': %v", id, err)
+		return nil, err
+	}
 
-	task.Title = title
-	task.Completed = completed
-
-	if err := s.db.WithContext(ctx).Save(&task).Error; err != nil {
+	if err := s.saveVersioned(ctx, &task, fields, mask, expectedVersion); err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			s.logger.Warnf("[Layer: task_service] [Method: UpdateTask] Warning: Task '%d' is no longer at version %d", id, expectedVersion)
+			return nil, err
+		}
+		if errors.Is(err, ErrTaskBlocked) {
+			s.logger.Warnf("[Layer: task_service] [Method: UpdateTask] Warning: Task '%d' has open blockers", id)
+			return nil, err
+		}
 		s.logger.Error("[Layer: task_service] [Method: UpdateTask] Error: ", err)
 		return nil, err
 	}
@@ -98,9 +310,64 @@ func (s *taskService) UpdateTask(ctx context.Context, id int, title string, comp
 	return &task, nil
 }
 
-func (s *taskService) DeleteTask(ctx context.Context, id int, username string) error {
+func (s *taskService) PatchTask(ctx context.Context, id int, username string, fields TaskFields, mask []string, expectedVersion uint) (*models.Task, error) {
+	columns, err := fields.columns(mask)
+	if err != nil {
+		s.logger.Warnf("[Layer: task_service] [Method: PatchTask] Warning: Invalid patch for task '%d': %v", id, err)
+		return nil, err
+	}
+
 	var task models.Task
-	if err := s.db.WithContext(ctx).Where("id = ? AND owner = ?", id, username).First(&task).Error; err != nil {
+	if err := withDetails(s.db.WithContext(ctx)).Where("id = ? AND owner = ?", id, username).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Warnf("[Layer: task_service] [Method: PatchTask] Warning: Task '%d' not found or not owned by user '%s'", id, username)
+			return nil, ErrTaskNotFound
+		}
+		s.logger.Error("[Layer: task_service] [Method: PatchTask] Error: ", err)
+		return nil, err
+	}
+	if len(columns) == 0 {
+		if expectedVersion != 0 && task.Version != expectedVersion {
+			return nil, ErrVersionConflict
+		}
+		return &task, nil
+	}
+	if slices.Contains(mask, FieldProjectID) && !sameID(task.ProjectID, fields.ProjectID) {
+		if err := checkProjectAssignable(s.db.WithContext(ctx), fields.ProjectID, username); err != nil {
+			s.logger.Warnf("[Layer: task_service] [Method: PatchTask] Warning: Project not assignable to task '%d': %v", id, err)
+			return nil, err
+		}
+	}
+	if slices.Contains(mask, FieldParentID) && !sameID(task.ParentID, fields.ParentID) {
+		if err := validateParent(s.db.WithContext(ctx), &task, fields.ParentID, username); err != nil {
+			s.logger.Warnf("[Layer: task_service] [Method: PatchTask] Warning: Invalid parent for task '%d': %v", id, err)
+			return nil, err
+		}
+	}
+	if err := checkRecurrenceDue(&task, fields, mask); err != nil {
+		s.logger.Warnf("[Layer: task_service] [Method: PatchTask] Warning: Task '%d': %v", id, err)
+		return nil, err
+	}
+
+	if err := s.saveVersioned(ctx, &task, fields, mask, expectedVersion); err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			s.logger.Warnf("[Layer: task_service] [Method: PatchTask] Warning: Task '%d' is no longer at version %d", id, expectedVersion)
+			return nil, err
+		}
+		if errors.Is(err, ErrTaskBlocked) {
+			s.logger.Warnf("[Layer: task_service] [Method: PatchTask] Warning: Task '%d' has open blockers", id)
+			return nil, err
+		}
+		s.logger.Error("[Layer: task_service] [Method: PatchTask] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: PatchTask] Info: Task '%d' patched (%v) for user '%s'", id, mask, username)
+	return &task, nil
+}
+
+func (s *taskService) DeleteTask(ctx context.Context, id int, username string, expectedVersion uint) error {
+	var task models.Task
+	if err := withDetails(s.db.WithContext(ctx)).Where("id = ? AND owner = ?", id, username).First(&task).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			s.logger.Warnf("[Layer: task_service] [Method: DeleteTask] Warning: Task '%d' not found or not owned by user '%s'", id, username)
 			return ErrTaskNotFound
@@ -109,10 +376,180 @@ func (s *taskService) DeleteTask(ctx context.Context, id int, username string) e
 		return err
 	}
 
-	if err := s.db.WithContext(ctx).Delete(&task).Error; err != nil {
-		s.logger.Error("[Layer: task_service] [Method: DeleteTask] Error: ", err)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids, err := subtreeIDs(tx, task.ID)
+		if err != nil {
+			return err
+		}
+		dependents, err := snapshotDependents(tx, ids)
+		if err != nil {
+			return err
+		}
+		query := tx
+		if expectedVersion != 0 {
+			query = query.Where("version = ?", expectedVersion)
+		}
+		result := query.Model(&task).Updates(trashUpdates(tx))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if expectedVersion == 0 {
+				return ErrTaskNotFound
+			}
+			return ErrVersionConflict
+		}
+		if err := recordTaskEvents(tx, []uint{task.ID}, models.TaskEvent{Action: models.TaskEventDeleted}); err != nil {
+			return err
+		}
+		if err := deleteSubtasks(tx, task.ID); err != nil {
+			return err
+		}
+		return dependents.sync(tx)
+	})
+	if err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			s.logger.Warnf("[Layer: task_service] [Method: DeleteTask] Warning: Task '%d' is no longer at version %d", id, expectedVersion)
+			return err
+		}
+		if !errors.Is(err, ErrTaskNotFound) {
+			s.logger.Error("[Layer: task_service] [Method: DeleteTask] Error: ", err)
+		}
 		return err
 	}
 	s.logger.Infof("[Layer: task_service] [Method: DeleteTask] Info: Task '%d' deleted for user '%s'", id, username)
 	return nil
 }
+
+// saveVersioned escribe los campos de mask e incrementa la versión en un solo UPDATE; con expectedVersion > 0
+// la condición va en el WHERE, así dos escrituras concurrentes sobre la misma versión no se pisan.
+// Las etiquetas se reemplazan y los cambios se registran en el historial en la misma transacción. Completar una tarea bloqueada devuelve ErrTaskBlocked
+// y completar una recurrente crea su siguiente ocurrencia. events se registran además con la nueva versión.
+func (s *taskService) saveVersioned(ctx context.Context, task *models.Task, fields TaskFields, mask []string, expectedVersion uint, events ...models.TaskEvent) error {
+	if expectedVersion != 0 && task.Version != expectedVersion {
+		return ErrVersionConflict
+	}
+	updates := fields.updates(mask)
+	if completed, ok := updates[FieldCompleted].(bool); ok && completed != task.Completed {
+		// completed_at se fija al completar y se limpia al reabrir
+		if completed {
+			updates["completed_at"] = time.Now().UTC()
+		} else {
+			updates["completed_at"] = nil
+		}
+	}
+	updates["version"] = gorm.Expr("version + 1")
+	wasOpen := !task.Completed
+	before := snapshotTask(task)
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.checkCompletable(tx, task, updates); err != nil {
+			return err
+		}
+		// completar o reabrir la tarea cambia blocked en las que dependen de ella
+		var dependents blockedSnapshot
+		if _, ok := updates[FieldCompleted]; ok {
+			var err error
+			if dependents, err = snapshotDependents(tx, []uint{task.ID}); err != nil {
+				return err
+			}
+		}
+		query := tx.Model(task)
+		if expectedVersion != 0 {
+			query = query.Where("version = ?", expectedVersion)
+		}
+		result := query.Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if expectedVersion != 0 {
+				return ErrVersionConflict
+			}
+			return ErrTaskNotFound
+		}
+		if slices.Contains(mask, FieldTags) {
+			if err := replaceTaskTags(tx, task, fields.Tags); err != nil {
+				return err
+			}
+		}
+		if err := withDetails(tx).First(task, task.ID).Error; err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := recordTaskEvents(tx, []uint{task.ID}, event); err != nil {
+				return err
+			}
+		}
+		if err := recordTaskChanges(tx, before, task); err != nil {
+			return err
+		}
+		if err := dependents.sync(tx); err != nil {
+			return err
+		}
+		if wasOpen && task.Completed {
+			if err := scheduleNextOccurrence(tx, task); err != nil {
+				return err
+			}
+		}
+		if s.autoCompleteParents && task.Completed && (slices.Contains(mask, FieldCompleted) || slices.Contains(mask, FieldParentID)) {
+			return s.completeAncestors(tx, task.ParentID)
+		}
+		return nil
+	})
+}
+
+func (s *taskService) GetAllTasks(ctx context.Context, query TaskQuery) (*TaskPage, error) {
+	result, err := s.pageTasks(s.db.WithContext(ctx).Model(&models.Task{}), query, "GetAllTasks")
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: GetAllTasks] Info: Admin listed %d of %d tasks", len(result.Tasks), result.Total)
+	return result, nil
+}
+
+func (s *taskService) GetTaskByIDAsAdmin(ctx context.Context, id int) (*models.Task, error) {
+	var task models.Task
+	if err := withDetails(s.db.WithContext(ctx)).Where("id = ?", id).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Warnf("[Layer: task_service] [Method: GetTaskByIDAsAdmin] Warning: Task '%d' not found", id)
+			return nil, ErrTaskNotFound
+		}
+		s.logger.Error("[Layer: task_service] [Method: GetTaskByIDAsAdmin] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: GetTaskByIDAsAdmin] Info: Admin retrieved task '%d' of user '%s'", id, task.Owner)
+	return &task, nil
+}
+
+func (s *taskService) DeleteTaskAsAdmin(ctx context.Context, id int) error {
+	task, err := s.GetTaskByIDAsAdmin(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids, err := subtreeIDs(tx, task.ID)
+		if err != nil {
+			return err
+		}
+		dependents, err := snapshotDependents(tx, ids)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(task).Updates(trashUpdates(tx)).Error; err != nil {
+			return err
+		}
+		if err := recordTaskEvents(tx, []uint{task.ID}, models.TaskEvent{Action: models.TaskEventDeleted}); err != nil {
+			return err
+		}
+		if err := deleteSubtasks(tx, task.ID); err != nil {
+			return err
+		}
+		return dependents.sync(tx)
+	})
+	if err != nil {
+		s.logger.Error("[Layer: task_service] [Method: DeleteTaskAsAdmin] Error: ", err)
+		return err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: DeleteTaskAsAdmin] Info: Admin deleted task '%d' of user '%s'", id, task.Owner)
+	return nil
+}
@@ -3,7 +3,9 @@ package services
 import (
 	"context"
 	"prueba_tecnica_go_guarapo/api/models"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -14,7 +16,7 @@ import (
 func setupTestDB(t *testing.T) *gorm.DB {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	assert.NoError(t, err)
-	assert.NoError(t, db.AutoMigrate(&models.Task{}))
+	assert.NoError(t, db.AutoMigrate(&models.Task{}, &models.Tag{}, &models.Project{}, &models.TaskDependency{}, &models.TaskEvent{}))
 	return db
 }
 
@@ -23,16 +25,16 @@ func TestCreateTask(t *testing.T) {
 	service := NewTaskService(db, logrus.New())
 	ctx := context.Background()
 
-	task, err := service.CreateTask(ctx, "", "user1")
+	task, err := service.CreateTask(ctx, "user1", TaskFields{Title: ""})
 	assert.Nil(t, task)
 	assert.ErrorIs(t, err, ErrTitleRequired)
 
-	task, err = service.CreateTask(ctx, "Test", "")
+	task, err = service.CreateTask(ctx, "", TaskFields{Title: "Test"})
 	assert.Nil(t, task)
 	assert.ErrorIs(t, err, ErrUserRequired)
 
 	// Caso: éxito
-	task, err = service.CreateTask(ctx, "Test Task", "user1")
+	task, err = service.CreateTask(ctx, "user1", TaskFields{Title: "Test Task"})
 	assert.NoError(t, err)
 	assert.NotNil(t, task)
 	assert.Equal(t, "Test Task", task.Title)
@@ -40,22 +42,625 @@ func TestCreateTask(t *testing.T) {
 	assert.Equal(t, "user1", task.Owner)
 }
 
-func TestGetTasksByUser(t *testing.T) {
+func TestListTasks(t *testing.T) {
 	db := setupTestDB(t)
 	service := NewTaskService(db, logrus.New())
 	ctx := context.Background()
 
-	tasks, err := service.GetTasksByUser(ctx, "user1")
+	page, err := service.ListTasks(ctx, TaskQuery{Owner: "user1"})
 	assert.NoError(t, err)
-	assert.Len(t, tasks, 0)
+	assert.Len(t, page.Tasks, 0)
+	assert.Equal(t, int64(0), page.Total)
 
-	_, _ = service.CreateTask(ctx, "Task 1", "user1")
-	_, _ = service.CreateTask(ctx, "Task 2", "user1")
-	_, _ = service.CreateTask(ctx, "Task 3", "user2")
+	_, _ = service.CreateTask(ctx, "user1", TaskFields{Title: "Comprar pan"})
+	done, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Pagar luz"})
+	_, _ = service.CreateTask(ctx, "user1", TaskFields{Title: "100% listo_"})
+	_, _ = service.CreateTask(ctx, "user2", TaskFields{Title: "Comprar leche"})
+	_, err = service.UpdateTask(ctx, int(done.ID), "user1", TaskFields{Title: done.Title, Completed: true}, 0)
+	assert.NoError(t, err)
+
+	completed, pending := true, false
+	testScenarios := []struct {
+		testName      string
+		query         TaskQuery
+		expectedErr   error
+		expectedTitle []string
+	}{
+		{testName: "Solo tareas del dueño", query: TaskQuery{Owner: "user1"}, expectedTitle: []string{"Comprar pan", "Pagar luz", "100% listo_"}},
+		{testName: "Filtrar completadas", query: TaskQuery{Owner: "user1", Completed: &completed}, expectedTitle: []string{"Pagar luz"}},
+		{testName: "Filtrar pendientes", query: TaskQuery{Owner: "user1", Completed: &pending}, expectedTitle: []string{"Comprar pan", "100% listo_"}},
+		{testName: "Buscar en el título", query: TaskQuery{Owner: "user1", Search: "compr"}, expectedTitle: []string{"Comprar pan"}},
+		{testName: "Comodines literales", query: TaskQuery{Owner: "user1", Search: "0% listo_"}, expectedTitle: []string{"100% listo_"}},
+		{testName: "Ordenar por título", query: TaskQuery{Owner: "user1", Sort: "title"}, expectedTitle: []string{"100% listo_", "Comprar pan", "Pagar luz"}},
+		{testName: "Orden descendente", query: TaskQuery{Owner: "user1", Sort: "-created_at"}, expectedTitle: []string{"100% listo_", "Pagar luz", "Comprar pan"}},
+		{testName: "Orden inválido", query: TaskQuery{Owner: "user1", Sort: "owner"}, expectedErr: ErrInvalidSort},
+		{testName: "Cursor inválido", query: TaskQuery{Owner: "user1", After: "not-a-cursor"}, expectedErr: ErrInvalidCursor},
+		{testName: "Sin dueño", query: TaskQuery{}, expectedErr: ErrUserRequired},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			page, err := service.ListTasks(ctx, tt.query)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			titles := make([]string, 0, len(page.Tasks))
+			for _, task := range page.Tasks {
+				titles = append(titles, task.Title)
+			}
+			assert.Equal(t, tt.expectedTitle, titles)
+			assert.Equal(t, int64(len(tt.expectedTitle)), page.Total)
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+}
+
+func TestTaskPlanningFields(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+
+	bogota := time.FixedZone("COT", -5*60*60)
+	due := time.Now().AddDate(0, 1, 0).Truncate(time.Second).In(bogota)
+
+	// Caso: validaciones de alta
+	_, err := service.CreateTask(ctx, "user1", TaskFields{Title: "Task", Priority: "critical"})
+	assert.ErrorIs(t, err, ErrInvalidPriority)
+	_, err = service.CreateTask(ctx, "user1", TaskFields{Title: "Task", Description: strings.Repeat("a", MaxDescriptionLength+1)})
+	assert.ErrorIs(t, err, ErrDescriptionTooLong)
+
+	task, err := service.CreateTask(ctx, "user1", TaskFields{Title: "Informe", Description: "**urgente**", Priority: "high", DueAt: &due})
+	assert.NoError(t, err)
+	assert.Equal(t, "**urgente**", task.Description)
+	assert.Equal(t, "high", task.Priority)
+	assert.True(t, due.Equal(*task.DueAt))
+	assert.Equal(t, time.UTC, task.DueAt.Location())
+	assert.Nil(t, task.CompletedAt)
+
+	plain, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Sin fecha"})
+	assert.Equal(t, "medium", plain.Priority)
+	assert.Nil(t, plain.DueAt)
+
+	// Caso: completed_at se fija al completar y se limpia al reabrir
+	completed, err := service.PatchTask(ctx, int(task.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 0)
+	assert.NoError(t, err)
+	assert.NotNil(t, completed.CompletedAt)
+	stillCompleted, err := service.UpdateTask(ctx, int(task.ID), "user1", TaskFields{Title: "Informe", Completed: true, DueAt: &due}, 0)
+	assert.NoError(t, err)
+	assert.True(t, completed.CompletedAt.Equal(*stillCompleted.CompletedAt))
+	assert.Equal(t, "medium", stillCompleted.Priority)
+	reopened, err := service.UpdateTask(ctx, int(task.ID), "user1", TaskFields{Title: "Informe", Priority: "urgent", DueAt: &due}, 0)
+	assert.NoError(t, err)
+	assert.Nil(t, reopened.CompletedAt)
+
+	// Caso: en PATCH la prioridad vacía no se completa por defecto
+	_, err = service.PatchTask(ctx, int(task.ID), "user1", TaskFields{}, []string{FieldPriority}, 0)
+	assert.ErrorIs(t, err, ErrInvalidPriority)
+
+	// Caso: filtros por vencimiento y prioridad
+	past := time.Now().Add(-time.Hour)
+	late, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Vencida", DueAt: &past})
+	lateDone, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Vencida hecha", DueAt: &past})
+	_, err = service.PatchTask(ctx, int(lateDone.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 0)
+	assert.NoError(t, err)
+
+	overdue, notOverdue := true, false
+	before := due.Add(time.Minute)
+	testScenarios := []struct {
+		testName      string
+		query         TaskQuery
+		expectedErr   error
+		expectedTitle []string
+	}{
+		{testName: "Vencidas", query: TaskQuery{Owner: "user1", Overdue: &overdue}, expectedTitle: []string{late.Title}},
+		{testName: "No vencidas", query: TaskQuery{Owner: "user1", Overdue: &notOverdue}, expectedTitle: []string{"Informe", "Sin fecha", "Vencida hecha"}},
+		{testName: "Vencen antes de", query: TaskQuery{Owner: "user1", DueBefore: &before}, expectedTitle: []string{"Informe", "Vencida", "Vencida hecha"}},
+		{testName: "Por prioridad", query: TaskQuery{Owner: "user1", Priority: "urgent"}, expectedTitle: []string{"Informe"}},
+		{testName: "Prioridad inválida", query: TaskQuery{Owner: "user1", Priority: "critical"}, expectedErr: ErrInvalidPriority},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			page, err := service.ListTasks(ctx, tt.query)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			titles := make([]string, 0, len(page.Tasks))
+			for _, task := range page.Tasks {
+				titles = append(titles, task.Title)
+			}
+			assert.Equal(t, tt.expectedTitle, titles)
+		})
+	}
+}
+
+func TestTaskTags(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+
+	// Caso: validaciones
+	_, err := service.CreateTask(ctx, "user1", TaskFields{Title: "Task", Tags: []string{"  "}})
+	assert.ErrorIs(t, err, ErrInvalidTagName)
+	_, err = service.CreateTask(ctx, "user1", TaskFields{Title: "Task", Tags: []string{strings.Repeat("a", MaxTagNameLength+1)}})
+	assert.ErrorIs(t, err, ErrInvalidTagName)
+
+	// Caso: las etiquetas se crean al asignarlas, sin duplicados y ordenadas por nombre
+	report, err := service.CreateTask(ctx, "user1", TaskFields{Title: "Informe", Tags: []string{"trabajo", " urgente ", "trabajo"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"trabajo", "urgente"}, tagNamesOf(report))
+	groceries, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Mercado", Tags: []string{"casa"}})
+	_, _ = service.CreateTask(ctx, "user1", TaskFields{Title: "Sin etiquetas"})
+	_, _ = service.CreateTask(ctx, "user2", TaskFields{Title: "Ajena", Tags: []string{"trabajo", "urgente"}})
+
+	var count int64
+	db.Model(&models.Tag{}).Where("owner = ?", "user1").Count(&count)
+	assert.Equal(t, int64(3), count)
+
+	// Caso: PUT sin etiquetas las conserva; con lista las reemplaza y sube la versión
+	updated, err := service.UpdateTask(ctx, int(groceries.ID), "user1", TaskFields{Title: "Mercado"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"casa"}, tagNamesOf(updated))
+	updated, err = service.UpdateTask(ctx, int(groceries.ID), "user1", TaskFields{Title: "Mercado", Tags: []string{"casa", "urgente"}}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"casa", "urgente"}, tagNamesOf(updated))
+	assert.Equal(t, uint(3), updated.Version)
+
+	// Caso: PATCH solo de etiquetas
+	patched, err := service.PatchTask(ctx, int(report.ID), "user1", TaskFields{Tags: []string{"trabajo"}}, []string{FieldTags}, report.Version)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"trabajo"}, tagNamesOf(patched))
+	assert.Equal(t, report.Version+1, patched.Version)
+
+	testScenarios := []struct {
+		testName      string
+		query         TaskQuery
+		expectedErr   error
+		expectedTitle []string
+	}{
+		{testName: "Una etiqueta", query: TaskQuery{Owner: "user1", Tags: []string{"urgente"}}, expectedTitle: []string{"Mercado"}},
+		{testName: "Todas", query: TaskQuery{Owner: "user1", Tags: []string{"casa", "urgente"}}, expectedTitle: []string{"Mercado"}},
+		{testName: "Todas sin coincidencias", query: TaskQuery{Owner: "user1", Tags: []string{"trabajo", "casa"}, TagMode: TagModeAll}, expectedTitle: []string{}},
+		{testName: "Alguna", query: TaskQuery{Owner: "user1", Tags: []string{"trabajo", "casa"}, TagMode: TagModeAny}, expectedTitle: []string{"Informe", "Mercado"}},
+		{testName: "Etiqueta inexistente", query: TaskQuery{Owner: "user1", Tags: []string{"otra"}}, expectedTitle: []string{}},
+		{testName: "Modo inválido", query: TaskQuery{Owner: "user1", Tags: []string{"casa"}, TagMode: "some"}, expectedErr: ErrInvalidTagMode},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			page, err := service.ListTasks(ctx, tt.query)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			titles := make([]string, 0, len(page.Tasks))
+			for _, task := range page.Tasks {
+				titles = append(titles, task.Title)
+			}
+			assert.Equal(t, tt.expectedTitle, titles)
+			assert.Equal(t, int64(len(tt.expectedTitle)), page.Total)
+		})
+	}
+}
+
+func tagNamesOf(task *models.Task) []string {
+	names := make([]string, 0, len(task.Tags))
+	for _, tag := range task.Tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+func TestTaskProjects(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	projects := NewProjectService(db, logrus.New())
+	ctx := context.Background()
+
+	work, _ := projects.CreateProject(ctx, "user1", ProjectFields{Name: "Trabajo"})
+	old, _ := projects.CreateProject(ctx, "user1", ProjectFields{Name: "Viejo"})
+	_, _ = projects.ArchiveProject(ctx, int(old.ID), "user1", ProjectTasksKeep)
+	foreign, _ := projects.CreateProject(ctx, "user2", ProjectFields{Name: "Ajeno"})
+
+	// Caso: solo se asignan proyectos propios y activos
+	_, err := service.CreateTask(ctx, "user1", TaskFields{Title: "Task", ProjectID: &foreign.ID})
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+	_, err = service.CreateTask(ctx, "user1", TaskFields{Title: "Task", ProjectID: &old.ID})
+	assert.ErrorIs(t, err, ErrProjectArchived)
+
+	report, err := service.CreateTask(ctx, "user1", TaskFields{Title: "Informe", ProjectID: &work.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, work.ID, *report.ProjectID)
+	inbox, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Suelta"})
+	assert.Nil(t, inbox.ProjectID)
+
+	// Caso: mover con PATCH y volver a la bandeja con PUT sin project_id
+	moved, err := service.PatchTask(ctx, int(inbox.ID), "user1", TaskFields{ProjectID: &work.ID}, []string{FieldProjectID}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, work.ID, *moved.ProjectID)
+	_, err = service.PatchTask(ctx, int(inbox.ID), "user1", TaskFields{ProjectID: &old.ID}, []string{FieldProjectID}, 0)
+	assert.ErrorIs(t, err, ErrProjectArchived)
+	back, err := service.UpdateTask(ctx, int(inbox.ID), "user1", TaskFields{Title: "Suelta"}, 0)
+	assert.NoError(t, err)
+	assert.Nil(t, back.ProjectID)
+
+	zero := uint(0)
+	testScenarios := []struct {
+		testName      string
+		projectID     *uint
+		expectedErr   error
+		expectedTitle []string
+	}{
+		{testName: "Por proyecto", projectID: &work.ID, expectedTitle: []string{"Informe"}},
+		{testName: "Bandeja de entrada", projectID: &zero, expectedTitle: []string{"Suelta"}},
+		{testName: "Proyecto ajeno", projectID: &foreign.ID, expectedErr: ErrProjectNotFound},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			page, err := service.ListTasks(ctx, TaskQuery{Owner: "user1", ProjectID: tt.projectID})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			titles := make([]string, 0, len(page.Tasks))
+			for _, task := range page.Tasks {
+				titles = append(titles, task.Title)
+			}
+			assert.Equal(t, tt.expectedTitle, titles)
+		})
+	}
+}
+
+func TestTaskSubtasks(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+
+	root, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Raíz"})
+	child, err := service.CreateTask(ctx, "user1", TaskFields{Title: "Hija", ParentID: &root.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, root.ID, *child.ParentID)
+	foreign, _ := service.CreateTask(ctx, "user2", TaskFields{Title: "Ajena"})
+
+	// Caso: el padre debe existir y ser del mismo dueño
+	_, err = service.CreateTask(ctx, "user1", TaskFields{Title: "Task", ParentID: &foreign.ID})
+	assert.ErrorIs(t, err, ErrParentNotFound)
+
+	// Caso: una tarea no puede colgar de sí misma ni de sus subtareas
+	_, err = service.PatchTask(ctx, int(root.ID), "user1", TaskFields{ParentID: &root.ID}, []string{FieldParentID}, 0)
+	assert.ErrorIs(t, err, ErrTaskCycle)
+	_, err = service.PatchTask(ctx, int(root.ID), "user1", TaskFields{ParentID: &child.ID}, []string{FieldParentID}, 0)
+	assert.ErrorIs(t, err, ErrTaskCycle)
+
+	// Caso: como máximo MaxTaskDepth niveles, también al mover un subárbol
+	last := child
+	for level := 3; level <= MaxTaskDepth; level++ {
+		last, err = service.CreateTask(ctx, "user1", TaskFields{Title: "Nivel", ParentID: &last.ID})
+		assert.NoError(t, err)
+	}
+	_, err = service.CreateTask(ctx, "user1", TaskFields{Title: "Demasiado", ParentID: &last.ID})
+	assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+	other, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Otra"})
+	_, err = service.PatchTask(ctx, int(root.ID), "user1", TaskFields{ParentID: &other.ID}, []string{FieldParentID}, 0)
+	assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+
+	tree, err := service.SubtaskTree(ctx, "user1", []uint{root.ID})
+	assert.NoError(t, err)
+	assert.Len(t, tree[root.ID], 1)
+	assert.Len(t, tree[child.ID], 1)
+	assert.Empty(t, tree[last.ID])
+
+	zero := uint(0)
+	testScenarios := []struct {
+		testName      string
+		parentID      *uint
+		expectedErr   error
+		expectedTitle []string
+	}{
+		{testName: "Subtareas directas", parentID: &root.ID, expectedTitle: []string{"Hija"}},
+		{testName: "Solo tareas raíz", parentID: &zero, expectedTitle: []string{"Raíz", "Otra"}},
+		{testName: "Padre ajeno", parentID: &foreign.ID, expectedErr: ErrTaskNotFound},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			page, err := service.ListTasks(ctx, TaskQuery{Owner: "user1", ParentID: tt.parentID})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			titles := make([]string, 0, len(page.Tasks))
+			for _, task := range page.Tasks {
+				titles = append(titles, task.Title)
+			}
+			assert.Equal(t, tt.expectedTitle, titles)
+		})
+	}
+
+	// Caso: borrar una tarea borra sus subtareas
+	assert.NoError(t, service.DeleteTask(ctx, int(child.ID), "user1", 0))
+	var remaining int64
+	db.Model(&models.Task{}).Where("owner = ?", "user1").Count(&remaining)
+	assert.Equal(t, int64(2), remaining)
+}
+
+func TestTaskSubtasks_AutoComplete(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New(), WithParentAutoComplete())
+	ctx := context.Background()
+
+	root, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Raíz"})
+	first, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Primera", ParentID: &root.ID})
+	second, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Segunda", ParentID: &root.ID})
+
+	_, err := service.PatchTask(ctx, int(first.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 0)
+	assert.NoError(t, err)
+	parent, _ := service.GetTaskByID(ctx, int(root.ID), "user1")
+	assert.False(t, parent.Completed)
+
+	_, err = service.PatchTask(ctx, int(second.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 0)
+	assert.NoError(t, err)
+	parent, _ = service.GetTaskByID(ctx, int(root.ID), "user1")
+	assert.True(t, parent.Completed)
+	assert.NotNil(t, parent.CompletedAt)
+	assert.Equal(t, root.Version+1, parent.Version)
+
+	// Caso: un padre recurrente completado por su última subtarea programa la siguiente ocurrencia
+	monday := time.Date(2026, time.January, 5, 9, 0, 0, 0, time.UTC)
+	weekly, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Limpieza", Recurrence: "FREQ=WEEKLY", DueAt: &monday, Tags: []string{"casa"}})
+	only, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Barrer", ParentID: &weekly.ID})
+	_, err = service.PatchTask(ctx, int(only.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 0)
+	assert.NoError(t, err)
+	parent, _ = service.GetTaskByID(ctx, int(weekly.ID), "user1")
+	assert.True(t, parent.Completed)
+	if assert.NotNil(t, parent.NextOccurrenceID) {
+		next, err := service.GetTaskByID(ctx, int(*parent.NextOccurrenceID), "user1")
+		assert.NoError(t, err)
+		assert.False(t, next.Completed)
+		assert.Equal(t, monday.AddDate(0, 0, 7), next.DueAt.UTC())
+		assert.Len(t, next.Tags, 1)
+	}
+}
+
+func TestTaskDependencies(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+
+	design, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Diseño"})
+	build, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Construcción"})
+	release, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Lanzamiento"})
+	foreign, _ := service.CreateTask(ctx, "user2", TaskFields{Title: "Ajena"})
+
+	blocked, err := service.AddBlocker(ctx, int(build.ID), "user1", design.ID)
+	assert.NoError(t, err)
+	assert.True(t, blocked.Blocked)
+	_, err = service.AddBlocker(ctx, int(release.ID), "user1", build.ID)
+	assert.NoError(t, err)
+
+	testScenarios := []struct {
+		testName    string
+		taskID      uint
+		blockerID   uint
+		expectedErr error
+	}{
+		{testName: "Bloqueo de otro usuario", taskID: build.ID, blockerID: foreign.ID, expectedErr: ErrBlockerNotFound},
+		{testName: "Tarea de otro usuario", taskID: foreign.ID, blockerID: design.ID, expectedErr: ErrTaskNotFound},
+		{testName: "Bloqueo repetido", taskID: build.ID, blockerID: design.ID, expectedErr: ErrDependencyExists},
+		{testName: "Bloqueada por sí misma", taskID: design.ID, blockerID: design.ID, expectedErr: ErrDependencyCycle},
+		{testName: "Ciclo directo", taskID: design.ID, blockerID: build.ID, expectedErr: ErrDependencyCycle},
+		{testName: "Ciclo indirecto", taskID: design.ID, blockerID: release.ID, expectedErr: ErrDependencyCycle},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			_, err := service.AddBlocker(ctx, int(tt.taskID), "user1", tt.blockerID)
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+
+	blockers, err := service.ListBlockers(ctx, int(build.ID), "user1")
+	assert.NoError(t, err)
+	assert.Len(t, blockers, 1)
+	assert.Equal(t, design.ID, blockers[0].ID)
+
+	// Caso: no se puede completar mientras el bloqueo siga pendiente
+	_, err = service.PatchTask(ctx, int(build.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 0)
+	assert.ErrorIs(t, err, ErrTaskBlocked)
+	_, err = service.UpdateTask(ctx, int(build.ID), "user1", TaskFields{Title: "Construcción", Completed: true}, 0)
+	assert.ErrorIs(t, err, ErrTaskBlocked)
+
+	_, err = service.PatchTask(ctx, int(design.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 0)
+	assert.NoError(t, err)
+	unblocked, _ := service.GetTaskByID(ctx, int(build.ID), "user1")
+	assert.False(t, unblocked.Blocked)
+	_, err = service.PatchTask(ctx, int(build.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 0)
+	assert.NoError(t, err)
+
+	// Caso: quitar un bloqueo
+	_, err = service.RemoveBlocker(ctx, int(release.ID), "user1", build.ID)
+	assert.NoError(t, err)
+	_, err = service.RemoveBlocker(ctx, int(release.ID), "user1", build.ID)
+	assert.ErrorIs(t, err, ErrDependencyNotFound)
+}
+
+func TestTaskDependencies_Versions(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+
+	design, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Diseño"})
+	build, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Construcción"})
+	version := func() uint {
+		task, err := service.GetTaskByID(ctx, int(build.ID), "user1")
+		assert.NoError(t, err)
+		return task.Version
+	}
+
+	// Caso: agregar o quitar un bloqueo es una escritura sobre la tarea bloqueada
+	blocked, err := service.AddBlocker(ctx, int(build.ID), "user1", design.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), blocked.Version)
+
+	// Caso: completar, reabrir, eliminar y restaurar el bloqueo cambia blocked en la dependiente
+	_, err = service.PatchTask(ctx, int(design.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), version())
+	_, err = service.PatchTask(ctx, int(design.ID), "user1", TaskFields{Title: "Diseño final"}, []string{FieldTitle}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), version())
+	_, err = service.PatchTask(ctx, int(design.ID), "user1", TaskFields{Completed: false}, []string{FieldCompleted}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), version())
+	assert.NoError(t, service.DeleteTask(ctx, int(design.ID), "user1", 0))
+	assert.Equal(t, uint(5), version())
+	_, err = service.RestoreTask(ctx, int(design.ID), "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(6), version())
+
+	unblocked, err := service.RemoveBlocker(ctx, int(build.ID), "user1", design.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), unblocked.Version)
+	assert.False(t, unblocked.Blocked)
+
+	page, err := service.ListTaskHistory(ctx, int(build.ID), "user1", 0, "")
+	assert.NoError(t, err)
+	var changes []string
+	for _, event := range page.Events {
+		changes = append(changes, event.Field+" "+event.OldValue+"→"+event.NewValue)
+	}
+	assert.Equal(t, []string{
+		"blockers [1]→[]",
+		"blocked false→true",
+		"blocked true→false",
+		"blocked false→true",
+		"blocked true→false",
+		"blockers []→[1]",
+		" →",
+	}, changes)
+}
+
+func TestTaskDependencies_BlockedCompletion(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New(), WithBlockedCompletion())
+	ctx := context.Background()
+
+	first, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Primera"})
+	second, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Segunda"})
+	_, _ = service.AddBlocker(ctx, int(second.ID), "user1", first.ID)
+
+	page, err := service.ListTasks(ctx, TaskQuery{Owner: "user1"})
+	assert.NoError(t, err)
+	assert.False(t, page.Tasks[0].Blocked)
+	assert.True(t, page.Tasks[1].Blocked)
+
+	completed, err := service.PatchTask(ctx, int(second.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 0)
+	assert.NoError(t, err)
+	assert.True(t, completed.Completed)
+
+	// Caso: un bloqueo eliminado deja de bloquear
+	assert.NoError(t, service.DeleteTask(ctx, int(first.ID), "user1", 0))
+	task, _ := service.GetTaskByID(ctx, int(second.ID), "user1")
+	assert.False(t, task.Blocked)
+}
+
+func TestTaskRecurrence(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+	monday := time.Date(2026, time.January, 5, 9, 0, 0, 0, time.UTC)
+
+	// Caso: la regla se valida, se guarda en forma canónica y necesita due_at
+	_, err := service.CreateTask(ctx, "user1", TaskFields{Title: "Task", Recurrence: "FREQ=HOURLY", DueAt: &monday})
+	assert.ErrorIs(t, err, ErrInvalidRecurrence)
+	_, err = service.CreateTask(ctx, "user1", TaskFields{Title: "Task", Recurrence: "FREQ=WEEKLY"})
+	assert.ErrorIs(t, err, ErrRecurrenceNeedsDueAt)
+	chore, err := service.CreateTask(ctx, "user1", TaskFields{Title: "Sacar la basura", Recurrence: "freq=weekly;byday=th,mo;count=2", DueAt: &monday, Tags: []string{"casa"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=2", chore.Recurrence)
+	_, err = service.PatchTask(ctx, int(chore.ID), "user1", TaskFields{}, []string{FieldDueAt}, 0)
+	assert.ErrorIs(t, err, ErrRecurrenceNeedsDueAt)
+
+	// Caso: completar crea la siguiente ocurrencia con los mismos datos
+	completed, err := service.UpdateTask(ctx, int(chore.ID), "user1", TaskFields{Title: "Sacar la basura", Completed: true, Recurrence: chore.Recurrence, DueAt: &monday}, 0)
+	assert.NoError(t, err)
+	assert.NotNil(t, completed.NextOccurrenceID)
+	next, err := service.GetTaskByID(ctx, int(*completed.NextOccurrenceID), "user1")
+	assert.NoError(t, err)
+	assert.False(t, next.Completed)
+	assert.Equal(t, monday.AddDate(0, 0, 3), next.DueAt.UTC())
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=1", next.Recurrence)
+	assert.Len(t, next.Tags, 1)
+	assert.Equal(t, "casa", next.Tags[0].Name)
+
+	// Caso: reabrir y volver a completar no duplica la ocurrencia
+	_, _ = service.PatchTask(ctx, int(chore.ID), "user1", TaskFields{Completed: false}, []string{FieldCompleted}, 0)
+	_, _ = service.PatchTask(ctx, int(chore.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 0)
+	var total int64
+	db.Model(&models.Task{}).Where("owner = ?", "user1").Count(&total)
+	assert.Equal(t, int64(2), total)
+
+	// Caso: con COUNT agotado la serie termina
+	last, err := service.PatchTask(ctx, int(next.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 0)
+	assert.NoError(t, err)
+	assert.Nil(t, last.NextOccurrenceID)
 
-	tasks, err = service.GetTasksByUser(ctx, "user1")
+	preview, err := service.PreviewRecurrence(ctx, "FREQ=DAILY", monday, 500)
 	assert.NoError(t, err)
-	assert.Len(t, tasks, 2)
+	assert.Len(t, preview.Occurrences, MaxPreviewOccurrences)
+	assert.Equal(t, "FREQ=DAILY", preview.Rule)
+}
+
+func TestListTasks_CursorPagination(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+
+	for _, title := range []string{"A", "B", "C", "D", "E"} {
+		_, err := service.CreateTask(ctx, "user1", TaskFields{Title: title})
+		assert.NoError(t, err)
+	}
+
+	for _, sort := range []string{"created_at", "-updated_at", "title", "-title"} {
+		t.Run(sort, func(t *testing.T) {
+			var seen []string
+			query := TaskQuery{Owner: "user1", Sort: sort, Limit: 2}
+			for {
+				page, err := service.ListTasks(ctx, query)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(5), page.Total)
+				for _, task := range page.Tasks {
+					seen = append(seen, task.Title)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				query.After = page.NextCursor
+			}
+			assert.Len(t, seen, 5)
+			assert.ElementsMatch(t, []string{"A", "B", "C", "D", "E"}, seen)
+		})
+	}
+
+	// Caso: una tarea insertada a mitad de la paginación no repite ni salta filas
+	first, err := service.ListTasks(ctx, TaskQuery{Owner: "user1", Sort: "title", Limit: 2})
+	assert.NoError(t, err)
+	_, _ = service.CreateTask(ctx, "user1", TaskFields{Title: "AA"})
+	second, err := service.ListTasks(ctx, TaskQuery{Owner: "user1", Sort: "title", Limit: 2, After: first.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, "C", second.Tasks[0].Title)
+
+	// Caso: el cursor pertenece a otro orden
+	_, err = service.ListTasks(ctx, TaskQuery{Owner: "user1", Sort: "-title", After: first.NextCursor})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
 }
 
 func TestGetTaskByID(t *testing.T) {
@@ -63,7 +668,7 @@ func TestGetTaskByID(t *testing.T) {
 	service := NewTaskService(db, logrus.New())
 	ctx := context.Background()
 
-	task, _ := service.CreateTask(ctx, "Task", "user1")
+	task, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Task"})
 
 	got, err := service.GetTaskByID(ctx, int(task.ID), "user1")
 	assert.NoError(t, err)
@@ -81,17 +686,87 @@ func TestUpdateTask(t *testing.T) {
 	service := NewTaskService(db, logrus.New())
 	ctx := context.Background()
 
-	task, _ := service.CreateTask(ctx, "Task", "user1")
+	task, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Task"})
 
-	updated, err := service.UpdateTask(ctx, int(task.ID), "Updated", true, "user1")
+	updated, err := service.UpdateTask(ctx, int(task.ID), "user1", TaskFields{Title: "Updated", Completed: true}, 0)
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated", updated.Title)
 	assert.True(t, updated.Completed)
 
-	_, err = service.UpdateTask(ctx, 999, "Nope", false, "user1")
+	_, err = service.UpdateTask(ctx, 999, "user1", TaskFields{Title: "Nope", Completed: false}, 0)
 	assert.ErrorIs(t, err, ErrTaskNotFound)
 
-	_, err = service.UpdateTask(ctx, int(task.ID), "Nope", false, "otro")
+	_, err = service.UpdateTask(ctx, int(task.ID), "otro", TaskFields{Title: "Nope", Completed: false}, 0)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestPatchTask(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+
+	task, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Task"})
+
+	// Caso: solo se escribe completed, el título enviado se ignora
+	patched, err := service.PatchTask(ctx, int(task.ID), "user1", TaskFields{Title: "Ignorado", Completed: true}, []string{FieldCompleted}, 0)
+	assert.NoError(t, err)
+	assert.True(t, patched.Completed)
+	assert.Equal(t, "Task", patched.Title)
+
+	// Caso: cambiar el título no toca completed
+	patched, err = service.PatchTask(ctx, int(task.ID), "user1", TaskFields{Title: "Renamed"}, []string{FieldTitle}, 0)
+	assert.NoError(t, err)
+	stored, _ := service.GetTaskByID(ctx, int(task.ID), "user1")
+	assert.Equal(t, "Renamed", stored.Title)
+	assert.True(t, stored.Completed)
+	assert.Equal(t, stored.UpdatedAt.Unix(), patched.UpdatedAt.Unix())
+
+	_, err = service.PatchTask(ctx, int(task.ID), "user1", TaskFields{}, []string{FieldTitle}, 0)
+	assert.ErrorIs(t, err, ErrTitleRequired)
+
+	_, err = service.PatchTask(ctx, int(task.ID), "user1", TaskFields{}, []string{"owner"}, 0)
+	assert.ErrorIs(t, err, ErrUnknownField)
+
+	_, err = service.PatchTask(ctx, int(task.ID), "otro", TaskFields{Completed: false}, []string{FieldCompleted}, 0)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestTaskVersioning(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+
+	task, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Task"})
+	assert.Equal(t, uint(1), task.Version)
+
+	// Caso: cada escritura incrementa la versión
+	updated, err := service.UpdateTask(ctx, int(task.ID), "user1", TaskFields{Title: "Updated", Completed: false}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), updated.Version)
+
+	// Caso: un segundo cliente con la versión vieja no pisa el cambio
+	_, err = service.UpdateTask(ctx, int(task.ID), "user1", TaskFields{Title: "Stale", Completed: true}, 1)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	_, err = service.PatchTask(ctx, int(task.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 1)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	err = service.DeleteTask(ctx, int(task.ID), "user1", 1)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	stored, _ := service.GetTaskByID(ctx, int(task.ID), "user1")
+	assert.Equal(t, "Updated", stored.Title)
+	assert.False(t, stored.Completed)
+
+	patched, err := service.PatchTask(ctx, int(task.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), patched.Version)
+
+	// Caso: sin versión esperada la escritura no se condiciona pero igual incrementa
+	updated, err = service.UpdateTask(ctx, int(task.ID), "user1", TaskFields{Title: "Last", Completed: true}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), updated.Version)
+
+	assert.NoError(t, service.DeleteTask(ctx, int(task.ID), "user1", 4))
+	_, err = service.GetTaskByID(ctx, int(task.ID), "user1")
 	assert.ErrorIs(t, err, ErrTaskNotFound)
 }
 
@@ -100,18 +775,461 @@ func TestDeleteTask(t *testing.T) {
 	service := NewTaskService(db, logrus.New())
 	ctx := context.Background()
 
-	task, _ := service.CreateTask(ctx, "Task", "user1")
+	task, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Task"})
 
-	err := service.DeleteTask(ctx, int(task.ID), "user1")
+	err := service.DeleteTask(ctx, int(task.ID), "user1", 0)
 	assert.NoError(t, err)
 
-	err = service.DeleteTask(ctx, int(task.ID), "user1")
+	err = service.DeleteTask(ctx, int(task.ID), "user1", 0)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	err = service.DeleteTask(ctx, 999, "user1", 0)
 	assert.ErrorIs(t, err, ErrTaskNotFound)
 
-	err = service.DeleteTask(ctx, 999, "user1")
+	task2, _ := service.CreateTask(ctx, "user2", TaskFields{Title: "Task2"})
+	err = service.DeleteTask(ctx, int(task2.ID), "user1", 0)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestTaskTrash(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+
+	parent, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Mudanza", Tags: []string{"casa"}})
+	child, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Cajas", ParentID: &parent.ID})
+	grandchild, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Cinta", ParentID: &child.ID})
+	other, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Otra"})
+	_, _ = service.AddBlocker(ctx, int(other.ID), "user1", parent.ID)
+
+	assert.NoError(t, service.DeleteTask(ctx, int(parent.ID), "user1", 0))
+
+	trash, err := service.ListTrash(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Len(t, trash, 3)
+	assert.True(t, trash[0].DeletedAt.Valid)
+	// Caso: mandar a la papelera sube la versión de la tarea y de sus subtareas
+	for _, trashed := range trash {
+		assert.Equal(t, uint(2), trashed.Version)
+	}
+	empty, _ := service.ListTrash(ctx, "user2")
+	assert.Empty(t, empty)
+
+	// Caso: una subtarea no se restaura mientras su padre siga en la papelera
+	_, err = service.RestoreTask(ctx, int(child.ID), "user1")
+	assert.ErrorIs(t, err, ErrParentTrashed)
+	_, err = service.RestoreTask(ctx, int(other.ID), "user1")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+	_, err = service.RestoreTask(ctx, int(parent.ID), "user2")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	// Caso: restaurar devuelve las subtareas, las etiquetas y los bloqueos
+	restored, err := service.RestoreTask(ctx, int(parent.ID), "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), restored.Version)
+	assert.Len(t, restored.Tags, 1)
+	_, err = service.GetTaskByID(ctx, int(grandchild.ID), "user1")
+	assert.NoError(t, err)
+	blocked, _ := service.GetTaskByID(ctx, int(other.ID), "user1")
+	assert.True(t, blocked.Blocked)
+
+	// Caso: borrar definitivamente quita la tarea, sus subtareas y sus referencias
+	err = service.PurgeTask(ctx, int(parent.ID), "user1", 2)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.NoError(t, service.PurgeTask(ctx, int(parent.ID), "user1", 0))
+	var remaining, dependencies int64
+	db.Unscoped().Model(&models.Task{}).Where("id IN ?", []uint{parent.ID, child.ID, grandchild.ID}).Count(&remaining)
+	db.Model(&models.TaskDependency{}).Count(&dependencies)
+	assert.Zero(t, remaining)
+	assert.Zero(t, dependencies)
+	assert.ErrorIs(t, service.PurgeTask(ctx, int(parent.ID), "user1", 0), ErrTaskNotFound)
+
+	// Caso: PurgeTrash solo borra lo eliminado antes del corte
+	assert.NoError(t, service.DeleteTask(ctx, int(other.ID), "user1", 0))
+	purged, err := service.PurgeTrash(ctx, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Zero(t, purged)
+	purged, err = service.PurgeTrash(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	trash, _ = service.ListTrash(ctx, "user1")
+	assert.Empty(t, trash)
+}
+
+func TestTaskHistory(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New(), WithParentAutoComplete())
+	ctx := WithAudit(context.Background(), Audit{RequestID: "req-1"})
+
+	parent, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Mudanza"})
+	child, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Cajas", ParentID: &parent.ID})
+	_, err := service.PatchTask(ctx, int(child.ID), "user1", TaskFields{Title: "Cajas grandes", Tags: []string{"casa"}}, []string{FieldTitle, FieldTags}, 0)
+	assert.NoError(t, err)
+
+	// Caso: completar la subtarea completa al padre y ambos cambios quedan registrados
+	adminCtx := WithAudit(context.Background(), Audit{Actor: "admin", RequestID: "req-2"})
+	_, err = service.PatchTask(adminCtx, int(child.ID), "user1", TaskFields{Completed: true}, []string{FieldCompleted}, 0)
+	assert.NoError(t, err)
+
+	page, err := service.ListTaskHistory(ctx, int(child.ID), "user1", 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, page.Events, 4)
+	completed := page.Events[0]
+	assert.Equal(t, models.TaskEventUpdated, completed.Action)
+	assert.Equal(t, FieldCompleted, completed.Field)
+	assert.Equal(t, "false", completed.OldValue)
+	assert.Equal(t, "true", completed.NewValue)
+	assert.Equal(t, "admin", completed.Actor)
+	assert.Equal(t, "req-2", completed.RequestID)
+	assert.Equal(t, uint(3), completed.Version)
+	assert.Equal(t, FieldTags, page.Events[1].Field)
+	assert.Equal(t, `["casa"]`, page.Events[1].NewValue)
+	assert.Equal(t, FieldTitle, page.Events[2].Field)
+	assert.Equal(t, `"Cajas"`, page.Events[2].OldValue)
+	created := page.Events[3]
+	assert.Equal(t, models.TaskEventCreated, created.Action)
+	assert.Equal(t, "user1", created.Actor)
+	assert.Equal(t, uint(1), created.Version)
+
+	parentPage, _ := service.ListTaskHistory(ctx, int(parent.ID), "user1", 0, "")
+	assert.Len(t, parentPage.Events, 2)
+	assert.Equal(t, FieldCompleted, parentPage.Events[0].Field)
+
+	// Caso: eliminar y restaurar se registran en la tarea y sus subtareas
+	assert.NoError(t, service.DeleteTask(ctx, int(parent.ID), "user1", 0))
+	_, err = service.RestoreTask(ctx, int(parent.ID), "user1")
+	assert.NoError(t, err)
+	page, _ = service.ListTaskHistory(ctx, int(child.ID), "user1", 2, "")
+	assert.Equal(t, models.TaskEventRestored, page.Events[0].Action)
+	assert.Equal(t, models.TaskEventDeleted, page.Events[1].Action)
+	assert.NotEmpty(t, page.NextCursor)
+	next, err := service.ListTaskHistory(ctx, int(child.ID), "user1", 2, page.NextCursor)
+	assert.NoError(t, err)
+	assert.Len(t, next.Events, 2)
+	assert.Equal(t, FieldCompleted, next.Events[0].Field)
+
+	_, err = service.ListTaskHistory(ctx, int(child.ID), "user2", 0, "")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+	_, err = service.ListTaskHistory(ctx, int(child.ID), "user1", 0, "nope")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	// Caso: borrar definitivamente conserva el historial y lo registra
+	var before int64
+	db.Model(&models.TaskEvent{}).Count(&before)
+	assert.NoError(t, service.PurgeTask(ctx, int(parent.ID), "user1", 0))
+	var purged []*models.TaskEvent
+	db.Where("action = ?", models.TaskEventPurged).Order("task_id").Find(&purged)
+	if assert.Len(t, purged, 2) {
+		assert.Equal(t, parent.ID, purged[0].TaskID)
+		assert.Equal(t, "user1", purged[0].Actor)
+	}
+	var after int64
+	db.Model(&models.TaskEvent{}).Count(&after)
+	assert.Equal(t, before+2, after)
+
+	// Caso: la purga automática queda a nombre del sistema
+	old, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Vieja"})
+	assert.NoError(t, service.DeleteTask(ctx, int(old.ID), "user1", 0))
+	_, err = service.PurgeTrash(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	var event models.TaskEvent
+	assert.NoError(t, db.Where("task_id = ? AND action = ?", old.ID, models.TaskEventPurged).First(&event).Error)
+	assert.Equal(t, SystemActor, event.Actor)
+}
+
+func TestRevertTask(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	projects := NewProjectService(db, logrus.New())
+	ctx := context.Background()
+	dueAt := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+
+	project, _ := projects.CreateProject(ctx, "user1", ProjectFields{Name: "Casa"})
+	task, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Pintar", ProjectID: &project.ID})
+	_, _ = service.PatchTask(ctx, int(task.ID), "user1", TaskFields{Title: "Pintar la sala", DueAt: &dueAt}, []string{FieldTitle, FieldDueAt}, 0)
+	_, _ = service.PatchTask(ctx, int(task.ID), "user1", TaskFields{Priority: models.TaskPriorityHigh, Tags: []string{"obra"}}, []string{FieldPriority, FieldTags}, 0)
+
+	// Caso: volver a la versión 1 deshace los dos cambios y crea la versión 4
+	reverted, err := service.RevertTask(ctx, int(task.ID), "user1", 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), reverted.Version)
+	assert.Equal(t, "Pintar", reverted.Title)
+	assert.Nil(t, reverted.DueAt)
+	assert.Equal(t, models.TaskPriorityMedium, reverted.Priority)
+	assert.Empty(t, reverted.Tags)
+
+	history, _ := service.ListTaskHistory(ctx, int(task.ID), "user1", 0, "")
+	var fields []string
+	for _, event := range history.Events {
+		if event.Version == 4 && event.Field != "" {
+			fields = append(fields, event.Field)
+		}
+	}
+	assert.ElementsMatch(t, []string{FieldTitle, FieldDueAt, FieldPriority, FieldTags}, fields)
+	last := history.Events[len(history.Events)-1]
+	assert.Equal(t, models.TaskEventCreated, last.Action)
+	var revertEvent *models.TaskEvent
+	for _, event := range history.Events {
+		if event.Action == models.TaskEventReverted {
+			revertEvent = event
+		}
+	}
+	assert.NotNil(t, revertEvent)
+	assert.Equal(t, "1", revertEvent.NewValue)
+
+	// Caso: una reversión también se puede revertir
+	again, err := service.RevertTask(ctx, int(task.ID), "user1", 3, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "Pintar la sala", again.Title)
+	assert.Equal(t, models.TaskPriorityHigh, again.Priority)
+	assert.Len(t, again.Tags, 1)
+	assert.Equal(t, dueAt, again.DueAt.UTC())
+
+	testScenarios := []struct {
+		testName        string
+		version         uint
+		expectedVersion uint
+		expectedErr     error
+	}{
+		{testName: "Edición concurrente", version: 1, expectedVersion: 4, expectedErr: ErrVersionConflict},
+		{testName: "Versión actual", version: 5, expectedErr: ErrRevisionNotFound},
+		{testName: "Versión futura", version: 9, expectedErr: ErrRevisionNotFound},
+		{testName: "Versión cero", version: 0, expectedErr: ErrRevisionNotFound},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			_, err := service.RevertTask(ctx, int(task.ID), "user1", tt.version, tt.expectedVersion)
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+
+	_, err = service.RevertTask(ctx, int(task.ID), "user2", 1, 0)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	// Caso: la versión vieja apunta a un proyecto que ya no existe
+	_, _ = service.PatchTask(ctx, int(task.ID), "user1", TaskFields{}, []string{FieldProjectID}, 0)
+	assert.NoError(t, projects.DeleteProject(ctx, int(project.ID), "user1", ProjectTasksMove))
+	_, err = service.RevertTask(ctx, int(task.ID), "user1", 5, 0)
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+
+	// Caso: las tareas anteriores al historial no se pueden reconstruir
+	legacy := &models.Task{Title: "Vieja", Owner: "user1", Version: 3}
+	db.Create(legacy)
+	_, err = service.RevertTask(ctx, int(legacy.ID), "user1", 1, 0)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+}
+
+func TestBulkTasks(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+
+	first, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Primera"})
+	second, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Segunda"})
+	foreign, _ := service.CreateTask(ctx, "user2", TaskFields{Title: "Ajena"})
+
+	_, err := service.BulkTasks(ctx, "user1", "later", []BulkOperation{{Op: BulkOpDelete, ID: int(first.ID)}})
+	assert.ErrorIs(t, err, ErrInvalidBulkMode)
+	_, err = service.BulkTasks(ctx, "user1", "", nil)
+	assert.ErrorIs(t, err, ErrInvalidBulkSize)
+
+	// Caso: en modo atómico un fallo deshace todo el lote
+	results, err := service.BulkTasks(ctx, "user1", BulkModeAtomic, []BulkOperation{
+		{Op: BulkOpCreate, Fields: TaskFields{Title: "Nueva"}},
+		{Op: BulkOpComplete, ID: int(first.ID)},
+		{Op: BulkOpDelete, ID: int(foreign.ID)},
+	})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, results[0].Err, ErrBulkAborted)
+	assert.ErrorIs(t, results[1].Err, ErrBulkAborted)
+	assert.ErrorIs(t, results[2].Err, ErrTaskNotFound)
+	var total int64
+	db.Model(&models.Task{}).Where("owner = ?", "user1").Count(&total)
+	assert.Equal(t, int64(2), total)
+	unchanged, _ := service.GetTaskByID(ctx, int(first.ID), "user1")
+	assert.False(t, unchanged.Completed)
+
+	// Caso: best_effort aplica lo que puede y reporta el resto
+	results, err = service.BulkTasks(ctx, "user1", BulkModeBestEffort, []BulkOperation{
+		{Op: BulkOpCreate, Fields: TaskFields{Title: "Nueva"}},
+		{Op: BulkOpComplete, ID: int(first.ID)},
+		{Op: BulkOpUpdate, ID: int(second.ID), Fields: TaskFields{Title: "Segunda editada"}, ExpectedVersion: 7},
+		{Op: BulkOpCreate, Fields: TaskFields{Title: ""}},
+		{Op: "archive", ID: int(second.ID)},
+		{Op: BulkOpDelete, ID: int(second.ID)},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "Nueva", results[0].Task.Title)
+	assert.NoError(t, results[1].Err)
+	assert.True(t, results[1].Task.Completed)
+	assert.ErrorIs(t, results[2].Err, ErrVersionConflict)
+	assert.ErrorIs(t, results[3].Err, ErrTitleRequired)
+	assert.ErrorIs(t, results[4].Err, ErrInvalidBulkOperation)
+	assert.NoError(t, results[5].Err)
+	assert.Nil(t, results[5].Task)
+
+	page, _ := service.ListTasks(ctx, TaskQuery{Owner: "user1"})
+	assert.Equal(t, int64(2), page.Total)
+	_, err = service.GetTaskByID(ctx, int(second.ID), "user1")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	testScenarios := []struct {
+		testName    string
+		query       string
+		expected    []searchTerm
+		expectedErr error
+	}{
+		{testName: "Palabras", query: "comprar  leche", expected: []searchTerm{{Text: "comprar"}, {Text: "leche"}}},
+		{testName: "Prefijo", query: "super*", expected: []searchTerm{{Text: "super", Prefix: true}}},
+		{testName: "Frase", query: `"billetes de  tren" viaje`, expected: []searchTerm{{Text: "billetes de tren"}, {Text: "viaje"}}},
+		{testName: "Frase con prefijo", query: `"billetes de tr"*`, expected: []searchTerm{{Text: "billetes de tr", Prefix: true}}},
+		{testName: "Comilla sin cerrar", query: `"billetes de`, expected: []searchTerm{{Text: "billetes de"}}},
+		{testName: "Operadores de FTS5 como texto", query: "title:leche OR NEAR(", expected: []searchTerm{{Text: "title:leche"}, {Text: "OR"}, {Text: "NEAR("}}},
+		{testName: "Sin términos", query: `"" * -`, expectedErr: ErrInvalidSearchQuery},
+		{testName: "Demasiados términos", query: strings.Repeat("a ", MaxSearchTerms+1), expectedErr: ErrInvalidSearchQuery},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			terms, err := parseSearchQuery(tt.query)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, terms)
+		})
+	}
+}
+
+func TestSearchTasks(t *testing.T) {
+	testScenarios := []struct {
+		testName string
+		fullText bool
+	}{
+		{testName: "LIKE", fullText: false},
+		{testName: "FTS5", fullText: true},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			db := setupTestDB(t)
+			ctx := context.Background()
+
+			// la tarea creada antes del índice también tiene que encontrarse
+			milk, _ := NewTaskService(db, logrus.New()).CreateTask(ctx, "user1", TaskFields{Title: "Comprar leche", Description: "Pasar por el supermercado antes de las 8"})
+			var opts []Option
+			if tt.fullText {
+				enabled, err := SetupTaskSearch(db)
+				assert.NoError(t, err)
+				if !enabled {
+					t.Skip("SQLite compilado sin FTS5 (build tag sqlite_fts5)")
+				}
+				_, err = SetupTaskSearch(db)
+				assert.NoError(t, err)
+				opts = append(opts, WithFullTextSearch())
+			}
+			service := NewTaskService(db, logrus.New(), opts...)
+			trip, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Planificar viaje", Description: "Comprar billetes de tren para el viaje de vacaciones"})
+			_, _ = service.CreateTask(ctx, "user1", TaskFields{Title: "<b>Importante</b>"})
+			_, _ = service.CreateTask(ctx, "user2", TaskFields{Title: "Comprar pan"})
+
+			// Caso: las coincidencias en el título pesan más que en la descripción
+			results, err := service.SearchTasks(ctx, "user1", "comprar", 0)
+			assert.NoError(t, err)
+			if assert.Len(t, results, 2) {
+				assert.Equal(t, milk.ID, results[0].Task.ID)
+				assert.Equal(t, "<mark>Comprar</mark> leche", results[0].TitleHighlight)
+				assert.Equal(t, trip.ID, results[1].Task.ID)
+				assert.Contains(t, results[1].Snippet, "<mark>Comprar</mark> billetes")
+				assert.Greater(t, results[0].Score, results[1].Score)
+			}
+
+			// Caso: prefijo y frase
+			results, _ = service.SearchTasks(ctx, "user1", "super*", 0)
+			if assert.Len(t, results, 1) {
+				assert.Contains(t, results[0].Snippet, "<mark>supermercado</mark>")
+			}
+			results, _ = service.SearchTasks(ctx, "user1", `"billetes de tren"`, 0)
+			assert.Len(t, results, 1)
+			results, _ = service.SearchTasks(ctx, "user1", `"tren de billetes"`, 0)
+			assert.Empty(t, results)
+
+			// Caso: el contenido de la tarea se escapa
+			results, _ = service.SearchTasks(ctx, "user1", "importante", 0)
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, "&lt;b&gt;<mark>Importante</mark>&lt;/b&gt;", results[0].TitleHighlight)
+			}
+
+			// Caso: solo las tareas del usuario y fuera de la papelera
+			results, _ = service.SearchTasks(ctx, "user2", "comprar", 0)
+			assert.Len(t, results, 1)
+			assert.NoError(t, service.DeleteTask(ctx, int(trip.ID), "user1", 0))
+			results, _ = service.SearchTasks(ctx, "user1", "billetes", 0)
+			assert.Empty(t, results)
+
+			// Caso: el índice sigue los cambios de la tarea
+			_, err = service.PatchTask(ctx, int(milk.ID), "user1", TaskFields{Title: "Comprar huevos"}, []string{FieldTitle}, 0)
+			assert.NoError(t, err)
+			results, _ = service.SearchTasks(ctx, "user1", "leche", 0)
+			assert.Empty(t, results)
+			results, _ = service.SearchTasks(ctx, "user1", "huevos", 0)
+			assert.Len(t, results, 1)
+
+			// Caso: con límite gana la coincidencia en el título aunque haya otras más recientes
+			for i := 0; i < 6; i++ {
+				_, _ = service.CreateTask(ctx, "user1", TaskFields{Title: "Recado", Description: "Comprar algo"})
+			}
+			results, _ = service.SearchTasks(ctx, "user1", "comprar", 1)
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, milk.ID, results[0].Task.ID)
+			}
+			_, err = service.SearchTasks(ctx, "user1", `""`, 0)
+			assert.ErrorIs(t, err, ErrInvalidSearchQuery)
+		})
+	}
+}
+
+func TestAdminTaskVariants(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewTaskService(db, logrus.New())
+	ctx := context.Background()
+
+	task1, _ := service.CreateTask(ctx, "user1", TaskFields{Title: "Task 1"})
+	_, _ = service.CreateTask(ctx, "user2", TaskFields{Title: "Task 2"})
+	_, _ = service.CreateTask(ctx, "user3", TaskFields{Title: "Task 3"})
+
+	page, err := service.GetAllTasks(ctx, TaskQuery{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	if assert.Len(t, page.Tasks, 2) && assert.NotEmpty(t, page.NextCursor) {
+		assert.Equal(t, "user1", page.Tasks[0].Owner)
+		next, err := service.GetAllTasks(ctx, TaskQuery{Limit: 2, After: page.NextCursor})
+		assert.NoError(t, err)
+		if assert.Len(t, next.Tasks, 1) {
+			assert.Equal(t, "user3", next.Tasks[0].Owner)
+		}
+		assert.Empty(t, next.NextCursor)
+	}
+	_, err = service.GetAllTasks(ctx, TaskQuery{After: "nope"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	got, err := service.GetTaskByIDAsAdmin(ctx, int(task1.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", got.Owner)
+
+	_, err = service.GetTaskByIDAsAdmin(ctx, 999)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	err = service.DeleteTaskAsAdmin(ctx, int(task1.ID))
+	assert.NoError(t, err)
+	_, err = service.GetTaskByID(ctx, int(task1.ID), "user1")
 	assert.ErrorIs(t, err, ErrTaskNotFound)
 
-	task2, _ := service.CreateTask(ctx, "Task2", "user2")
-	err = service.DeleteTask(ctx, int(task2.ID), "user1")
+	err = service.DeleteTaskAsAdmin(ctx, int(task1.ID))
 	assert.ErrorIs(t, err, ErrTaskNotFound)
 }
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"prueba_tecnica_go_guarapo/api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxTaskDepth es la cantidad máxima de niveles de una jerarquía, contando la tarea raíz.
+const MaxTaskDepth = 5
+
+// Option configura comportamientos opcionales de TaskService.
+type Option func(*taskService)
+
+// WithParentAutoComplete completa la tarea padre cuando todas sus subtareas quedan completadas.
+func WithParentAutoComplete() Option {
+	return func(s *taskService) {
+		s.autoCompleteParents = true
+	}
+}
+
+// validateParent comprueba que task (nil si es nueva) pueda colgar de parentID: el padre debe ser del
+// mismo dueño, no puede ser la tarea ni una de sus subtareas y la jerarquía no puede pasar de MaxTaskDepth.
+func validateParent(db *gorm.DB, task *models.Task, parentID *uint, username string) error {
+	if parentID == nil {
+		return nil
+	}
+	depth := 0
+	for current := parentID; current != nil; {
+		if task != nil && *current == task.ID {
+			return ErrTaskCycle
+		}
+		var ancestor models.Task
+		if err := db.Select("id", "parent_id").Where("id = ? AND owner = ?", *current, username).First(&ancestor).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrParentNotFound
+			}
+			return err
+		}
+		depth++
+		if depth >= MaxTaskDepth {
+			return ErrMaxDepthExceeded
+		}
+		current = ancestor.ParentID
+	}
+
+	height := 1
+	if task != nil {
+		levels, err := descendantLevels(db, task.ID)
+		if err != nil {
+			return err
+		}
+		height += len(levels)
+	}
+	if depth+height > MaxTaskDepth {
+		return ErrMaxDepthExceeded
+	}
+	return nil
+}
+
+// descendantLevels devuelve los IDs de las subtareas agrupados por nivel (hijos, nietos...).
+func descendantLevels(db *gorm.DB, id uint) ([][]uint, error) {
+	var levels [][]uint
+	parents := []uint{id}
+	for len(parents) > 0 && len(levels) < MaxTaskDepth {
+		var children []uint
+		if err := db.Model(&models.Task{}).Where("parent_id IN ?", parents).Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+		if len(children) == 0 {
+			break
+		}
+		levels = append(levels, children)
+		parents = children
+	}
+	return levels, nil
+}
+
+// subtreeIDs devuelve id y los IDs de todas sus subtareas.
+func subtreeIDs(db *gorm.DB, id uint) ([]uint, error) {
+	levels, err := descendantLevels(db, id)
+	if err != nil {
+		return nil, err
+	}
+	ids := []uint{id}
+	for _, level := range levels {
+		ids = append(ids, level...)
+	}
+	return ids, nil
+}
+
+func deleteSubtasks(tx *gorm.DB, id uint) error {
+	levels, err := descendantLevels(tx, id)
+	if err != nil {
+		return err
+	}
+	for _, ids := range levels {
+		if err := tx.Model(&models.Task{}).Where("id IN ?", ids).Updates(trashUpdates(tx)).Error; err != nil {
+			return err
+		}
+		if err := recordTaskEvents(tx, ids, models.TaskEvent{Action: models.TaskEventDeleted}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// completeAncestors sube por la jerarquía completando cada padre cuyas subtareas estén todas completadas
+// y programando su siguiente ocurrencia si es recurrente; se detiene en un padre con bloqueos pendientes
+// salvo WithBlockedCompletion.
+func (s *taskService) completeAncestors(tx *gorm.DB, parentID *uint) error {
+	for current := parentID; current != nil; {
+		var pending int64
+		if err := tx.Model(&models.Task{}).Where("parent_id = ? AND completed = ?", *current, false).Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return nil
+		}
+		var parent models.Task
+		if err := withDetails(tx).First(&parent, *current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if parent.Completed {
+			return nil
+		}
+		if err := s.checkCompletable(tx, &parent, map[string]interface{}{FieldCompleted: true}); err != nil {
+			if errors.Is(err, ErrTaskBlocked) {
+				return nil
+			}
+			return err
+		}
+		dependents, err := snapshotDependents(tx, []uint{parent.ID})
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&parent).Updates(map[string]interface{}{
+			FieldCompleted: true,
+			"completed_at": time.Now().UTC(),
+			"version":      gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		if err := recordTaskEvents(tx, []uint{parent.ID}, models.TaskEvent{
+			Action:   models.TaskEventUpdated,
+			Field:    FieldCompleted,
+			OldValue: "false",
+			NewValue: "true",
+		}); err != nil {
+			return err
+		}
+		if err := dependents.sync(tx); err != nil {
+			return err
+		}
+		if err := scheduleNextOccurrence(tx, &parent); err != nil {
+			return err
+		}
+		current = parent.ParentID
+	}
+	return nil
+}
+
+func (s *taskService) SubtaskTree(ctx context.Context, username string, rootIDs []uint) (map[uint][]*models.Task, error) {
+	tree := make(map[uint][]*models.Task)
+	parents := rootIDs
+	for depth := 1; depth < MaxTaskDepth && len(parents) > 0; depth++ {
+		var children []*models.Task
+		if err := withDetails(s.db.WithContext(ctx)).
+			Where("parent_id IN ? AND owner = ?", parents, username).
+			Order("created_at, id").
+			Find(&children).Error; err != nil {
+			s.logger.Error("[Layer: task_service] [Method: SubtaskTree] Error: ", err)
+			return nil, err
+		}
+		parents = parents[:0:0]
+		for _, child := range children {
+			tree[*child.ParentID] = append(tree[*child.ParentID], child)
+			parents = append(parents, child.ID)
+		}
+	}
+	return tree, nil
+}
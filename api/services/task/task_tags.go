@@ -0,0 +1,109 @@
+package services
+
+import (
+	"prueba_tecnica_go_guarapo/api/models"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	MaxTagNameLength = 50
+	MaxTagsPerTask   = 20
+
+	TagModeAll = "all"
+	TagModeAny = "any"
+)
+
+// normalizeTagName recorta espacios y valida el largo del nombre.
+func normalizeTagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxTagNameLength {
+		return "", ErrInvalidTagName
+	}
+	return name, nil
+}
+
+// normalizeTagNames normaliza y quita duplicados conservando el orden.
+func normalizeTagNames(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, raw := range names {
+		name, err := normalizeTagName(raw)
+		if err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	if len(result) > MaxTagsPerTask {
+		return nil, ErrTooManyTags
+	}
+	return result, nil
+}
+
+// withTags precarga las etiquetas ordenadas por nombre.
+func withTags(db *gorm.DB) *gorm.DB {
+	return db.Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("tags.name")
+	})
+}
+
+// replaceTaskTags deja en la tarea exactamente las etiquetas indicadas, creando las que falten.
+func replaceTaskTags(tx *gorm.DB, task *models.Task, names []string) error {
+	names, err := normalizeTagNames(names)
+	if err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM task_tags WHERE task_id = ?", task.ID).Error; err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, models.Tag{Owner: task.Owner, Name: name})
+	}
+	// las existentes chocan con el índice único y se ignoran; después se leen todas por nombre
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return err
+	}
+	var ids []uint
+	if err := tx.Model(&models.Tag{}).Where("owner = ? AND name IN ?", task.Owner, names).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	rows := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		rows = append(rows, map[string]interface{}{"task_id": task.ID, "tag_id": id})
+	}
+	return tx.Table("task_tags").Create(rows).Error
+}
+
+// filterByTags limita el listado a las tareas con todas (all) o alguna (any) de las etiquetas.
+func filterByTags(db, filtered *gorm.DB, owner string, names []string, mode string) (*gorm.DB, error) {
+	if mode == "" {
+		mode = TagModeAll
+	}
+	if mode != TagModeAll && mode != TagModeAny {
+		return nil, ErrInvalidTagMode
+	}
+	names, err := normalizeTagNames(names)
+	if err != nil {
+		return nil, err
+	}
+	tagged := db.Table("task_tags").
+		Select("task_tags.task_id").
+		Joins("JOIN tags ON tags.id = task_tags.tag_id").
+		Where("tags.owner = ? AND tags.name IN ?", owner, names)
+	if mode == TagModeAll {
+		tagged = tagged.Group("task_tags.task_id").Having("COUNT(*) = ?", len(names))
+	}
+	return filtered.Where("tasks.id IN (?)", tagged), nil
+}
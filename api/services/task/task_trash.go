@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"prueba_tecnica_go_guarapo/api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultTrashRetention es cuánto tiempo queda una tarea en la papelera antes de purgarse.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+func (s *taskService) ListTrash(ctx context.Context, username string) ([]*models.Task, error) {
+	var tasks []*models.Task
+	if err := withTags(s.db.WithContext(ctx).Unscoped()).
+		Where("owner = ? AND deleted_at IS NOT NULL", username).
+		Order("deleted_at DESC, id DESC").
+		Find(&tasks).Error; err != nil {
+		s.logger.Error("[Layer: task_service] [Method: ListTrash] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: ListTrash] Info: User '%s' has %d trashed tasks", username, len(tasks))
+	return tasks, nil
+}
+
+func (s *taskService) RestoreTask(ctx context.Context, id int, username string) (*models.Task, error) {
+	var task models.Task
+	if err := s.db.WithContext(ctx).Unscoped().Where("id = ? AND owner = ? AND deleted_at IS NOT NULL", id, username).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Warnf("[Layer: task_service] [Method: RestoreTask] Warning: Task '%d' not in trash of user '%s'", id, username)
+			return nil, ErrTaskNotFound
+		}
+		s.logger.Error("[Layer: task_service] [Method: RestoreTask] Error: ", err)
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}
+		if task.ParentID != nil {
+			var parent models.Task
+			err := tx.Unscoped().Select("id", "deleted_at").First(&parent, *task.ParentID).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				// el padre ya se purgó: la tarea vuelve como raíz
+				updates[FieldParentID] = nil
+			case err != nil:
+				return err
+			case parent.DeletedAt.Valid:
+				return ErrParentTrashed
+			}
+		}
+		// si el proyecto se eliminó mientras tanto, la tarea vuelve a la bandeja de entrada
+		if task.ProjectID != nil {
+			if _, err := findProject(tx, *task.ProjectID, username); errors.Is(err, ErrProjectNotFound) {
+				updates[FieldProjectID] = nil
+			} else if err != nil {
+				return err
+			}
+		}
+		// las tareas restauradas vuelven a bloquear a las que dependen de ellas
+		candidates, err := subtreeIDs(tx.Unscoped().Session(&gorm.Session{}), task.ID)
+		if err != nil {
+			return err
+		}
+		dependents, err := snapshotDependents(tx, candidates)
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&task).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := recordTaskEvents(tx, []uint{task.ID}, models.TaskEvent{Action: models.TaskEventRestored}); err != nil {
+			return err
+		}
+		// las subtareas que se eliminaron junto con la tarea (o después) vuelven con ella
+		levels, err := descendantLevels(tx.Unscoped().Session(&gorm.Session{}), task.ID)
+		if err != nil {
+			return err
+		}
+		for _, ids := range levels {
+			var restored []uint
+			if err := tx.Unscoped().Model(&models.Task{}).
+				Where("id IN ? AND deleted_at >= ?", ids, task.DeletedAt.Time).
+				Pluck("id", &restored).Error; err != nil {
+				return err
+			}
+			if len(restored) == 0 {
+				continue
+			}
+			if err := tx.Unscoped().Model(&models.Task{}).Where("id IN ?", restored).
+				Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error; err != nil {
+				return err
+			}
+			if err := recordTaskEvents(tx, restored, models.TaskEvent{Action: models.TaskEventRestored}); err != nil {
+				return err
+			}
+		}
+		if err := dependents.sync(tx); err != nil {
+			return err
+		}
+		return withDetails(tx).First(&task, task.ID).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrParentTrashed) {
+			s.logger.Warnf("[Layer: task_service] [Method: RestoreTask] Warning: Parent of task '%d' is in the trash", id)
+			return nil, err
+		}
+		s.logger.Error("[Layer: task_service] [Method: RestoreTask] Error: ", err)
+		return nil, err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: RestoreTask] Info: Task '%d' restored for user '%s'", id, username)
+	return &task, nil
+}
+
+func (s *taskService) PurgeTask(ctx context.Context, id int, username string, expectedVersion uint) error {
+	// la lectura y la comprobación de versión van en la misma transacción que el borrado
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var task models.Task
+		if err := tx.Unscoped().Where("id = ? AND owner = ?", id, username).First(&task).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTaskNotFound
+			}
+			return err
+		}
+		if expectedVersion != 0 && task.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+		ids, err := subtreeIDs(tx.Unscoped().Session(&gorm.Session{}), task.ID)
+		if err != nil {
+			return err
+		}
+		return purgeTasks(tx, ids)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTaskNotFound):
+			s.logger.Warnf("[Layer: task_service] [Method: PurgeTask] Warning: Task '%d' not found or not owned by user '%s'", id, username)
+		case errors.Is(err, ErrVersionConflict):
+			s.logger.Warnf("[Layer: task_service] [Method: PurgeTask] Warning: Task '%d' is no longer at version %d", id, expectedVersion)
+		default:
+			s.logger.Error("[Layer: task_service] [Method: PurgeTask] Error: ", err)
+		}
+		return err
+	}
+	s.logger.Infof("[Layer: task_service] [Method: PurgeTask] Info: Task '%d' permanently deleted for user '%s'", id, username)
+	return nil
+}
+
+func (s *taskService) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	if auditFrom(ctx).Actor == "" {
+		ctx = WithAudit(ctx, Audit{Actor: SystemActor})
+	}
+	var purged int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Unscoped().Model(&models.Task{}).Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		purged = int64(len(ids))
+		if len(ids) == 0 {
+			return nil
+		}
+		return purgeTasks(tx, ids)
+	})
+	if err != nil {
+		s.logger.Error("[Layer: task_service] [Method: PurgeTrash] Error: ", err)
+		return 0, err
+	}
+	return purged, nil
+}
+
+// trashUpdates manda una tarea a la papelera; como cualquier otra escritura, sube su versión.
+func trashUpdates(tx *gorm.DB) map[string]interface{} {
+	return map[string]interface{}{
+		"deleted_at": tx.NowFunc(),
+		"version":    gorm.Expr("version + 1"),
+	}
+}
+
+// purgeTasks borra de verdad las tareas y lo que las referencia: etiquetas, dependencias y enlaces de
+// recurrencia. El historial se conserva y el borrado queda en él como un evento purged.
+func purgeTasks(tx *gorm.DB, ids []uint) error {
+	dependents, err := snapshotDependents(tx, ids)
+	if err != nil {
+		return err
+	}
+	if err := recordTaskEvents(tx, ids, models.TaskEvent{Action: models.TaskEventPurged}); err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM task_tags WHERE task_id IN ?", ids).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("task_id IN ? OR blocker_id IN ?", ids, ids).Delete(&models.TaskDependency{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.Task{}).Where("next_occurrence_id IN ?", ids).Update("next_occurrence_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Task{}).Error; err != nil {
+		return err
+	}
+	return dependents.sync(tx)
+}
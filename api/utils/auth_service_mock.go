@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"prueba_tecnica_go_guarapo/api/models"
+	services "prueba_tecnica_go_guarapo/api/services/auth"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type mockAuthService struct {
+	mock.Mock
+}
+
+func (m *mockAuthService) Register(ctx context.Context, username, password string) (*models.User, error) {
+	args := m.Called(ctx, username, password)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *mockAuthService) VerifyCredentials(ctx context.Context, username, password string) (*models.User, error) {
+	args := m.Called(ctx, username, password)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *mockAuthService) Login(ctx context.Context, username, password string, client services.ClientInfo) (*services.LoginResult, error) {
+	args := m.Called(ctx, username, password, client)
+	return args.Get(0).(*services.LoginResult), args.Error(1)
+}
+func (m *mockAuthService) CompleteTwoFactorLogin(ctx context.Context, challengeToken, code string, client services.ClientInfo) (*services.TokenPair, error) {
+	args := m.Called(ctx, challengeToken, code, client)
+	return args.Get(0).(*services.TokenPair), args.Error(1)
+}
+func (m *mockAuthService) SetupTOTP(ctx context.Context, username string) (*services.TOTPSetup, error) {
+	args := m.Called(ctx, username)
+	return args.Get(0).(*services.TOTPSetup), args.Error(1)
+}
+func (m *mockAuthService) EnableTOTP(ctx context.Context, username, code string) ([]string, error) {
+	args := m.Called(ctx, username, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+func (m *mockAuthService) LoginExternal(ctx context.Context, identity services.ExternalIdentity, client services.ClientInfo) (*services.LoginResult, error) {
+	args := m.Called(ctx, identity, client)
+	return args.Get(0).(*services.LoginResult), args.Error(1)
+}
+func (m *mockAuthService) LinkExternalIdentity(ctx context.Context, username string, identity services.ExternalIdentity) error {
+	args := m.Called(ctx, username, identity)
+	return args.Error(0)
+}
+func (m *mockAuthService) Refresh(ctx context.Context, refreshToken string, client services.ClientInfo) (*services.TokenPair, error) {
+	args := m.Called(ctx, refreshToken, client)
+	return args.Get(0).(*services.TokenPair), args.Error(1)
+}
+func (m *mockAuthService) ValidateToken(ctx context.Context, token string) (*services.TokenClaims, error) {
+	args := m.Called(ctx, token)
+	return args.Get(0).(*services.TokenClaims), args.Error(1)
+}
+func (m *mockAuthService) Logout(ctx context.Context, claims *services.TokenClaims) error {
+	args := m.Called(ctx, claims)
+	return args.Error(0)
+}
+func (m *mockAuthService) LogoutAll(ctx context.Context, username string) error {
+	args := m.Called(ctx, username)
+	return args.Error(0)
+}
+func (m *mockAuthService) ListSessions(ctx context.Context, username string) ([]*models.Session, error) {
+	args := m.Called(ctx, username)
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+func (m *mockAuthService) RevokeSession(ctx context.Context, username, sessionID string) error {
+	args := m.Called(ctx, username, sessionID)
+	return args.Error(0)
+}
+func (m *mockAuthService) TouchSession(ctx context.Context, sessionID string, client services.ClientInfo) error {
+	args := m.Called(ctx, sessionID, client)
+	return args.Error(0)
+}
+func (m *mockAuthService) ListUsers(ctx context.Context) ([]*models.User, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+func (m *mockAuthService) SetUserDisabled(ctx context.Context, username string, disabled bool) (*models.User, error) {
+	args := m.Called(ctx, username, disabled)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+func (m *mockAuthService) SetUserRole(ctx context.Context, username, role string) (*models.User, error) {
+	args := m.Called(ctx, username, role)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *mockAuthService) UnlockUser(ctx context.Context, username string) error {
+	args := m.Called(ctx, username)
+	return args.Error(0)
+}
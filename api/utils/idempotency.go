@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"prueba_tecnica_go_guarapo/api/models"
+	idempotencyServices "prueba_tecnica_go_guarapo/api/services/idempotency"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodyBytes limita el cuerpo que se lee en memoria para calcular la huella
+	maxIdempotentBodyBytes = 1 << 20
+)
+
+// Idempotency permite reintentar escrituras con el header Idempotency-Key: la primera petición con una
+// clave se ejecuta y su respuesta se guarda por usuario durante ttl; las repeticiones reciben esa misma
+// respuesta sin volver a ejecutarse. Reusar la clave con otra petición responde 422.
+// Las respuestas 5xx no se guardan para que el cliente pueda reintentar. Con clave, el cuerpo no puede
+// superar 1 MiB (413).
+func Idempotency(store idempotencyServices.IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key no puede superar los 255 caracteres"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "El cuerpo de la petición no puede superar 1 MiB"})
+				c.Abort()
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el cuerpo de la petición"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		owner := c.GetString("username")
+		fingerprint := requestFingerprint(c.Request, body)
+		now := time.Now()
+		existing, err := store.Reserve(c.Request.Context(), models.IdempotencyKey{
+			Owner:       owner,
+			Key:         key,
+			Fingerprint: fingerprint,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar la Idempotency-Key"})
+			c.Abort()
+			return
+		}
+		if existing != nil {
+			replayResponse(c, existing, fingerprint)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		defer func() {
+			// si el handler entra en pánico la clave se libera para que el reintento no quede bloqueado
+			if r := recover(); r != nil {
+				_ = store.Release(c.Request.Context(), owner, key)
+				panic(r)
+			}
+		}()
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			_ = store.Release(c.Request.Context(), owner, key)
+			return
+		}
+		_ = store.Complete(c.Request.Context(), owner, key, status,
+			recorder.Header().Get("Content-Type"), recorder.Header().Get("ETag"), recorder.body.Bytes())
+	}
+}
+
+func isMutatingMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete
+}
+
+// requestFingerprint identifica la petición: método, ruta con query, If-Match y cuerpo.
+func requestFingerprint(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{req.Method, req.URL.RequestURI(), req.Header.Get("If-Match")} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replayResponse(c *gin.Context, entry *models.IdempotencyKey, fingerprint string) {
+	switch {
+	case entry.Fingerprint != fingerprint:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "La Idempotency-Key ya se usó con otra petición"})
+	case entry.Status == 0:
+		c.JSON(http.StatusConflict, gin.H{"error": "La petición original con esta Idempotency-Key todavía está en curso"})
+	default:
+		if entry.ETag != "" {
+			c.Header("ETag", entry.ETag)
+		}
+		c.Header(IdempotencyReplayedHeader, "true")
+		c.Data(entry.Status, entry.ContentType, entry.Body)
+	}
+	c.Abort()
+}
+
+// responseRecorder copia lo que escribe el handler para poder guardarlo.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	idempotencyServices "prueba_tecnica_go_guarapo/api/services/idempotency"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type call struct {
+		method         string
+		user           string
+		key            string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		replayed       bool
+	}
+
+	testScenarios := []struct {
+		testName      string
+		calls         []call
+		expectedCalls int
+	}{
+		{
+			testName: "La repetición devuelve la respuesta guardada",
+			calls: []call{
+				{method: http.MethodPost, user: "ana", key: "k1", body: `{"title":"a"}`, expectedStatus: http.StatusCreated, expectedBody: `"call":1`},
+				{method: http.MethodPost, user: "ana", key: "k1", body: `{"title":"a"}`, expectedStatus: http.StatusCreated, expectedBody: `"call":1`, replayed: true},
+			},
+			expectedCalls: 1,
+		},
+		{
+			testName: "La misma clave con otro cuerpo",
+			calls: []call{
+				{method: http.MethodPost, user: "ana", key: "k1", body: `{"title":"a"}`, expectedStatus: http.StatusCreated},
+				{method: http.MethodPost, user: "ana", key: "k1", body: `{"title":"b"}`, expectedStatus: http.StatusUnprocessableEntity, expectedBody: "La Idempotency-Key ya se usó con otra petición"},
+			},
+			expectedCalls: 1,
+		},
+		{
+			testName: "Las claves son por usuario",
+			calls: []call{
+				{method: http.MethodPost, user: "ana", key: "k1", body: `{}`, expectedStatus: http.StatusCreated, expectedBody: `"call":1`},
+				{method: http.MethodPost, user: "beto", key: "k1", body: `{}`, expectedStatus: http.StatusCreated, expectedBody: `"call":2`},
+			},
+			expectedCalls: 2,
+		},
+		{
+			testName: "Sin header no se guarda nada",
+			calls: []call{
+				{method: http.MethodPost, user: "ana", body: `{}`, expectedStatus: http.StatusCreated, expectedBody: `"call":1`},
+				{method: http.MethodPost, user: "ana", body: `{}`, expectedStatus: http.StatusCreated, expectedBody: `"call":2`},
+			},
+			expectedCalls: 2,
+		},
+		{
+			testName: "GET no es idempotente por clave",
+			calls: []call{
+				{method: http.MethodGet, user: "ana", key: "k1", expectedStatus: http.StatusOK, expectedBody: `"call":1`},
+				{method: http.MethodGet, user: "ana", key: "k1", expectedStatus: http.StatusOK, expectedBody: `"call":2`},
+			},
+			expectedCalls: 2,
+		},
+		{
+			testName: "Los errores del servidor no se guardan",
+			calls: []call{
+				{method: http.MethodPost, user: "ana", key: "falla", body: `{}`, expectedStatus: http.StatusInternalServerError},
+				{method: http.MethodPost, user: "ana", key: "falla", body: `{}`, expectedStatus: http.StatusInternalServerError},
+			},
+			expectedCalls: 2,
+		},
+		{
+			testName: "Clave demasiado larga",
+			calls: []call{
+				{method: http.MethodPost, user: "ana", key: strings.Repeat("k", 256), body: `{}`, expectedStatus: http.StatusBadRequest, expectedBody: "Idempotency-Key no puede superar los 255 caracteres"},
+			},
+			expectedCalls: 0,
+		},
+		{
+			testName: "Cuerpo demasiado grande",
+			calls: []call{
+				{method: http.MethodPost, user: "ana", key: "k1", body: strings.Repeat("a", maxIdempotentBodyBytes+1), expectedStatus: http.StatusRequestEntityTooLarge, expectedBody: "El cuerpo de la petición no puede superar 1 MiB"},
+				{method: http.MethodPost, user: "ana", key: "k1", body: `{}`, expectedStatus: http.StatusCreated, expectedBody: `"call":1`},
+			},
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			store := idempotencyServices.NewMemoryIdempotencyStore()
+			calls := 0
+			handler := func(c *gin.Context) {
+				calls++
+				if c.GetHeader(IdempotencyKeyHeader) == "falla" {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "fallo"})
+					return
+				}
+				status := http.StatusCreated
+				if c.Request.Method == http.MethodGet {
+					status = http.StatusOK
+				}
+				c.Header("ETag", `"1"`)
+				c.JSON(status, gin.H{"call": calls})
+			}
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", c.GetHeader("X-User"))
+				c.Next()
+			}, Idempotency(store, time.Hour))
+			router.POST("/tasks", handler)
+			router.GET("/tasks", handler)
+
+			for _, call := range tt.calls {
+				req, _ := http.NewRequest(call.method, "/tasks", strings.NewReader(call.body))
+				req.Header.Set("X-User", call.user)
+				if call.key != "" {
+					req.Header.Set(IdempotencyKeyHeader, call.key)
+				}
+				w := httptest.NewRecorder()
+
+				router.ServeHTTP(w, req)
+
+				assert.Equal(t, call.expectedStatus, w.Code)
+				assert.Contains(t, w.Body.String(), call.expectedBody)
+				if call.replayed {
+					assert.Equal(t, "true", w.Header().Get(IdempotencyReplayedHeader))
+					assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+					assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+				}
+			}
+			assert.Equal(t, tt.expectedCalls, calls)
+		})
+	}
+}
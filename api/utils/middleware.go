@@ -1,15 +1,49 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"net/http"
+	"prueba_tecnica_go_guarapo/api/models"
+	keyServices "prueba_tecnica_go_guarapo/api/services/apikey"
 	services "prueba_tecnica_go_guarapo/api/services/auth"
+	"regexp"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(authService services.AuthService) gin.HandlerFunc {
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern limita los IDs que se aceptan del cliente; cualquier otro se reemplaza por uno generado.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID identifica cada petición con el X-Request-ID del cliente o uno aleatorio. Lo deja en el
+// contexto como request_id y lo devuelve en la respuesta para poder cruzarlo con el historial y los logs.
+func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			bytes := make([]byte, 16)
+			_, _ = rand.Read(bytes)
+			id = hex.EncodeToString(bytes)
+		}
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// AuthMiddleware acepta un access token (Bearer) o una API key personal (X-API-Key).
+// En ambos casos deja el username en el contexto; rol y claims solo existen con token.
+func AuthMiddleware(authService services.AuthService, apiKeyService keyServices.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rawKey := c.GetHeader("X-API-Key"); rawKey != "" {
+			authenticateAPIKey(c, apiKeyService, rawKey)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header requerido"})
@@ -25,14 +59,95 @@ func AuthMiddleware(authService services.AuthService) gin.HandlerFunc {
 		}
 
 		token := parts[1]
-		username, valid := authService.ValidateToken(token)
-		if !valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token inválido"})
+		claims, err := authService.ValidateToken(c.Request.Context(), token)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrTokenExpired):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expirado"})
+			case errors.Is(err, services.ErrTokenMalformed):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token mal formado"})
+			case errors.Is(err, services.ErrTokenRevoked):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token revocado"})
+			case errors.Is(err, services.ErrTokenInvalid):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token inválido"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo validar el token"})
+			}
 			c.Abort()
 			return
 		}
 
-		c.Set("username", username)
+		// si falla solo se pierde la actividad de la sesión; el servicio ya lo registra
+		_ = authService.TouchSession(c.Request.Context(), claims.SessionID, services.ClientInfo{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+
+		c.Set("username", claims.Subject)
+		c.Set("role", claims.Role)
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+func authenticateAPIKey(c *gin.Context, apiKeyService keyServices.APIKeyService, rawKey string) {
+	key, err := apiKeyService.ValidateKey(c.Request.Context(), rawKey)
+	if err != nil {
+		switch {
+		case errors.Is(err, keyServices.ErrAPIKeyExpired):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API key expirada"})
+		case errors.Is(err, keyServices.ErrAPIKeyRevoked):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API key revocada"})
+		case errors.Is(err, keyServices.ErrAPIKeyInvalid):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API key inválida"})
+		case errors.Is(err, keyServices.ErrOwnerDisabled):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Cuenta deshabilitada"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo validar la API key"})
+		}
+		c.Abort()
+		return
+	}
+
+	if key.Scope == models.APIKeyScopeRead && !isReadOnlyMethod(c.Request.Method) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "La API key es de solo lectura"})
+		c.Abort()
+		return
+	}
+
+	c.Set("username", key.Username)
+	c.Set("api_key_scope", key.Scope)
+	c.Next()
+}
+
+func isReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// RequireSession bloquea las rutas que administran credenciales cuando la petición
+// llega con una API key; solo un login interactivo puede gestionarlas.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("claims"); !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Esta operación requiere iniciar sesión"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
+
+// RequireRole debe ir después de AuthMiddleware, que es quien deja el rol en el contexto.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permisos para este recurso"})
+		c.Abort()
+	}
+}
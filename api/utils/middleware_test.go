@@ -0,0 +1,367 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"prueba_tecnica_go_guarapo/api/models"
+	keyServices "prueba_tecnica_go_guarapo/api/services/apikey"
+	services "prueba_tecnica_go_guarapo/api/services/auth"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		authHeader     string
+		mockSetup      func(*mockAuthService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName:   "Token válido",
+			authHeader: "Bearer good",
+			mockSetup: func(m *mockAuthService) {
+				claims := &services.TokenClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "user1", ID: "jti1"}, SessionID: "session1"}
+				m.On("ValidateToken", mock.Anything, "good").Return(claims, nil)
+				m.On("TouchSession", mock.Anything, "session1", services.ClientInfo{IP: "192.0.2.1", UserAgent: "go-test"}).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"username":"user1"`,
+		},
+		{
+			testName:       "Sin header",
+			authHeader:     "",
+			mockSetup:      nil,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"Authorization header requerido"`,
+		},
+		{
+			testName:       "Formato inválido",
+			authHeader:     "Basic abc",
+			mockSetup:      nil,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"Formato de Authorization inválido"`,
+		},
+		{
+			testName:   "Token expirado",
+			authHeader: "Bearer expired",
+			mockSetup: func(m *mockAuthService) {
+				m.On("ValidateToken", mock.Anything, "expired").Return((*services.TokenClaims)(nil), services.ErrTokenExpired)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"Token expirado"`,
+		},
+		{
+			testName:   "Token mal formado",
+			authHeader: "Bearer garbage",
+			mockSetup: func(m *mockAuthService) {
+				m.On("ValidateToken", mock.Anything, "garbage").Return((*services.TokenClaims)(nil), services.ErrTokenMalformed)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"Token mal formado"`,
+		},
+		{
+			testName:   "Token revocado",
+			authHeader: "Bearer revoked",
+			mockSetup: func(m *mockAuthService) {
+				m.On("ValidateToken", mock.Anything, "revoked").Return((*services.TokenClaims)(nil), services.ErrTokenRevoked)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"Token revocado"`,
+		},
+		{
+			testName:   "Firma inválida",
+			authHeader: "Bearer forged",
+			mockSetup: func(m *mockAuthService) {
+				m.On("ValidateToken", mock.Anything, "forged").Return((*services.TokenClaims)(nil), services.ErrTokenInvalid)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"Token inválido"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockService := new(mockAuthService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockService)
+			}
+
+			router := gin.New()
+			router.Use(AuthMiddleware(mockService, new(mockAPIKeyService)))
+			router.GET("/private", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"username": c.GetString("username")})
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/private", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set("User-Agent", "go-test")
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthMiddleware_APIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		method         string
+		apiKey         string
+		mockSetup      func(*mockAPIKeyService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName: "Llave de lectura en GET",
+			method:   http.MethodGet,
+			apiKey:   "gk_read",
+			mockSetup: func(m *mockAPIKeyService) {
+				m.On("ValidateKey", mock.Anything, "gk_read").Return(&models.APIKey{Username: "user1", Scope: models.APIKeyScopeRead}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"username":"user1"`,
+		},
+		{
+			testName: "Llave de lectura en POST",
+			method:   http.MethodPost,
+			apiKey:   "gk_read",
+			mockSetup: func(m *mockAPIKeyService) {
+				m.On("ValidateKey", mock.Anything, "gk_read").Return(&models.APIKey{Username: "user1", Scope: models.APIKeyScopeRead}, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `"error":"La API key es de solo lectura"`,
+		},
+		{
+			testName: "Llave de escritura en POST",
+			method:   http.MethodPost,
+			apiKey:   "gk_write",
+			mockSetup: func(m *mockAPIKeyService) {
+				m.On("ValidateKey", mock.Anything, "gk_write").Return(&models.APIKey{Username: "user1", Scope: models.APIKeyScopeReadWrite}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"username":"user1"`,
+		},
+		{
+			testName: "Llave expirada",
+			method:   http.MethodGet,
+			apiKey:   "gk_expired",
+			mockSetup: func(m *mockAPIKeyService) {
+				m.On("ValidateKey", mock.Anything, "gk_expired").Return((*models.APIKey)(nil), keyServices.ErrAPIKeyExpired)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"API key expirada"`,
+		},
+		{
+			testName: "Llave revocada",
+			method:   http.MethodGet,
+			apiKey:   "gk_revoked",
+			mockSetup: func(m *mockAPIKeyService) {
+				m.On("ValidateKey", mock.Anything, "gk_revoked").Return((*models.APIKey)(nil), keyServices.ErrAPIKeyRevoked)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"API key revocada"`,
+		},
+		{
+			testName: "Llave desconocida",
+			method:   http.MethodGet,
+			apiKey:   "gk_unknown",
+			mockSetup: func(m *mockAPIKeyService) {
+				m.On("ValidateKey", mock.Anything, "gk_unknown").Return((*models.APIKey)(nil), keyServices.ErrAPIKeyInvalid)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `"error":"API key inválida"`,
+		},
+		{
+			testName: "Dueño deshabilitado",
+			method:   http.MethodGet,
+			apiKey:   "gk_disabled",
+			mockSetup: func(m *mockAPIKeyService) {
+				m.On("ValidateKey", mock.Anything, "gk_disabled").Return((*models.APIKey)(nil), keyServices.ErrOwnerDisabled)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `"error":"Cuenta deshabilitada"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockKeys := new(mockAPIKeyService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockKeys)
+			}
+
+			router := gin.New()
+			router.Use(AuthMiddleware(new(mockAuthService), mockKeys))
+			router.Handle(tt.method, "/private", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"username": c.GetString("username")})
+			})
+
+			req, _ := http.NewRequest(tt.method, "/private", nil)
+			req.Header.Set("X-API-Key", tt.apiKey)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockKeys.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		claims         *services.TokenClaims
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName:       "Con sesión",
+			claims:         &services.TokenClaims{SessionID: "session1"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"ok":true`,
+		},
+		{
+			testName:       "Con API key",
+			claims:         nil,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `"error":"Esta operación requiere iniciar sesión"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.claims != nil {
+					c.Set("claims", tt.claims)
+				}
+			})
+			router.Use(RequireSession())
+			router.GET("/keys", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"ok": true})
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/keys", nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName       string
+		role           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			testName:       "Admin autorizado",
+			role:           "admin",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"ok":true`,
+		},
+		{
+			testName:       "Usuario sin permisos",
+			role:           "user",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `"error":"No tienes permisos para este recurso"`,
+		},
+		{
+			testName:       "Sin rol",
+			role:           "",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `"error":"No tienes permisos para este recurso"`,
+		},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.role != "" {
+					c.Set("role", tt.role)
+				}
+			})
+			router.Use(RequireRole("admin"))
+			router.GET("/admin", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"ok": true})
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/admin", nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testScenarios := []struct {
+		testName  string
+		header    string
+		generated bool
+	}{
+		{testName: "Se respeta el del cliente", header: "req-123.abc_D"},
+		{testName: "Sin header se genera", header: "", generated: true},
+		{testName: "Header inválido se reemplaza", header: "con espacios y <html>", generated: true},
+	}
+
+	for _, tt := range testScenarios {
+		t.Run(tt.testName, func(t *testing.T) {
+			router := gin.New()
+			router.Use(RequestID())
+			router.GET("/private", func(c *gin.Context) {
+				c.String(http.StatusOK, c.GetString("request_id"))
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/private", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			assert.Equal(t, id, w.Body.String())
+			if tt.generated {
+				assert.Len(t, id, 32)
+			} else {
+				assert.Equal(t, tt.header, id)
+			}
+		})
+	}
+}